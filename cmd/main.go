@@ -23,6 +23,9 @@ var flagConfig = flag.String("config", "./config/local.yml", "path to the config
 // @license.name Toptal
 // @license.url https://toptal.com
 // @BasePath /api/v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func main() {
 
 	flag.Parse()
@@ -55,6 +58,14 @@ func main() {
 		cancel()
 	}()
 
+	// migrate up|down [steps]|status runs the migrations of the storage instead of the server
+	if args := flag.Args(); len(args) > 0 && args[0] == "migrate" {
+		if err := bootstrap.Migrate(ctx, cfg, args[1:], os.Stdout); err != nil {
+			log.Fatalf("migrate: %s\n", err)
+		}
+		os.Exit(0)
+	}
+
 	a := bootstrap.New(cfg, ctx)
 
 	a.Run(ctx)
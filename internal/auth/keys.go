@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// supported signing algorithms of access tokens
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown token signing algorithm")
+	ErrNoSigningKey     = errors.New("no key to sign tokens with")
+)
+
+// Keys - signing and verification keys of access tokens.
+type Keys struct {
+	method jwt.SigningMethod
+	sign   interface{}
+	verify interface{}
+}
+
+// NewKeys - HS256 uses the shared secret, RS256 the PEM encoded key pair.
+// The private key may be left empty when tokens are issued by another service.
+func NewKeys(algorithm, secret, privateKeyPEM, publicKeyPEM string) (*Keys, error) {
+	switch algorithm {
+	case HS256:
+		return &Keys{method: jwt.SigningMethodHS256, sign: []byte(secret), verify: []byte(secret)}, nil
+	case RS256:
+		keys := &Keys{method: jwt.SigningMethodRS256}
+
+		public, err := jwt.ParseRSAPublicKeyFromPEM([]byte(publicKeyPEM))
+		if err != nil {
+			return nil, err
+		}
+		keys.verify = public
+
+		if privateKeyPEM != "" {
+			private, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
+			if err != nil {
+				return nil, err
+			}
+			keys.sign = private
+		}
+
+		return keys, nil
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+}
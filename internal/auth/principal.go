@@ -0,0 +1,23 @@
+package auth
+
+import "context"
+
+// Principal - the authenticated user making the request.
+type Principal struct {
+	// UserID - public t2_ prefixed id of the user
+	UserID   string `json:"user_id"`
+	Username string `json:"username,omitempty"`
+}
+
+type contextKey struct{}
+
+// NewContext - returns a copy of ctx carrying the principal.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext - returns the principal of the request, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	return p, ok && p != nil
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"time"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims - claims of an access token, the subject is the user id.
+type Claims struct {
+	Username string `json:"name,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Issuer - signs access tokens for authenticated users.
+type Issuer struct {
+	keys *Keys
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+func NewIssuer(keys *Keys, ttl time.Duration) *Issuer {
+	return &Issuer{
+		keys: keys,
+		ttl:  ttl,
+		now:  time.Now,
+	}
+}
+
+// Issue - returns a signed token for the principal and its expiry.
+func (i *Issuer) Issue(p *Principal) (string, time.Time, error) {
+	if i.keys.sign == nil {
+		return "", time.Time{}, ErrNoSigningKey
+	}
+
+	now := i.now()
+	expiresAt := now.Add(i.ttl)
+
+	claims := Claims{
+		Username: p.Username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   p.UserID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(i.keys.method, claims).SignedString(i.keys.sign)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// Verifier - validates access tokens.
+type Verifier struct {
+	keys   *Keys
+	parser *jwt.Parser
+}
+
+func NewVerifier(keys *Keys) *Verifier {
+	return &Verifier{
+		keys: keys,
+		// only the configured algorithm is accepted, a RS256 public key must never be used as a HS256 secret
+		parser: jwt.NewParser(jwt.WithValidMethods([]string{keys.method.Alg()})),
+	}
+}
+
+// Verify - checks the signature and expiry of the token and returns its principal.
+func (v *Verifier) Verify(token string) (*Principal, error) {
+	claims := &Claims{}
+
+	_, err := v.parser.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return v.keys.verify, nil
+	})
+	if err != nil || claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+
+	return &Principal{UserID: claims.Subject, Username: claims.Username}, nil
+}
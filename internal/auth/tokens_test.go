@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssuer_Issue(t *testing.T) {
+
+	keys, err := NewKeys(HS256, "secret", "", "")
+	require.NoError(t, err)
+
+	issuer := NewIssuer(keys, time.Hour)
+	now := time.Now().Truncate(time.Second)
+	issuer.now = func() time.Time { return now }
+
+	token, expiresAt, err := issuer.Issue(&Principal{UserID: "t2_abcdefgh", Username: "gopher"})
+	require.NoError(t, err)
+	require.Equal(t, now.Add(time.Hour), expiresAt)
+
+	p, err := NewVerifier(keys).Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, &Principal{UserID: "t2_abcdefgh", Username: "gopher"}, p)
+}
+
+func TestVerifier_Verify(t *testing.T) {
+
+	hs, err := NewKeys(HS256, "secret", "", "")
+	require.NoError(t, err)
+
+	private, public := rsaPEM(t)
+	rs, err := NewKeys(RS256, "", private, public)
+	require.NoError(t, err)
+
+	principal := &Principal{UserID: "t2_abcdefgh"}
+
+	t.Run("rs256", func(t *testing.T) {
+		token, _, err := NewIssuer(rs, time.Hour).Issue(principal)
+		require.NoError(t, err)
+
+		p, err := NewVerifier(rs).Verify(token)
+		require.NoError(t, err)
+		require.Equal(t, principal, p)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		issuer := NewIssuer(hs, time.Hour)
+		issuer.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+
+		token, _, err := issuer.Issue(principal)
+		require.NoError(t, err)
+
+		_, err = NewVerifier(hs).Verify(token)
+		require.Equal(t, ErrInvalidToken, err)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		other, err := NewKeys(HS256, "other", "", "")
+		require.NoError(t, err)
+
+		token, _, err := NewIssuer(other, time.Hour).Issue(principal)
+		require.NoError(t, err)
+
+		_, err = NewVerifier(hs).Verify(token)
+		require.Equal(t, ErrInvalidToken, err)
+	})
+
+	t.Run("algorithm mismatch", func(t *testing.T) {
+		token, _, err := NewIssuer(hs, time.Hour).Issue(principal)
+		require.NoError(t, err)
+
+		_, err = NewVerifier(rs).Verify(token)
+		require.Equal(t, ErrInvalidToken, err)
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		_, err := NewVerifier(hs).Verify("not.a.token")
+		require.Equal(t, ErrInvalidToken, err)
+	})
+
+	t.Run("verify only keys cannot issue", func(t *testing.T) {
+		verifyOnly, err := NewKeys(RS256, "", "", public)
+		require.NoError(t, err)
+
+		_, _, err = NewIssuer(verifyOnly, time.Hour).Issue(principal)
+		require.Equal(t, ErrNoSigningKey, err)
+	})
+}
+
+func TestNewKeys(t *testing.T) {
+
+	_, err := NewKeys("none", "", "", "")
+	require.Equal(t, ErrUnknownAlgorithm, err)
+
+	_, err = NewKeys(RS256, "", "", "not a pem")
+	require.Error(t, err)
+}
+
+func TestFromContext(t *testing.T) {
+
+	_, ok := FromContext(context.Background())
+	require.False(t, ok)
+
+	p := &Principal{UserID: "t2_abcdefgh"}
+	got, ok := FromContext(NewContext(context.Background(), p))
+	require.True(t, ok)
+	require.Equal(t, p, got)
+}
+
+// rsaPEM - generates a PEM encoded RSA key pair
+func rsaPEM(t *testing.T) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	private := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return string(private), string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}))
+}
@@ -10,9 +10,14 @@ import (
 	"time"
 
 	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/aliykh/reddit-feed/internal/health"
 	"github.com/aliykh/reddit-feed/internal/http/server"
+	"github.com/aliykh/reddit-feed/internal/metrics"
+	"github.com/aliykh/reddit-feed/internal/posts/snapshot"
+	"github.com/aliykh/reddit-feed/internal/tracing"
 
 	"github.com/aliykh/log"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
@@ -27,12 +32,21 @@ type App struct {
 	//	http server
 	http *http.Server
 
+	// worker of the hot feed snapshots, nil when they are disabled
+	snapshots *snapshot.Worker
+
 	//	log
 	log *log.Factory
 
-	// mongodb client
+	// mongodb client, nil unless the storage is mongo
 	mongoClient *mongo.Client
 
+	// postgres pool, nil unless the storage is postgres
+	postgresPool *pgxpool.Pool
+
+	// readiness of the app, fed by the mongo heartbeats or pinging postgres
+	health *health.Checker
+
 	//	tearDowns -> for graceful shutdown
 	tearDowns []func()
 }
@@ -43,10 +57,30 @@ func New(cfg *config.Config, ctx context.Context) *App {
 
 	app.log = log.NewFactory(log.ZapLogger, cfg.LogLevel)
 
-	// todo mongodb init
-	if err := app.initMongoDb(ctx); err != nil {
-		app.compensate()
-		app.log.Default().Fatal("mongodb init", zap.String("err", err.Error()))
+	if err := app.initTracing(ctx); err != nil {
+		app.log.Default().Fatal("tracing init", zap.String("err", err.Error()))
+	}
+
+	switch cfg.Storage {
+	case "memory":
+		app.initMemory()
+	case "postgres":
+		if err := app.initPostgres(ctx); err != nil {
+			app.compensate()
+			app.log.Default().Fatal("postgres init", zap.String("err", err.Error()))
+		}
+	default:
+		if err := app.initMongoDb(ctx); err != nil {
+			app.compensate()
+			app.log.Default().Fatal("mongodb init", zap.String("err", err.Error()))
+		}
+	}
+
+	if cfg.MigrateOnStartup && cfg.Storage != "memory" {
+		if err := app.migrateUp(ctx); err != nil {
+			app.compensate()
+			app.log.Default().Fatal("migrations", zap.String("err", err.Error()))
+		}
 	}
 
 	if err := app.initHTTPServer(); err != nil {
@@ -54,44 +88,62 @@ func New(cfg *config.Config, ctx context.Context) *App {
 		app.log.Default().Fatal("http server init", zap.String("err", err.Error()))
 	}
 
+	app.startSnapshots()
+
 	return app
 }
 
+// initTracing installs the tracer provider, the pending spans are flushed on shutdown.
+func (a *App) initTracing(ctx context.Context) error {
+	shutdown, err := tracing.Init(ctx, a.config.Tracing)
+	if err != nil {
+		return err
+	}
+
+	a.tearDowns = append(a.tearDowns, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+		defer cancel()
+
+		if err := shutdown(ctx); err != nil {
+			a.log.Default().Debug("tracer provider shutdown", zap.String("err", err.Error()))
+		}
+	})
+
+	return nil
+}
+
+// initMemory - the repositories keep the data in memory, there is no database to check for readiness.
+func (a *App) initMemory() {
+	a.health = health.New(nil, time.Second*2)
+
+	a.log.Default().Info("the data is kept in memory, it is lost on restart")
+}
+
 func (a *App) initMongoDb(ctx context.Context) error {
 
-	//startedCommands := make(map[int64]bson.Raw)
-	//cmdMonitor := &event.CommandMonitor{
-	//	Started: func(_ context.Context, evt *event.CommandStartedEvent) {
-	//		startedCommands[evt.RequestID] = evt.Command
-	//	},
-	//	Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
-	//		a.log.Default().Debug(fmt.Sprintf("Command: %v Reply: %v\n",
-	//			startedCommands[evt.RequestID].String(),
-	//			evt.Reply.String()),
-	//		)
-	//	},
-	//	Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
-	//		a.log.Default().Debug(fmt.Sprintf("Command: %v Failure: %v\n",
-	//			startedCommands[evt.RequestID].String(),
-	//			evt.Failure,
-	//		))
-	//	},
-	//}
+	// the client is set below, readiness is not checked before the http server runs
+	a.health = health.New(func(ctx context.Context) error {
+		return a.mongoClient.Ping(ctx, readpref.Primary())
+	}, time.Second*2)
 
 	heartBeatFailEvent := &event.ServerMonitor{
 		ServerHeartbeatFailed: func(failedEvent *event.ServerHeartbeatFailedEvent) {
+			a.health.HeartbeatFailed(failedEvent.Failure)
 			a.log.Default().Error(fmt.Sprintf("mongo db server is not responding, cause: %s", failedEvent.Failure.Error()))
 		},
 		ServerHeartbeatStarted: func(startedEvent *event.ServerHeartbeatStartedEvent) {
 			a.log.Default().Debug(fmt.Sprintf("checking mongo db server %v", startedEvent.ConnectionID))
 		},
 		ServerHeartbeatSucceeded: func(succeededEvent *event.ServerHeartbeatSucceededEvent) {
+			a.health.HeartbeatSucceeded()
 			a.log.Default().Debug(fmt.Sprintf("mongo db server is online %v", succeededEvent.ConnectionID))
 		},
 	}
 
 	clientOpts := options.Client().SetHeartbeatInterval(time.Second * 30)
-	clientOpts.ApplyURI(a.config.MongoAddr).SetServerMonitor(heartBeatFailEvent) //.SetMonitor(cmdMonitor)
+	clientOpts.ApplyURI(a.config.MongoAddr).SetServerMonitor(heartBeatFailEvent)
+	// command latency, failures and pool usage are exposed on /metrics, every command is traced as well
+	clientOpts.SetMonitor(combineMonitors(metrics.CommandMonitor(), tracing.CommandMonitor())).SetPoolMonitor(metrics.PoolMonitor())
 	clientOpts.SetMaxPoolSize(20)
 
 	client, err := mongo.Connect(ctx, clientOpts)
@@ -125,15 +177,55 @@ func (a *App) initMongoDb(ctx context.Context) error {
 
 	a.mongoClient = client
 
-	// todo add health checker for db connection availability and also for the case when the mongo db shuts down unexpectedly
-
 	return err
 }
 
+// initPostgres - connection pool of the postgres storage, readiness pings postgres. There are no heartbeats
+// like those of mongo, the pool checks its idle connections by itself.
+func (a *App) initPostgres(ctx context.Context) error {
+	a.health = health.New(nil, time.Second*2)
+
+	poolConfig, err := pgxpool.ParseConfig(a.config.PostgresAddr)
+	if err != nil {
+		return err
+	}
+
+	poolConfig.MaxConns = a.config.Postgres.MaxConns
+	poolConfig.MinConns = a.config.Postgres.MinConns
+	poolConfig.MaxConnLifetime = a.config.Postgres.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = a.config.Postgres.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = a.config.Postgres.HealthCheckPeriod
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*8)
+	defer cancel()
+
+	pool, err := pgxpool.ConnectConfig(ctx, poolConfig)
+	if err != nil {
+		return err
+	}
+
+	// the pool connects lazily, the ping fails the start when postgres is not reachable
+	if err = pool.Ping(ctx); err != nil {
+		pool.Close()
+		return err
+	}
+
+	a.tearDowns = append(a.tearDowns, func() {
+		pool.Close()
+		a.log.Default().Info("postgres pool shutdown")
+	})
+
+	a.health.AddCheck("postgres", pool.Ping)
+
+	a.postgresPool = pool
+
+	return nil
+}
+
 // initHTTPServer initializes http server.
 func (app *App) initHTTPServer() error {
 
-	hs, err := server.New(app.config, app.log, app.mongoClient)
+	hs, err := server.New(app.config, app.log, app.mongoClient, app.postgresPool, app.health)
 
 	if err != nil {
 		return err
@@ -148,9 +240,64 @@ func (app *App) initHTTPServer() error {
 		IdleTimeout:  1 * time.Minute,
 	}
 
+	// open event streams would otherwise hold the shutdown until it times out
+	app.http.RegisterOnShutdown(hs.Shutdown)
+
+	app.snapshots = hs.SnapshotWorker()
+
 	return nil
 }
 
+// startSnapshots - refreshes the hot feed snapshots in the background. The worker is stopped before the
+// databases are disconnected, a refresh in progress is cancelled.
+func (app *App) startSnapshots() {
+	if app.snapshots == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		app.snapshots.Run(ctx)
+	}()
+
+	stop := func() {
+		cancel()
+		<-done
+		app.log.Default().Info("feed snapshots worker shutdown")
+	}
+	app.tearDowns = append([]func(){stop}, app.tearDowns...)
+}
+
+// combineMonitors - the driver accepts a single command monitor, the returned one calls all the given ones.
+func combineMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, evt)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, evt)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, evt)
+				}
+			}
+		},
+	}
+}
+
 func (app *App) compensate() {
 	for _, v := range app.tearDowns {
 		v()
@@ -172,6 +319,11 @@ func (app *App) Run(ctx context.Context) {
 
 	<-ctx.Done()
 
+	// fail readiness first, so load balancers stop routing to us before the server closes
+	app.health.Drain()
+	app.log.Default().Info(fmt.Sprintf("draining for %v", app.config.DrainDelay))
+	time.Sleep(app.config.DrainDelay)
+
 	ctxShutDown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer func() {
 		cancel()
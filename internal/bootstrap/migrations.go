@@ -0,0 +1,140 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/aliykh/reddit-feed/internal/migrations"
+	embedded "github.com/aliykh/reddit-feed/migrations"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"strconv"
+	"time"
+)
+
+// migrateTimeout - longer than the lock ttl, so the lock of a crashed instance expires while waiting for it
+const migrateTimeout = time.Minute * 10
+
+var (
+	ErrUnknownMigrateCommand = errors.New("unknown migrate command, expected up, down [steps] or status")
+	ErrMigrateMemory         = errors.New("migrations only apply to the mongo and postgres storages")
+)
+
+// migrationRunner - runner of the embedded migrations against the app database.
+func (a *App) migrationRunner() (*migrations.Runner, error) {
+	loaded, err := migrations.Load(embedded.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	return migrations.NewRunner(a.log, migrations.NewMongoStore(a.mongoClient, a.config.DatabaseName), loaded), nil
+}
+
+// postgresMigrationRunner - runner of the embedded sql migrations against the postgres storage.
+func (a *App) postgresMigrationRunner() (*migrations.Runner, error) {
+	fsys, err := embedded.Postgres()
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, err := migrations.Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return migrations.NewRunner(a.log, migrations.NewPostgresStore(a.postgresPool), loaded), nil
+}
+
+// storageMigrationRunner - runner of the migrations of the configured storage.
+func (a *App) storageMigrationRunner() (*migrations.Runner, error) {
+	if a.config.Storage == "postgres" {
+		return a.postgresMigrationRunner()
+	}
+	return a.migrationRunner()
+}
+
+// migrateUp applies the pending migrations before the app starts serving.
+func (a *App) migrateUp(ctx context.Context) error {
+	runner, err := a.storageMigrationRunner()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, migrateTimeout)
+	defer cancel()
+
+	count, err := runner.Up(ctx)
+	if err != nil {
+		return err
+	}
+
+	a.log.Default().Info("migrations are up to date", zap.Int("applied", count))
+
+	return nil
+}
+
+// Migrate runs the migrate command: up, down [steps] or status. The status is written to out.
+// The commands run the migrations of the configured storage, the json ones of mongo or the sql ones of postgres.
+func Migrate(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	if cfg.Storage == "memory" {
+		return ErrMigrateMemory
+	}
+
+	app := &App{config: cfg}
+	app.log = log.NewFactory(log.ZapLogger, cfg.LogLevel)
+
+	initStorage := app.initMongoDb
+	if cfg.Storage == "postgres" {
+		initStorage = app.initPostgres
+	}
+
+	if err := initStorage(ctx); err != nil {
+		return err
+	}
+	defer app.compensate()
+
+	runner, err := app.storageMigrationRunner()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return ErrUnknownMigrateCommand
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := runner.Up(ctx)
+		fmt.Fprintf(out, "applied %d migrations\n", count)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return errors.Errorf("invalid steps %q", args[1])
+			}
+		}
+
+		count, err := runner.Down(ctx, steps)
+		fmt.Fprintf(out, "reverted %d migrations\n", count)
+		return err
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(out, "%03d_%s\t%s\n", s.Version, s.Name, state)
+		}
+		return nil
+	default:
+		return ErrUnknownMigrateCommand
+	}
+}
@@ -0,0 +1,10 @@
+package comments
+
+import "github.com/gin-gonic/gin"
+
+type Handlers interface {
+	Create(c *gin.Context)
+	ListByPost(c *gin.Context)
+	Reply(c *gin.Context)
+	ListReplies(c *gin.Context)
+}
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"fmt"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/comments"
+	"github.com/aliykh/reddit-feed/internal/comments/models"
+	"github.com/aliykh/reddit-feed/internal/http/server/helpers"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type handlers struct {
+	logger *log.Factory
+	uc     comments.UseCase
+}
+
+func New(logger *log.Factory, uc comments.UseCase) *handlers {
+	return &handlers{
+		logger: logger,
+		uc:     uc,
+	}
+}
+
+// Create godoc
+// @Summary Create - comments on a post
+// @Description adds a top level comment to the post
+// @Tags Comments
+// @Param id path string true "post id"
+// @Security BearerAuth
+// @Param params body models.Comment true "body"
+// @Accept json
+// @Produce json
+// @Success 201 {object} models.Comment
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 401 {object} customErrors.ErrorResponse
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Router /post/{id}/comments [POST]
+func (h *handlers) Create(c *gin.Context) {
+
+	model, id, ok := h.bindComment(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.uc.Create(c.Request.Context(), id, model)
+	if err != nil {
+		h.logger.For(c.Request.Context()).Error("comment create", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondCreated(c, result)
+}
+
+// ListByPost godoc
+// @Summary ListByPost - lists the comments of a post
+// @Description returns the top level comments of the post, replies are listed per comment
+// @Tags Comments
+// @Param id path string true "post id"
+// @Param page query int false "page number"
+// @Param size query int false "page size, max 25"
+// @Param sort query string false "order: top (default), new or old"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.CommentList
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Router /post/{id}/comments [GET]
+func (h *handlers) ListByPost(c *gin.Context) {
+
+	id, params, pg, ok := h.bindList(c)
+	if !ok {
+		return
+	}
+
+	res, err := h.uc.ListByPost(c.Request.Context(), id, params, pg)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, res)
+}
+
+// Reply godoc
+// @Summary Reply - replies to a comment
+// @Description adds a reply under the comment, in the same post
+// @Tags Comments
+// @Param id path string true "comment id"
+// @Security BearerAuth
+// @Param params body models.Comment true "body"
+// @Accept json
+// @Produce json
+// @Success 201 {object} models.Comment
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 401 {object} customErrors.ErrorResponse
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Router /comment/{id}/replies [POST]
+func (h *handlers) Reply(c *gin.Context) {
+
+	model, id, ok := h.bindComment(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.uc.Reply(c.Request.Context(), id, model)
+	if err != nil {
+		h.logger.For(c.Request.Context()).Error("comment reply", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondCreated(c, result)
+}
+
+// ListReplies godoc
+// @Summary ListReplies - lists the replies of a comment
+// @Description returns the direct replies of the comment
+// @Tags Comments
+// @Param id path string true "comment id"
+// @Param page query int false "page number"
+// @Param size query int false "page size, max 25"
+// @Param sort query string false "order: top (default), new or old"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.CommentList
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Router /comment/{id}/replies [GET]
+func (h *handlers) ListReplies(c *gin.Context) {
+
+	id, params, pg, ok := h.bindList(c)
+	if !ok {
+		return
+	}
+
+	res, err := h.uc.ListReplies(c.Request.Context(), id, params, pg)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, res)
+}
+
+// bindComment - parses the id uri param, the commenting user and the comment body, responds on failure.
+func (h *handlers) bindComment(c *gin.Context) (*models.Comment, primitive.ObjectID, bool) {
+
+	id, err := parseID(c)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return nil, id, false
+	}
+
+	user, err := helpers.UserID(c)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return nil, id, false
+	}
+
+	model := &models.Comment{}
+
+	// go-validator validations
+	if err = c.ShouldBindJSON(model); err != nil {
+		h.logger.For(c.Request.Context()).Error(fmt.Sprintf("error while binding json body: %v\n", err.Error()))
+		helpers.RespondError(c, err)
+		return nil, id, false
+	}
+
+	model.Author = user
+
+	return model, id, true
+}
+
+// bindList - parses the id uri param and the listing query, responds on failure.
+func (h *handlers) bindList(c *gin.Context) (primitive.ObjectID, *models.ListParams, *pagination.Query, bool) {
+
+	id, err := parseID(c)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return id, nil, nil, false
+	}
+
+	pg := &pagination.Query{
+		Size: 25,
+	}
+
+	if err = c.ShouldBindQuery(pg); err != nil {
+		h.logger.For(c.Request.Context()).Error("pagination query binding err", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return id, nil, nil, false
+	}
+
+	params := &models.ListParams{}
+
+	if err = c.ShouldBindQuery(params); err != nil {
+		h.logger.For(c.Request.Context()).Error("comment list params binding err", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return id, nil, nil, false
+	}
+
+	return id, params, pg, true
+}
+
+// parseID - parses the id uri param as mongo ObjectID
+func parseID(c *gin.Context) (primitive.ObjectID, error) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return primitive.NilObjectID, customErrors.New(http.StatusBadRequest, customErrors.InvalidUriParam)
+	}
+	return id, nil
+}
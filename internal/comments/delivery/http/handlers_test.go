@@ -0,0 +1,203 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/auth"
+	"github.com/aliykh/reddit-feed/internal/comments/mock"
+	"github.com/aliykh/reddit-feed/internal/comments/models"
+	"github.com/aliykh/reddit-feed/internal/http/middleware"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/helpers"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/aliykh/reddit-feed/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"testing"
+)
+
+func init() {
+
+	binding.Validator = new(helpers.DefaultValidator)
+
+	engine := binding.Validator.Engine().(*validator.Validate)
+
+	eng := en.New()
+	uni := ut.New(eng, eng)
+	customErrors.Trans, _ = uni.GetTranslator("en")
+	_ = en_translations.RegisterDefaultTranslations(engine, customErrors.Trans)
+
+}
+
+func TestHandlers_Create(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCommentUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	commentHandlers := New(logger, mockCommentUC)
+
+	router := gin.Default()
+	router.Use(middleware.Authenticate(auth.NewVerifier(utils.TestKeys)))
+	RegisterHandlers(router.Group(""), commentHandlers)
+
+	id := primitive.NewObjectID()
+
+	create := func(body interface{}, user string) *utils.Response {
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/post/"+id.Hex()+"/comments", body)
+		require.NoError(t, err)
+
+		if user != "" {
+			req.Header.Set("Authorization", utils.Bearer(utils.TestKeys, user))
+		}
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		model := &models.Comment{Id: primitive.NewObjectID().Hex(), PostId: id.Hex(), Author: "t2_user", Body: "first"}
+
+		mockCommentUC.EXPECT().Create(gomock.Any(), id, &models.Comment{Author: "t2_user", Body: "first"}).Return(model, nil)
+
+		resp := create(&models.Comment{Body: "first"}, "t2_user")
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		data := &models.Comment{}
+		err := json.Unmarshal(resp.Body, &data)
+		require.NoError(t, err)
+		require.Equal(t, model, data)
+	})
+
+	t.Run("no user", func(t *testing.T) {
+		resp := create(&models.Comment{Body: "first"}, "")
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		resp := create(&models.Comment{}, "t2_user")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("post not found", func(t *testing.T) {
+		expectedErr := customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		mockCommentUC.EXPECT().Create(gomock.Any(), id, gomock.Any()).Return(nil, expectedErr)
+
+		resp := create(&models.Comment{Body: "first"}, "t2_user")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestHandlers_Reply(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCommentUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	commentHandlers := New(logger, mockCommentUC)
+
+	router := gin.Default()
+	router.Use(middleware.Authenticate(auth.NewVerifier(utils.TestKeys)))
+	RegisterHandlers(router.Group(""), commentHandlers)
+
+	parent := primitive.NewObjectID()
+
+	t.Run("ok", func(t *testing.T) {
+		model := &models.Comment{Id: primitive.NewObjectID().Hex(), ParentId: parent.Hex(), Path: parent.Hex() + ",", Depth: 1, Author: "t2_user", Body: "reply"}
+
+		mockCommentUC.EXPECT().Reply(gomock.Any(), parent, &models.Comment{Author: "t2_user", Body: "reply"}).Return(model, nil)
+
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/comment/"+parent.Hex()+"/replies", &models.Comment{Body: "reply"})
+		require.NoError(t, err)
+		req.Header.Set("Authorization", utils.Bearer(utils.TestKeys, "t2_user"))
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		data := &models.Comment{}
+		err = json.Unmarshal(resp.Body, &data)
+		require.NoError(t, err)
+		require.Equal(t, model, data)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/comment/1234/replies", &models.Comment{Body: "reply"})
+		require.NoError(t, err)
+		req.Header.Set("Authorization", utils.Bearer(utils.TestKeys, "t2_user"))
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestHandlers_List(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCommentUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	commentHandlers := New(logger, mockCommentUC)
+
+	router := gin.Default()
+	router.Use(middleware.Authenticate(auth.NewVerifier(utils.TestKeys)))
+	RegisterHandlers(router.Group(""), commentHandlers)
+
+	id := primitive.NewObjectID()
+
+	list := &models.CommentList{TotalCount: 1, TotalPages: 1, Page: 1, Size: 10, Comments: []*models.Comment{{Id: primitive.NewObjectID().Hex(), Body: "first"}}}
+
+	t.Run("by post", func(t *testing.T) {
+		mockCommentUC.EXPECT().ListByPost(context.Background(), id, &models.ListParams{Sort: models.SortNew}, &pagination.Query{Page: 1, Size: 10}).Return(list, nil)
+
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/post/"+id.Hex()+"/comments?page=1&size=10&sort=new", nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		data := &models.CommentList{}
+		err = json.Unmarshal(resp.Body, &data)
+		require.NoError(t, err)
+		require.Equal(t, list, data)
+	})
+
+	t.Run("replies", func(t *testing.T) {
+		mockCommentUC.EXPECT().ListReplies(context.Background(), id, &models.ListParams{}, &pagination.Query{Size: 25}).Return(list, nil)
+
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/comment/"+id.Hex()+"/replies", nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("invalid sort", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/post/"+id.Hex()+"/comments?sort=best", nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,18 @@
+package http
+
+import (
+	"github.com/aliykh/reddit-feed/internal/comments"
+	"github.com/aliykh/reddit-feed/internal/http/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterHandlers(router *gin.RouterGroup, handlers comments.Handlers) {
+
+	router.POST("/post/:id/comments", middleware.RequireAuth(), handlers.Create)
+	router.GET("/post/:id/comments", handlers.ListByPost)
+
+	r1Group := router.Group("/comment")
+	r1Group.POST("/:id/replies", middleware.RequireAuth(), handlers.Reply)
+	r1Group.GET("/:id/replies", handlers.ListReplies)
+
+}
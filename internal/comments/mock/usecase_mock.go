@@ -0,0 +1,98 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: usecase.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/aliykh/reddit-feed/internal/comments/models"
+	pagination "github.com/aliykh/reddit-feed/pkg/pagination"
+	gomock "github.com/golang/mock/gomock"
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MockUseCase is a mock of UseCase interface.
+type MockUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockUseCaseMockRecorder
+}
+
+// MockUseCaseMockRecorder is the mock recorder for MockUseCase.
+type MockUseCaseMockRecorder struct {
+	mock *MockUseCase
+}
+
+// NewMockUseCase creates a new mock instance.
+func NewMockUseCase(ctrl *gomock.Controller) *MockUseCase {
+	mock := &MockUseCase{ctrl: ctrl}
+	mock.recorder = &MockUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUseCase) EXPECT() *MockUseCaseMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockUseCase) Create(ctx context.Context, post primitive.ObjectID, model *models.Comment) (*models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, post, model)
+	ret0, _ := ret[0].(*models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockUseCaseMockRecorder) Create(ctx, post, model interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUseCase)(nil).Create), ctx, post, model)
+}
+
+// ListByPost mocks base method.
+func (m *MockUseCase) ListByPost(ctx context.Context, post primitive.ObjectID, params *models.ListParams, query *pagination.Query) (*models.CommentList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByPost", ctx, post, params, query)
+	ret0, _ := ret[0].(*models.CommentList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByPost indicates an expected call of ListByPost.
+func (mr *MockUseCaseMockRecorder) ListByPost(ctx, post, params, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByPost", reflect.TypeOf((*MockUseCase)(nil).ListByPost), ctx, post, params, query)
+}
+
+// ListReplies mocks base method.
+func (m *MockUseCase) ListReplies(ctx context.Context, parent primitive.ObjectID, params *models.ListParams, query *pagination.Query) (*models.CommentList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReplies", ctx, parent, params, query)
+	ret0, _ := ret[0].(*models.CommentList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReplies indicates an expected call of ListReplies.
+func (mr *MockUseCaseMockRecorder) ListReplies(ctx, parent, params, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReplies", reflect.TypeOf((*MockUseCase)(nil).ListReplies), ctx, parent, params, query)
+}
+
+// Reply mocks base method.
+func (m *MockUseCase) Reply(ctx context.Context, parent primitive.ObjectID, model *models.Comment) (*models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reply", ctx, parent, model)
+	ret0, _ := ret[0].(*models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reply indicates an expected call of Reply.
+func (mr *MockUseCaseMockRecorder) Reply(ctx, parent, model interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reply", reflect.TypeOf((*MockUseCase)(nil).Reply), ctx, parent, model)
+}
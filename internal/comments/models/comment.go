@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// sort orders of comment listings
+const (
+	SortTop = "top"
+	SortNew = "new"
+	SortOld = "old"
+)
+
+type CommentList struct {
+	TotalCount int64      `json:"total_count"`
+	TotalPages int        `json:"total_pages"`
+	Page       int        `json:"page"`
+	Size       int        `json:"size"`
+	HasMore    bool       `json:"has_more"`
+	Comments   []*Comment `json:"comments"`
+}
+
+// ListParams - ordering of a comment listing, top by default.
+type ListParams struct {
+	Sort string `json:"sort,omitempty" form:"sort" binding:"omitempty,oneof=top new old"`
+}
+
+type Comment struct {
+	Id       string `json:"id" bson:"_id,omitempty"`
+	PostId   string `json:"post_id" bson:"post_id"`
+	ParentId string `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	// Path - materialized path of the ancestors' ids, each followed by a comma, empty for top level comments
+	Path      string    `json:"path" bson:"path"`
+	Depth     int       `json:"depth" bson:"depth"`
+	Author    string    `json:"author" bson:"author"`
+	Body      string    `json:"body" bson:"body" binding:"required"`
+	Score     int       `json:"score" bson:"score"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// ReplyTo - places the comment under the given parent in the thread.
+func (c *Comment) ReplyTo(parent *Comment) {
+	c.PostId = parent.PostId
+	c.ParentId = parent.Id
+	c.Path = parent.Path + parent.Id + ","
+	c.Depth = parent.Depth + 1
+}
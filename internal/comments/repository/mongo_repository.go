@@ -0,0 +1,125 @@
+//go:generate mockgen -source mongo_repository.go -destination mock/repository_mock.go -package mock
+package repository
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/comments/models"
+	"github.com/aliykh/reddit-feed/internal/driver/db"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+const (
+	collectionName = "comments"
+)
+
+type Repository interface {
+	Create(context.Context, *models.Comment) (*models.Comment, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error)
+	CountDocuments(ctx context.Context, filter bson.D) (int64, error)
+	FindAll(ctx context.Context, filter bson.D, sort string, query *pagination.Query) ([]*models.Comment, error)
+}
+
+type repo struct {
+	logger     *log.Factory
+	collection db.Collection
+}
+
+func New(logger *log.Factory, collection db.Collection) *repo {
+	return &repo{
+		logger:     logger,
+		collection: collection,
+	}
+}
+
+func (r *repo) Create(ctx context.Context, m *models.Comment) (*models.Comment, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	res, err := r.collection.InsertOne(ctx, m)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "CommentMongoRepo.Create.InsertOne")
+	}
+
+	result := &models.Comment{}
+
+	if err = r.collection.FindOne(ctx, bson.D{{"_id", res.InsertedID}}, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	result := &models.Comment{}
+	if err := r.collection.FindOne(ctx, bson.D{{"_id", id}}, result); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		}
+		r.logger.For(ctx).Error("Comments.FindByID.FindOne", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Comments.FindByID.FindOne")
+	}
+
+	return result, nil
+}
+
+func (r *repo) CountDocuments(ctx context.Context, filter bson.D) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	totalCount, err := r.collection.CountDocuments(ctx, filter)
+
+	if err != nil {
+		r.logger.For(ctx).Error("Comments.CountDocuments", zap.String("err", err.Error()))
+		return 0, errors.Wrap(err, "Comments.CountDocuments")
+	}
+
+	return totalCount, nil
+}
+
+// FindAll - returns one page of the comments matching the filter in the given sort order.
+func (r *repo) FindAll(ctx context.Context, filter bson.D, sort string, query *pagination.Query) ([]*models.Comment, error) {
+
+	result := make([]*models.Comment, 0, query.GetSize())
+
+	opts := options.Find().SetSort(sortOf(sort)).SetSkip(int64(query.GetOffset())).SetLimit(int64(query.GetSize()))
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	err := r.collection.Find(ctx, filter, &result, opts)
+
+	if err != nil {
+		r.logger.For(ctx).Error("Comments.FindAll.Find", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Comments.FindAll.Find")
+	}
+
+	return result, nil
+}
+
+// sortOf - maps the sort param to the mongo sort document, _id breaks ties so the order is stable across pages.
+func sortOf(sort string) bson.D {
+	switch sort {
+	case models.SortNew:
+		return bson.D{{"created_at", -1}, {"_id", -1}}
+	case models.SortOld:
+		return bson.D{{"created_at", 1}, {"_id", 1}}
+	default:
+		return bson.D{{"score", -1}, {"_id", -1}}
+	}
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/comments/models"
+	"github.com/aliykh/reddit-feed/internal/driver/db/mock"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestRepo_FindByID(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	id := primitive.NewObjectID()
+
+	t.Run("not found", func(t *testing.T) {
+
+		coll.EXPECT().FindOne(gomock.Any(), bson.D{{"_id", id}}, gomock.Any()).Return(mongo.ErrNoDocuments)
+
+		_, err := repo.FindByID(context.Background(), id)
+
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+	})
+
+}
+
+func TestRepo_FindAll(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	filter := bson.D{{"parent_id", "abc"}}
+	query := &pagination.Query{Page: 2, Size: 10}
+
+	tests := []struct {
+		sort string
+		want bson.D
+	}{
+		{"", bson.D{{"score", -1}, {"_id", -1}}},
+		{models.SortTop, bson.D{{"score", -1}, {"_id", -1}}},
+		{models.SortNew, bson.D{{"created_at", -1}, {"_id", -1}}},
+		{models.SortOld, bson.D{{"created_at", 1}, {"_id", 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run("sort "+tt.sort, func(t *testing.T) {
+
+			coll.EXPECT().Find(gomock.Any(), filter, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ interface{}, _ interface{}, opts ...*options.FindOptions) error {
+				require.Len(t, opts, 1)
+				require.Equal(t, tt.want, opts[0].Sort)
+				require.EqualValues(t, 10, *opts[0].Skip)
+				require.EqualValues(t, 10, *opts[0].Limit)
+				return nil
+			})
+
+			_, err := repo.FindAll(context.Background(), filter, tt.sort, query)
+
+			require.NoError(t, err)
+		})
+	}
+
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/comments/models"
+	"github.com/aliykh/reddit-feed/internal/driver/db"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// selectColumns - columns scanned by scanComment, in its order
+const selectColumns = "id, post_id, parent_id, path, depth, author, body, score, created_at"
+
+// columns - columns of the fields the use cases filter the comments by
+var columns = map[string]string{
+	"_id":       "id",
+	"post_id":   "post_id",
+	"parent_id": "parent_id",
+	"depth":     "depth",
+	"author":    "author",
+}
+
+// postgresRepo - comments kept in the comments table of postgres, ids are generated like mongo object ids.
+type postgresRepo struct {
+	logger *log.Factory
+	pool   *pgxpool.Pool
+}
+
+func NewPostgres(logger *log.Factory, pool *pgxpool.Pool) *postgresRepo {
+	return &postgresRepo{
+		logger: logger,
+		pool:   pool,
+	}
+}
+
+func (r *postgresRepo) Create(ctx context.Context, m *models.Comment) (*models.Comment, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	var parentId *string
+	if m.ParentId != "" {
+		parentId = &m.ParentId
+	}
+
+	query := `INSERT INTO comments (` + selectColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING ` + selectColumns
+
+	result, err := scanComment(r.pool.QueryRow(ctx, query, primitive.NewObjectID().Hex(), m.PostId, parentId, m.Path,
+		m.Depth, m.Author, m.Body, m.Score, m.CreatedAt))
+	if err != nil {
+		return nil, errors.Wrap(err, "CommentPostgresRepo.Create.Insert")
+	}
+
+	return result, nil
+}
+
+func (r *postgresRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	result, err := scanComment(r.pool.QueryRow(ctx, `SELECT `+selectColumns+` FROM comments WHERE id = $1`, id.Hex()))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		}
+		r.logger.For(ctx).Error("Comments.FindByID.QueryRow", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Comments.FindByID.QueryRow")
+	}
+
+	return result, nil
+}
+
+func (r *postgresRepo) CountDocuments(ctx context.Context, filter bson.D) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	where, args, err := db.Where(filter, columns)
+	if err != nil {
+		return 0, errors.Wrap(err, "Comments.CountDocuments")
+	}
+
+	var totalCount int64
+	if err = r.pool.QueryRow(ctx, `SELECT count(*) FROM comments WHERE `+where, args...).Scan(&totalCount); err != nil {
+		r.logger.For(ctx).Error("Comments.CountDocuments", zap.String("err", err.Error()))
+		return 0, errors.Wrap(err, "Comments.CountDocuments")
+	}
+
+	return totalCount, nil
+}
+
+// FindAll - returns one page of the comments matching the filter in the given sort order.
+func (r *postgresRepo) FindAll(ctx context.Context, filter bson.D, sort string, query *pagination.Query) ([]*models.Comment, error) {
+
+	where, args, err := db.Where(filter, columns)
+	if err != nil {
+		return nil, errors.Wrap(err, "Comments.FindAll.Query")
+	}
+
+	sql := fmt.Sprintf(`SELECT %s FROM comments WHERE %s ORDER BY %s OFFSET $%d LIMIT $%d`,
+		selectColumns, where, orderBy(sort), len(args)+1, len(args)+2)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, sql, append(args, query.GetOffset(), query.GetSize())...)
+	if err != nil {
+		r.logger.For(ctx).Error("Comments.FindAll.Query", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Comments.FindAll.Query")
+	}
+	defer rows.Close()
+
+	result := make([]*models.Comment, 0, query.GetSize())
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "Comments.FindAll.Scan")
+		}
+		result = append(result, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.For(ctx).Error("Comments.FindAll.Query", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Comments.FindAll.Query")
+	}
+
+	return result, nil
+}
+
+// orderBy - the sql order of the sort param, see sortOf.
+func orderBy(sort string) string {
+	switch sort {
+	case models.SortNew:
+		return "created_at DESC, id DESC"
+	case models.SortOld:
+		return "created_at ASC, id ASC"
+	default:
+		return "score DESC, id DESC"
+	}
+}
+
+// scanComment - scans the selectColumns of the row.
+func scanComment(row pgx.Row) (*models.Comment, error) {
+	c := &models.Comment{}
+
+	var parentId *string
+	if err := row.Scan(&c.Id, &c.PostId, &parentId, &c.Path, &c.Depth, &c.Author, &c.Body, &c.Score, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if parentId != nil {
+		c.ParentId = *parentId
+	}
+	c.CreatedAt = c.CreatedAt.UTC()
+
+	return c, nil
+}
@@ -0,0 +1,16 @@
+//go:generate mockgen -source usecase.go -destination mock/usecase_mock.go -package mock
+package comments
+
+import (
+	"context"
+	"github.com/aliykh/reddit-feed/internal/comments/models"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UseCase interface {
+	Create(ctx context.Context, post primitive.ObjectID, model *models.Comment) (*models.Comment, error)
+	Reply(ctx context.Context, parent primitive.ObjectID, model *models.Comment) (*models.Comment, error)
+	ListByPost(ctx context.Context, post primitive.ObjectID, params *models.ListParams, query *pagination.Query) (*models.CommentList, error)
+	ListReplies(ctx context.Context, parent primitive.ObjectID, params *models.ListParams, query *pagination.Query) (*models.CommentList, error)
+}
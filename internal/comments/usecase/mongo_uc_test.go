@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/comments/models"
+	"github.com/aliykh/reddit-feed/internal/comments/repository/mock"
+	postsModels "github.com/aliykh/reddit-feed/internal/posts/models"
+	postsMock "github.com/aliykh/reddit-feed/internal/posts/repository/mock"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCommentsUC_Create(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	posts := postsMock.NewMockRepository(ctrl)
+
+	uc := New(logger, repo, posts)
+
+	post := primitive.NewObjectID()
+
+	t.Run("ok", func(t *testing.T) {
+		posts.EXPECT().FindByID(gomock.Any(), post).Return(&postsModels.Post{Id: post.Hex()}, nil)
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m *models.Comment) (*models.Comment, error) {
+			require.Equal(t, post.Hex(), m.PostId)
+			require.Empty(t, m.ParentId)
+			require.Empty(t, m.Path)
+			require.Zero(t, m.Depth)
+			require.False(t, m.CreatedAt.IsZero())
+			return m, nil
+		})
+		posts.EXPECT().IncrementCommentCount(gomock.Any(), post, 1).Return(nil)
+
+		res, err := uc.Create(context.Background(), post, &models.Comment{Body: "first", Author: "t2_user", Path: "forged,", Depth: 3})
+		require.NoError(t, err)
+		require.Equal(t, "first", res.Body)
+	})
+
+	t.Run("post not found", func(t *testing.T) {
+		expectedErr := customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		posts.EXPECT().FindByID(gomock.Any(), post).Return(nil, expectedErr)
+
+		_, err := uc.Create(context.Background(), post, &models.Comment{Body: "first"})
+		require.Equal(t, expectedErr, err)
+	})
+
+	t.Run("locked post", func(t *testing.T) {
+		posts.EXPECT().FindByID(gomock.Any(), post).Return(&postsModels.Post{Id: post.Hex(), Status: postsModels.StatusLocked}, nil)
+
+		_, err := uc.Create(context.Background(), post, &models.Comment{Body: "first"})
+		require.Equal(t, customErrors.New(http.StatusForbidden, postsModels.ErrLocked), err)
+	})
+
+	t.Run("removed post", func(t *testing.T) {
+		posts.EXPECT().FindByID(gomock.Any(), post).Return(&postsModels.Post{Id: post.Hex(), Status: postsModels.StatusRemovedByMod}, nil)
+
+		_, err := uc.Create(context.Background(), post, &models.Comment{Body: "first"})
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+	})
+
+	t.Run("count failure is not fatal", func(t *testing.T) {
+		posts.EXPECT().FindByID(gomock.Any(), post).Return(&postsModels.Post{Id: post.Hex()}, nil)
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(&models.Comment{Body: "first"}, nil)
+		posts.EXPECT().IncrementCommentCount(gomock.Any(), post, 1).Return(errors.New("timeout"))
+
+		_, err := uc.Create(context.Background(), post, &models.Comment{Body: "first"})
+		require.NoError(t, err)
+	})
+}
+
+func TestCommentsUC_Reply(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	posts := postsMock.NewMockRepository(ctrl)
+
+	uc := New(logger, repo, posts)
+
+	post := primitive.NewObjectID()
+	root := primitive.NewObjectID()
+	parent := primitive.NewObjectID()
+
+	parentComment := &models.Comment{
+		Id:       parent.Hex(),
+		PostId:   post.Hex(),
+		ParentId: root.Hex(),
+		Path:     root.Hex() + ",",
+		Depth:    1,
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		repo.EXPECT().FindByID(gomock.Any(), parent).Return(parentComment, nil)
+		posts.EXPECT().FindByID(gomock.Any(), post).Return(&postsModels.Post{Id: post.Hex(), Status: postsModels.StatusActive}, nil)
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m *models.Comment) (*models.Comment, error) {
+			require.Equal(t, post.Hex(), m.PostId)
+			require.Equal(t, parent.Hex(), m.ParentId)
+			require.Equal(t, root.Hex()+","+parent.Hex()+",", m.Path)
+			require.Equal(t, 2, m.Depth)
+			return m, nil
+		})
+		posts.EXPECT().IncrementCommentCount(gomock.Any(), post, 1).Return(nil)
+
+		_, err := uc.Reply(context.Background(), parent, &models.Comment{Body: "reply"})
+		require.NoError(t, err)
+	})
+
+	t.Run("locked post", func(t *testing.T) {
+		repo.EXPECT().FindByID(gomock.Any(), parent).Return(parentComment, nil)
+		posts.EXPECT().FindByID(gomock.Any(), post).Return(&postsModels.Post{Id: post.Hex(), Status: postsModels.StatusLocked}, nil)
+
+		_, err := uc.Reply(context.Background(), parent, &models.Comment{Body: "reply"})
+		require.Equal(t, customErrors.New(http.StatusForbidden, postsModels.ErrLocked), err)
+	})
+
+	t.Run("parent not found", func(t *testing.T) {
+		expectedErr := customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		repo.EXPECT().FindByID(gomock.Any(), parent).Return(nil, expectedErr)
+
+		_, err := uc.Reply(context.Background(), parent, &models.Comment{Body: "reply"})
+		require.Equal(t, expectedErr, err)
+	})
+}
+
+func TestCommentsUC_List(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	posts := postsMock.NewMockRepository(ctrl)
+
+	uc := New(logger, repo, posts)
+
+	id := primitive.NewObjectID()
+	post := primitive.NewObjectID()
+	query := &pagination.Query{Page: 1, Size: 2}
+	params := &models.ListParams{Sort: models.SortNew}
+	comments := []*models.Comment{{Body: "a"}, {Body: "b"}}
+
+	t.Run("by post", func(t *testing.T) {
+		filter := bson.D{{"post_id", id.Hex()}, {"depth", 0}}
+
+		posts.EXPECT().FindByID(gomock.Any(), id).Return(&postsModels.Post{Id: id.Hex()}, nil)
+		repo.EXPECT().CountDocuments(gomock.Any(), filter).Return(int64(5), nil)
+		repo.EXPECT().FindAll(gomock.Any(), filter, models.SortNew, query).Return(comments, nil)
+
+		res, err := uc.ListByPost(context.Background(), id, params, query)
+		require.NoError(t, err)
+		require.Equal(t, &models.CommentList{TotalCount: 5, TotalPages: 3, Page: 1, Size: 2, HasMore: true, Comments: comments}, res)
+	})
+
+	t.Run("replies", func(t *testing.T) {
+		filter := bson.D{{"parent_id", id.Hex()}}
+
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(&models.Comment{Id: id.Hex(), PostId: post.Hex()}, nil)
+		posts.EXPECT().FindByID(gomock.Any(), post).Return(&postsModels.Post{Id: post.Hex()}, nil)
+		repo.EXPECT().CountDocuments(gomock.Any(), filter).Return(int64(2), nil)
+		repo.EXPECT().FindAll(gomock.Any(), filter, models.SortNew, query).Return(comments, nil)
+
+		res, err := uc.ListReplies(context.Background(), id, params, query)
+		require.NoError(t, err)
+		require.False(t, res.HasMore)
+		require.Len(t, res.Comments, 2)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		filter := bson.D{{"post_id", id.Hex()}, {"depth", 0}}
+		last := &pagination.Query{Page: 3, Size: 2}
+
+		posts.EXPECT().FindByID(gomock.Any(), id).Return(&postsModels.Post{Id: id.Hex()}, nil)
+		repo.EXPECT().CountDocuments(gomock.Any(), filter).Return(int64(5), nil)
+		repo.EXPECT().FindAll(gomock.Any(), filter, models.SortNew, last).Return(comments[:1], nil)
+
+		// the size is the one of the page, like the feeds report it
+		res, err := uc.ListByPost(context.Background(), id, params, last)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Size)
+		require.False(t, res.HasMore)
+	})
+
+	t.Run("hidden post", func(t *testing.T) {
+		notFound := customErrors.New(http.StatusNotFound, customErrors.NotFound)
+
+		posts.EXPECT().FindByID(gomock.Any(), id).Return(&postsModels.Post{Id: id.Hex(), Status: postsModels.StatusRemovedByMod}, nil)
+
+		_, err := uc.ListByPost(context.Background(), id, params, query)
+		require.Equal(t, notFound, err)
+
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(&models.Comment{Id: id.Hex(), PostId: post.Hex()}, nil)
+		posts.EXPECT().FindByID(gomock.Any(), post).Return(&postsModels.Post{Id: post.Hex(), Status: postsModels.StatusDeletedByAuthor}, nil)
+
+		_, err = uc.ListReplies(context.Background(), id, params, query)
+		require.Equal(t, notFound, err)
+	})
+}
@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/comments/models"
+	"github.com/aliykh/reddit-feed/internal/comments/repository"
+	postsModels "github.com/aliykh/reddit-feed/internal/posts/models"
+	postsRepository "github.com/aliykh/reddit-feed/internal/posts/repository"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type commentsUC struct {
+	logger *log.Factory
+	repo   repository.Repository
+	posts  postsRepository.Repository
+}
+
+func New(logger *log.Factory, repo repository.Repository, posts postsRepository.Repository) *commentsUC {
+	return &commentsUC{
+		logger: logger,
+		repo:   repo,
+		posts:  posts,
+	}
+}
+
+// Create - adds a top level comment to the post.
+func (u *commentsUC) Create(ctx context.Context, post primitive.ObjectID, model *models.Comment) (*models.Comment, error) {
+	if err := u.checkCommentable(ctx, post); err != nil {
+		return nil, err
+	}
+
+	model.PostId = post.Hex()
+	model.ParentId, model.Path, model.Depth = "", "", 0
+
+	return u.create(ctx, post, model)
+}
+
+// Reply - adds a comment under the given parent comment, in the same post.
+func (u *commentsUC) Reply(ctx context.Context, parent primitive.ObjectID, model *models.Comment) (*models.Comment, error) {
+	p, err := u.repo.FindByID(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := primitive.ObjectIDFromHex(p.PostId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.checkCommentable(ctx, post); err != nil {
+		return nil, err
+	}
+
+	model.ReplyTo(p)
+
+	return u.create(ctx, post, model)
+}
+
+func (u *commentsUC) create(ctx context.Context, post primitive.ObjectID, model *models.Comment) (*models.Comment, error) {
+	model.Score = 0
+	model.CreatedAt = time.Now().UTC()
+
+	result, err := u.repo.Create(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+
+	// the comment is already stored, a failed counter update must not fail the request
+	if err = u.posts.IncrementCommentCount(ctx, post, 1); err != nil {
+		u.logger.For(ctx).Error("comment count increment", zap.String("post", post.Hex()), zap.String("err", err.Error()))
+	}
+
+	return result, nil
+}
+
+// checkCommentable - comments may only be added to visible posts which are not locked.
+func (u *commentsUC) checkCommentable(ctx context.Context, post primitive.ObjectID) error {
+	p, err := u.findVisiblePost(ctx, post)
+	if err != nil {
+		return err
+	}
+
+	if p.Status == postsModels.StatusLocked {
+		return customErrors.New(http.StatusForbidden, postsModels.ErrLocked)
+	}
+
+	return nil
+}
+
+// findVisiblePost - the post of the comments, hidden posts are missing like they are for GetByID.
+func (u *commentsUC) findVisiblePost(ctx context.Context, post primitive.ObjectID) (*postsModels.Post, error) {
+	p, err := u.posts.FindByID(ctx, post)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.Visible() {
+		return nil, customErrors.New(http.StatusNotFound, customErrors.NotFound)
+	}
+
+	return p, nil
+}
+
+// ListByPost - returns the top level comments of the post.
+func (u *commentsUC) ListByPost(ctx context.Context, post primitive.ObjectID, params *models.ListParams, query *pagination.Query) (*models.CommentList, error) {
+	if _, err := u.findVisiblePost(ctx, post); err != nil {
+		return nil, err
+	}
+
+	return u.list(ctx, bson.D{{"post_id", post.Hex()}, {"depth", 0}}, params, query)
+}
+
+// ListReplies - returns the direct replies of the comment, the replies of a hidden post are missing as well.
+func (u *commentsUC) ListReplies(ctx context.Context, parent primitive.ObjectID, params *models.ListParams, query *pagination.Query) (*models.CommentList, error) {
+	p, err := u.repo.FindByID(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := primitive.ObjectIDFromHex(p.PostId)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = u.findVisiblePost(ctx, post); err != nil {
+		return nil, err
+	}
+
+	return u.list(ctx, bson.D{{"parent_id", parent.Hex()}}, params, query)
+}
+
+func (u *commentsUC) list(ctx context.Context, filter bson.D, params *models.ListParams, query *pagination.Query) (*models.CommentList, error) {
+	totalCount, err := u.repo.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := u.repo.FindAll(ctx, filter, params.Sort, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CommentList{
+		TotalCount: totalCount,
+		TotalPages: pagination.GetTotalPages(totalCount, query.GetSize()),
+		Page:       query.GetPage(),
+		Size:       len(comments),
+		HasMore:    pagination.GetHasMore(query.GetPage(), int(totalCount), query.GetSize()),
+		Comments:   comments,
+	}, nil
+}
@@ -0,0 +1,496 @@
+package db
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"math/rand"
+	"reflect"
+	"sync"
+)
+
+// duplicateKeyCode - server error code of a unique index violation, recognized by mongo.IsDuplicateKeyError
+const duplicateKeyCode = 11000
+
+var ErrUnsupported = errors.New("not supported by the in-memory collection")
+
+// Index - unique and text indexes of the in-memory collection. Mongo collections get theirs from the migrations.
+type Index struct {
+	Keys   []string
+	Unique bool
+	// Text - the keys are searched by $text queries
+	Text bool
+}
+
+// memoryCollection - Collection kept in the memory of the process, for local development and tests.
+// It supports the filters, updates and pipeline stages the repositories use, others fail with ErrUnsupported.
+type memoryCollection struct {
+	mu sync.RWMutex
+	// docs - in insertion order, the natural order of the collection
+	docs    []bson.M
+	indexes []Index
+}
+
+func NewMemory(indexes ...Index) Collection {
+	return &memoryCollection{
+		indexes: indexes,
+	}
+}
+
+func (m *memoryCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	doc, err := toDocument(document)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if writeErr := m.insert(doc); writeErr != nil {
+		return nil, mongo.WriteException{WriteErrors: mongo.WriteErrors{*writeErr}}
+	}
+
+	return &mongo.InsertOneResult{InsertedID: doc["_id"]}, nil
+}
+
+func (m *memoryCollection) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	ordered := true
+	if o := options.MergeInsertManyOptions(opts...); o.Ordered != nil {
+		ordered = *o.Ordered
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := &mongo.InsertManyResult{}
+	var writeErrors []mongo.BulkWriteError
+
+	for i, document := range documents {
+		doc, err := toDocument(document)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := doc["_id"]; !ok {
+			doc["_id"] = primitive.NewObjectID()
+		}
+		result.InsertedIDs = append(result.InsertedIDs, doc["_id"])
+
+		if writeErr := m.insert(doc); writeErr != nil {
+			writeErr.Index = i
+			writeErrors = append(writeErrors, mongo.BulkWriteError{WriteError: *writeErr})
+			if ordered {
+				break
+			}
+		}
+	}
+
+	if len(writeErrors) > 0 {
+		return result, mongo.BulkWriteException{WriteErrors: writeErrors}
+	}
+
+	return result, nil
+}
+
+func (m *memoryCollection) FindOne(ctx context.Context, filter interface{}, res interface{}, opts ...*options.FindOneOptions) error {
+	o := options.MergeFindOneOptions(opts...)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	docs, err := m.find(filter, o.Sort, o.Skip, nil)
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return decode(docs[0], res)
+}
+
+func (m *memoryCollection) Find(ctx context.Context, filter interface{}, res interface{}, opts ...*options.FindOptions) error {
+	o := options.MergeFindOptions(opts...)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	docs, err := m.find(filter, o.Sort, o.Skip, o.Limit)
+	if err != nil {
+		return err
+	}
+
+	return decodeAll(docs, res)
+}
+
+func (m *memoryCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	docs, err := m.find(filter, nil, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(docs)), nil
+}
+
+// Aggregate - runs pipelines of $match, $sort, $skip, $limit and $sample stages.
+func (m *memoryCollection) Aggregate(ctx context.Context, pipeline mongo.Pipeline, res interface{}, opts ...*options.AggregateOptions) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	docs := append([]bson.M(nil), m.docs...)
+
+	for _, stage := range pipeline {
+		if len(stage) != 1 {
+			return errors.Wrap(ErrUnsupported, "stage with several operators")
+		}
+
+		var err error
+		op, arg := stage[0].Key, stage[0].Value
+
+		switch op {
+		case "$match":
+			docs, err = m.match(docs, arg)
+		case "$sort":
+			err = sortDocuments(docs, arg)
+		case "$skip":
+			skip := toInt(arg)
+			if skip > len(docs) {
+				skip = len(docs)
+			}
+			docs = docs[skip:]
+		case "$limit":
+			if limit := toInt(arg); limit < len(docs) {
+				docs = docs[:limit]
+			}
+		case "$sample":
+			docs, err = sample(docs, arg)
+		default:
+			return errors.Wrapf(ErrUnsupported, "%s stage", op)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return decodeAll(docs, res)
+}
+
+func (m *memoryCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	upsert := false
+	if o := options.MergeUpdateOptions(opts...); o.Upsert != nil {
+		upsert = *o.Upsert
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.first(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if i < 0 {
+		if !upsert {
+			return &mongo.UpdateResult{}, nil
+		}
+
+		doc, err := m.upsert(filter, update)
+		if err != nil {
+			return nil, err
+		}
+		return &mongo.UpdateResult{UpsertedCount: 1, UpsertedID: doc["_id"]}, nil
+	}
+
+	if _, err = m.update(i, update); err != nil {
+		return nil, err
+	}
+
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+func (m *memoryCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.first(filter)
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 {
+		return &mongo.DeleteResult{}, nil
+	}
+
+	m.docs = append(m.docs[:i], m.docs[i+1:]...)
+
+	return &mongo.DeleteResult{DeletedCount: 1}, nil
+}
+
+func (m *memoryCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, res interface{}, opts ...*options.FindOneAndUpdateOptions) error {
+	o := options.MergeFindOneAndUpdateOptions(opts...)
+	upsert := o.Upsert != nil && *o.Upsert
+	after := o.ReturnDocument != nil && *o.ReturnDocument == options.After
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.first(filter)
+	if err != nil {
+		return err
+	}
+
+	if i < 0 {
+		if !upsert {
+			return mongo.ErrNoDocuments
+		}
+
+		doc, err := m.upsert(filter, update)
+		if err != nil {
+			return err
+		}
+		// there is no document before an insert
+		if !after {
+			return mongo.ErrNoDocuments
+		}
+		return decode(doc, res)
+	}
+
+	before := m.docs[i]
+
+	updated, err := m.update(i, update)
+	if err != nil {
+		return err
+	}
+
+	if after {
+		return decode(updated, res)
+	}
+	return decode(before, res)
+}
+
+// find - documents matching the filter in the sort order, called with the lock held.
+func (m *memoryCollection) find(filter interface{}, sort interface{}, skip, limit *int64) ([]bson.M, error) {
+	docs, err := m.match(m.docs, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if sort != nil {
+		if err = sortDocuments(docs, sort); err != nil {
+			return nil, err
+		}
+	}
+
+	if skip != nil {
+		if int(*skip) >= len(docs) {
+			return nil, nil
+		}
+		docs = docs[*skip:]
+	}
+
+	if limit != nil && *limit != 0 {
+		n := int(*limit)
+		if n < 0 {
+			n = -n
+		}
+		if n < len(docs) {
+			docs = docs[:n]
+		}
+	}
+
+	return docs, nil
+}
+
+// match - the documents matching the filter, a new slice.
+func (m *memoryCollection) match(docs []bson.M, filter interface{}) ([]bson.M, error) {
+	f, err := toFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []bson.M
+	for _, doc := range docs {
+		ok, err := m.matches(doc, f)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result = append(result, doc)
+		}
+	}
+
+	return result, nil
+}
+
+// first - index of the first document matching the filter, -1 when there is none.
+func (m *memoryCollection) first(filter interface{}) (int, error) {
+	f, err := toFilter(filter)
+	if err != nil {
+		return -1, err
+	}
+
+	for i, doc := range m.docs {
+		ok, err := m.matches(doc, f)
+		if err != nil {
+			return -1, err
+		}
+		if ok {
+			return i, nil
+		}
+	}
+
+	return -1, nil
+}
+
+// insert - appends the document unless it violates a unique index, called with the lock held.
+func (m *memoryCollection) insert(doc bson.M) *mongo.WriteError {
+	if _, ok := doc["_id"]; !ok {
+		doc["_id"] = primitive.NewObjectID()
+	}
+
+	unique := append([]Index{{Keys: []string{"_id"}, Unique: true}}, m.indexes...)
+
+	for _, index := range unique {
+		if !index.Unique {
+			continue
+		}
+		for _, existing := range m.docs {
+			if sameKeys(existing, doc, index.Keys) {
+				return &mongo.WriteError{
+					Code:    duplicateKeyCode,
+					Message: "E11000 duplicate key error on " + keyNames(index.Keys),
+				}
+			}
+		}
+	}
+
+	m.docs = append(m.docs, doc)
+
+	return nil
+}
+
+// update - replaces the document at i with its updated copy, a failed update leaves it as is.
+// The unique indexes are only enforced on insert.
+func (m *memoryCollection) update(i int, update interface{}) (bson.M, error) {
+	updated, err := toDocument(m.docs[i])
+	if err != nil {
+		return nil, err
+	}
+
+	if err = applyUpdate(updated, update, false); err != nil {
+		return nil, err
+	}
+
+	m.docs[i] = updated
+
+	return updated, nil
+}
+
+// upsert - inserts the equality conditions of the filter with the update applied.
+func (m *memoryCollection) upsert(filter interface{}, update interface{}) (bson.M, error) {
+	f, err := toFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := bson.M{}
+	for key, cond := range f {
+		if isOperator(key) || isOperatorDocument(cond) {
+			continue
+		}
+		setPath(doc, key, cond)
+	}
+
+	if err = applyUpdate(doc, update, true); err != nil {
+		return nil, err
+	}
+
+	if writeErr := m.insert(doc); writeErr != nil {
+		return nil, mongo.WriteException{WriteErrors: mongo.WriteErrors{*writeErr}}
+	}
+
+	return doc, nil
+}
+
+// sample - size random documents, all of them in random order when there are fewer.
+func sample(docs []bson.M, arg interface{}) ([]bson.M, error) {
+	spec, err := toFilter(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	size := toInt(spec["size"])
+
+	result := append([]bson.M(nil), docs...)
+	rand.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+
+	if size < len(result) {
+		result = result[:size]
+	}
+
+	return result, nil
+}
+
+// toDocument - the value as a document of the types the bson decoder produces.
+func toDocument(v interface{}) (bson.M, error) {
+	doc := bson.M{}
+	if v == nil {
+		return doc, nil
+	}
+
+	// a nil bson.D filter matches everything, like in the driver
+	if rv := reflect.ValueOf(v); (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map || rv.Kind() == reflect.Ptr) && rv.IsNil() {
+		return doc, nil
+	}
+
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func decode(doc bson.M, res interface{}) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return bson.Unmarshal(raw, res)
+}
+
+// decodeAll - decodes the documents into the slice res points to, like the All method of a cursor.
+func decodeAll(docs []bson.M, res interface{}) error {
+	v := reflect.ValueOf(res)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return errors.New("results argument must be a pointer to a slice")
+	}
+
+	slice := v.Elem().Slice(0, 0)
+	elem := slice.Type().Elem()
+
+	for _, doc := range docs {
+		item := reflect.New(elem)
+		if err := decode(doc, item.Interface()); err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, item.Elem())
+	}
+
+	v.Elem().Set(slice)
+
+	return nil
+}
@@ -0,0 +1,518 @@
+package db
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// toFilter - the filter with its values converted like the stored documents, so they compare by the bson types.
+func toFilter(filter interface{}) (bson.M, error) {
+	return toDocument(filter)
+}
+
+// matches - whether the document passes every condition of the filter, the way a mongo query would.
+func (m *memoryCollection) matches(doc bson.M, filter bson.M) (bool, error) {
+	for key, cond := range filter {
+		var ok bool
+		var err error
+
+		switch key {
+		case "$and", "$or", "$nor":
+			ok, err = m.matchLogical(doc, key, cond)
+		case "$text":
+			ok, err = m.matchText(doc, cond)
+		default:
+			if isOperator(key) {
+				return false, errors.Wrapf(ErrUnsupported, "%s operator", key)
+			}
+			value, found := lookup(doc, key)
+			ok, err = matchField(value, found, cond)
+		}
+
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (m *memoryCollection) matchLogical(doc bson.M, op string, cond interface{}) (bool, error) {
+	filters, ok := cond.(primitive.A)
+	if !ok || len(filters) == 0 {
+		return false, errors.Errorf("%s must be a nonempty array", op)
+	}
+
+	for _, item := range filters {
+		filter, ok := asMap(item)
+		if !ok {
+			return false, errors.Errorf("%s entries must be documents", op)
+		}
+
+		matched, err := m.matches(doc, filter)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case op == "$and" && !matched:
+			return false, nil
+		case op == "$or" && matched:
+			return true, nil
+		case op == "$nor" && matched:
+			return false, nil
+		}
+	}
+
+	return op != "$or", nil
+}
+
+// matchText - whether a text indexed field of the document contains one of the search terms.
+func (m *memoryCollection) matchText(doc bson.M, cond interface{}) (bool, error) {
+	spec, ok := asMap(cond)
+	if !ok {
+		return false, errors.New("$text needs a document")
+	}
+
+	search, ok := spec["$search"].(string)
+	if !ok {
+		return false, errors.New("$text needs a $search string")
+	}
+
+	if !m.hasTextIndex() {
+		return false, errors.New("text index required for $text query")
+	}
+
+	var texts []string
+	for _, index := range m.indexes {
+		if !index.Text {
+			continue
+		}
+		for _, key := range index.Keys {
+			if value, ok := lookup(doc, key); ok {
+				if text, ok := value.(string); ok {
+					texts = append(texts, text)
+				}
+			}
+		}
+	}
+
+	return TextScore(search, texts...) > 0, nil
+}
+
+func (m *memoryCollection) hasTextIndex() bool {
+	for _, index := range m.indexes {
+		if index.Text {
+			return true
+		}
+	}
+	return false
+}
+
+// TextScore - relevance of the texts to the search, the number of words of the texts that are search terms.
+// Words are compared case-insensitively, unlike mongo they are not stemmed.
+func TextScore(search string, texts ...string) float64 {
+	terms := make(map[string]struct{})
+	for _, term := range words(search) {
+		terms[term] = struct{}{}
+	}
+
+	var score float64
+	for _, text := range texts {
+		for _, word := range words(text) {
+			if _, ok := terms[word]; ok {
+				score++
+			}
+		}
+	}
+
+	return score
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// matchField - whether the value passes the condition, either a value it equals or a document of operators.
+func matchField(value interface{}, found bool, cond interface{}) (bool, error) {
+	if !isOperatorDocument(cond) {
+		return equals(value, found, cond), nil
+	}
+
+	ops, _ := asMap(cond)
+
+	for op, arg := range ops {
+		var ok bool
+
+		switch op {
+		case "$eq":
+			ok = equals(value, found, arg)
+		case "$ne":
+			ok = !equals(value, found, arg)
+		case "$gt", "$gte", "$lt", "$lte":
+			c, comparable := compareValues(value, arg)
+			ok = found && comparable && (op == "$gt" && c > 0 || op == "$gte" && c >= 0 ||
+				op == "$lt" && c < 0 || op == "$lte" && c <= 0)
+		case "$in", "$nin":
+			values, isArray := arg.(primitive.A)
+			if !isArray {
+				return false, errors.Errorf("%s needs an array", op)
+			}
+			for _, v := range values {
+				if equals(value, found, v) {
+					ok = true
+					break
+				}
+			}
+			if op == "$nin" {
+				ok = !ok
+			}
+		case "$exists":
+			exists := toInt(arg) != 0
+			if b, isBool := arg.(bool); isBool {
+				exists = b
+			}
+			ok = found == exists
+		default:
+			return false, errors.Wrapf(ErrUnsupported, "%s operator", op)
+		}
+
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// equals - null matches missing fields as well, an array matches when one of its elements does.
+func equals(value interface{}, found bool, target interface{}) bool {
+	if target == nil {
+		return !found || value == nil
+	}
+	if !found {
+		return false
+	}
+
+	if values, ok := value.(primitive.A); ok {
+		if _, ok = target.(primitive.A); !ok {
+			for _, v := range values {
+				if equals(v, true, target) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	c, ok := compareValues(value, target)
+	return ok && c == 0
+}
+
+// compareValues - order of the values, reports false when they are of different types.
+// Values of different types are ordered by the bson comparison order of their types.
+func compareValues(a, b interface{}) (int, bool) {
+	ta, tb := typeOrder(a), typeOrder(b)
+	if ta != tb {
+		if ta < tb {
+			return -1, false
+		}
+		return 1, false
+	}
+
+	switch x := a.(type) {
+	case nil:
+		return 0, true
+	case string:
+		return strings.Compare(x, b.(string)), true
+	case bool:
+		y := b.(bool)
+		switch {
+		case x == y:
+			return 0, true
+		case y:
+			return -1, true
+		default:
+			return 1, true
+		}
+	case primitive.DateTime:
+		return compareFloats(float64(x), float64(b.(primitive.DateTime))), true
+	case primitive.ObjectID:
+		y := b.(primitive.ObjectID)
+		return bytes.Compare(x[:], y[:]), true
+	}
+
+	if ta == numberOrder {
+		return compareFloats(toFloat(a), toFloat(b)), true
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return 0, true
+	}
+	return 1, true
+}
+
+const numberOrder = 2
+
+func typeOrder(v interface{}) int {
+	switch v.(type) {
+	case nil, primitive.Null:
+		return 1
+	case int, int32, int64, float64:
+		return numberOrder
+	case string:
+		return 3
+	case bson.M, primitive.D:
+		return 4
+	case primitive.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime:
+		return 9
+	default:
+		return 10
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toFloat(v interface{}) float64 {
+	switch x := v.(type) {
+	case int:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case float64:
+		return x
+	}
+	return 0
+}
+
+func toInt(v interface{}) int {
+	return int(toFloat(v))
+}
+
+// sortDocuments - sorts by a sort specification, the keys must be ordered so it has to be a bson.D.
+func sortDocuments(docs []bson.M, spec interface{}) error {
+	keys, ok := spec.(primitive.D)
+	if !ok {
+		if m, isMap := spec.(bson.M); isMap && len(m) == 1 {
+			for key, direction := range m {
+				keys = primitive.D{{key, direction}}
+			}
+		} else {
+			return errors.Wrap(ErrUnsupported, "sort specification other than bson.D")
+		}
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, key := range keys {
+			a, _ := lookup(docs[i], key.Key)
+			b, _ := lookup(docs[j], key.Key)
+
+			c, _ := compareValues(a, b)
+			if c == 0 {
+				continue
+			}
+			if toInt(key.Value) < 0 {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+
+	return nil
+}
+
+// applyUpdate - applies the $set, $unset, $inc and, when inserting, $setOnInsert operators to the document.
+func applyUpdate(doc bson.M, update interface{}, inserting bool) error {
+	u, err := toDocument(update)
+	if err != nil {
+		return err
+	}
+
+	for op, arg := range u {
+		fields, ok := asMap(arg)
+		if !ok || !isOperator(op) {
+			return errors.Wrap(ErrUnsupported, "replacement documents")
+		}
+
+		switch op {
+		case "$set":
+			for key, value := range fields {
+				setPath(doc, key, value)
+			}
+		case "$setOnInsert":
+			if !inserting {
+				continue
+			}
+			for key, value := range fields {
+				setPath(doc, key, value)
+			}
+		case "$unset":
+			for key := range fields {
+				unsetPath(doc, key)
+			}
+		case "$inc":
+			for key, delta := range fields {
+				current, found := lookup(doc, key)
+				if !found {
+					current = int32(0)
+				}
+
+				sum, err := add(current, delta)
+				if err != nil {
+					return errors.Wrapf(err, "$inc of %s", key)
+				}
+				setPath(doc, key, sum)
+			}
+		default:
+			return errors.Wrapf(ErrUnsupported, "%s update", op)
+		}
+	}
+
+	return nil
+}
+
+// add - sum of two numbers, int32 unless it overflows, float64 when either is one.
+func add(a, b interface{}) (interface{}, error) {
+	if typeOrder(a) != numberOrder || typeOrder(b) != numberOrder {
+		return nil, errors.New("cannot increment a non-numeric value")
+	}
+
+	_, aFloat := a.(float64)
+	_, bFloat := b.(float64)
+	if aFloat || bFloat {
+		return toFloat(a) + toFloat(b), nil
+	}
+
+	sum := int64(toFloat(a)) + int64(toFloat(b))
+
+	_, aLong := a.(int64)
+	_, bLong := b.(int64)
+	if !aLong && !bLong && sum >= math.MinInt32 && sum <= math.MaxInt32 {
+		return int32(sum), nil
+	}
+
+	return sum, nil
+}
+
+// lookup - value of a dotted path in the document.
+func lookup(doc bson.M, path string) (interface{}, bool) {
+	var current interface{} = doc
+
+	for _, part := range strings.Split(path, ".") {
+		m, ok := asMap(current)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+func setPath(doc bson.M, path string, value interface{}) {
+	parts := strings.Split(path, ".")
+
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := doc[part].(bson.M)
+		if !ok {
+			next = bson.M{}
+			doc[part] = next
+		}
+		doc = next
+	}
+
+	doc[parts[len(parts)-1]] = value
+}
+
+func unsetPath(doc bson.M, path string) {
+	parts := strings.Split(path, ".")
+
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := doc[part].(bson.M)
+		if !ok {
+			return
+		}
+		doc = next
+	}
+
+	delete(doc, parts[len(parts)-1])
+}
+
+// sameKeys - whether the documents have equal values for the keys, a missing key is null like in a mongo index.
+func sameKeys(a, b bson.M, keys []string) bool {
+	for _, key := range keys {
+		va, _ := lookup(a, key)
+		vb, _ := lookup(b, key)
+
+		if c, ok := compareValues(va, vb); !ok || c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func keyNames(keys []string) string {
+	return strings.Join(keys, ", ")
+}
+
+func asMap(v interface{}) (bson.M, bool) {
+	switch x := v.(type) {
+	case bson.M:
+		return x, true
+	case primitive.D:
+		return x.Map(), true
+	case map[string]interface{}:
+		return x, true
+	}
+	return nil, false
+}
+
+func isOperator(key string) bool {
+	return strings.HasPrefix(key, "$")
+}
+
+// isOperatorDocument - a condition of operators like {"$gt": 1} rather than a document to compare with.
+func isOperatorDocument(v interface{}) bool {
+	m, ok := asMap(v)
+	if !ok || len(m) == 0 {
+		return false
+	}
+
+	for key := range m {
+		if !isOperator(key) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type item struct {
+	Id        string    `bson:"_id,omitempty"`
+	Name      string    `bson:"name"`
+	Score     int       `bson:"score"`
+	Tags      []string  `bson:"tags,omitempty"`
+	Hidden    *bool     `bson:"hidden,omitempty"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func seed(t *testing.T, c Collection, items ...*item) {
+	for _, it := range items {
+		_, err := c.InsertOne(context.Background(), it)
+		require.NoError(t, err)
+	}
+}
+
+func TestMemoryCollection_Find(t *testing.T) {
+
+	ctx := context.Background()
+	c := NewMemory(Index{Keys: []string{"name"}, Text: true})
+
+	now := time.Now().UTC()
+	hidden := true
+
+	seed(t, c,
+		&item{Id: "a", Name: "Go generics", Score: 3, Tags: []string{"go"}, CreatedAt: now.Add(-time.Hour)},
+		&item{Id: "b", Name: "Rust traits", Score: 5, Tags: []string{"rust"}, CreatedAt: now},
+		&item{Id: "c", Name: "Go errors", Score: 5, Hidden: &hidden, CreatedAt: now.Add(-time.Hour * 48)},
+	)
+
+	find := func(filter bson.D, opts ...*options.FindOptions) []string {
+		var result []*item
+		require.NoError(t, c.Find(ctx, filter, &result, opts...))
+
+		ids := make([]string, 0, len(result))
+		for _, it := range result {
+			ids = append(ids, it.Id)
+		}
+		return ids
+	}
+
+	byScore := options.Find().SetSort(bson.D{{"score", -1}, {"_id", -1}})
+
+	cases := map[string]struct {
+		filter bson.D
+		result []string
+	}{
+		"all":         {nil, []string{"c", "b", "a"}},
+		"equality":    {bson.D{{"score", 5}}, []string{"c", "b"}},
+		"array":       {bson.D{{"tags", "go"}}, []string{"a"}},
+		"missing":     {bson.D{{"hidden", nil}}, []string{"b", "a"}},
+		"in":          {bson.D{{"_id", bson.D{{"$in", []string{"a", "c"}}}}}, []string{"c", "a"}},
+		"nin":         {bson.D{{"hidden", bson.D{{"$nin", []bool{true}}}}}, []string{"b", "a"}},
+		"range":       {bson.D{{"created_at", bson.D{{"$gte", now.Add(-time.Hour * 2)}}}}, []string{"b", "a"}},
+		"or":          {bson.D{{"$or", bson.A{bson.D{{"score", bson.D{{"$lt", 5}}}}, bson.D{{"_id", "b"}}}}}, []string{"b", "a"}},
+		"text":        {bson.D{{"$text", bson.D{{"$search", "go"}}}}, []string{"c", "a"}},
+		"text and eq": {bson.D{{"$text", bson.D{{"$search", "GO rust"}}}, {"score", 5}}, []string{"c", "b"}},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.result, find(tc.filter, byScore))
+		})
+	}
+
+	t.Run("skip and limit", func(t *testing.T) {
+		require.Equal(t, []string{"b"}, find(nil, byScore, options.Find().SetSkip(1).SetLimit(1)))
+	})
+
+	t.Run("count", func(t *testing.T) {
+		count, err := c.CountDocuments(ctx, bson.D{{"score", bson.D{{"$gte", 4}}}})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), count)
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		var result []*item
+		pipeline := mongo.Pipeline{
+			{{"$match", bson.D{{"score", 5}}}},
+			{{"$sample", bson.D{{"size", 1}}}},
+		}
+		require.NoError(t, c.Aggregate(ctx, pipeline, &result))
+		require.Len(t, result, 1)
+		require.Equal(t, 5, result[0].Score)
+
+		err := c.Aggregate(ctx, mongo.Pipeline{{{"$group", bson.D{{"_id", "$name"}}}}}, &result)
+		require.ErrorIs(t, err, ErrUnsupported)
+	})
+
+	t.Run("find one", func(t *testing.T) {
+		result := &item{}
+		require.NoError(t, c.FindOne(ctx, bson.D{{"_id", "b"}}, result))
+		require.Equal(t, "Rust traits", result.Name)
+		require.True(t, result.CreatedAt.Equal(now.Truncate(time.Millisecond)))
+
+		require.Equal(t, mongo.ErrNoDocuments, c.FindOne(ctx, bson.D{{"_id", "x"}}, result))
+	})
+}
+
+func TestMemoryCollection_Write(t *testing.T) {
+
+	ctx := context.Background()
+	c := NewMemory(Index{Keys: []string{"name"}, Unique: true})
+
+	seed(t, c, &item{Id: "a", Name: "first", Score: 1})
+
+	t.Run("unique", func(t *testing.T) {
+		_, err := c.InsertOne(ctx, &item{Id: "a", Name: "other"})
+		require.True(t, mongo.IsDuplicateKeyError(err))
+
+		_, err = c.InsertOne(ctx, &item{Name: "first"})
+		require.True(t, mongo.IsDuplicateKeyError(err))
+
+		res, err := c.InsertMany(ctx, []interface{}{&item{Name: "second"}, &item{Name: "first"}, &item{Name: "third"}},
+			options.InsertMany().SetOrdered(false))
+		var bulkErr mongo.BulkWriteException
+		require.ErrorAs(t, err, &bulkErr)
+		require.Len(t, bulkErr.WriteErrors, 1)
+		require.Equal(t, 1, bulkErr.WriteErrors[0].Index)
+		require.Len(t, res.InsertedIDs, 3)
+
+		count, err := c.CountDocuments(ctx, nil)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), count)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		update := bson.D{{"$inc", bson.D{{"score", 2}}}, {"$set", bson.D{{"tags", bson.A{"x"}}}}}
+
+		before := &item{}
+		require.NoError(t, c.FindOneAndUpdate(ctx, bson.D{{"_id", "a"}}, update, before))
+		require.Equal(t, 1, before.Score)
+
+		after := &item{}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		require.NoError(t, c.FindOneAndUpdate(ctx, bson.D{{"_id", "a"}}, bson.D{{"$unset", bson.D{{"tags", ""}}}}, after, opts))
+		require.Equal(t, 3, after.Score)
+		require.Empty(t, after.Tags)
+
+		res, err := c.UpdateOne(ctx, bson.D{{"_id", "x"}}, update)
+		require.NoError(t, err)
+		require.Equal(t, int64(0), res.MatchedCount)
+	})
+
+	t.Run("upsert", func(t *testing.T) {
+		filter := bson.D{{"name", "fourth"}}
+		update := bson.D{{"$set", bson.D{{"score", 4}}}}
+		opts := options.FindOneAndUpdate().SetUpsert(true)
+
+		// nothing before the insert
+		require.Equal(t, mongo.ErrNoDocuments, c.FindOneAndUpdate(ctx, filter, update, &item{}, opts))
+
+		result := &item{}
+		require.NoError(t, c.FindOne(ctx, filter, result))
+		require.Equal(t, 4, result.Score)
+		require.NotEmpty(t, result.Id)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		res, err := c.DeleteOne(ctx, bson.D{{"_id", "a"}})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), res.DeletedCount)
+
+		res, err = c.DeleteOne(ctx, bson.D{{"_id", "a"}})
+		require.NoError(t, err)
+		require.Equal(t, int64(0), res.DeletedCount)
+	})
+}
@@ -11,10 +11,14 @@ import (
 
 type Collection interface {
 	InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error)
+	InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
 	FindOne(ctx context.Context, filter interface{}, res interface{}, opts ...*options.FindOneOptions) error
 	Find(ctx context.Context, filter interface{}, res interface{}, opts ...*options.FindOptions) error
 	CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error)
 	Aggregate(ctx context.Context, pipeline mongo.Pipeline, res interface{}, opts ...*options.AggregateOptions) error
+	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+	FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, res interface{}, opts ...*options.FindOneAndUpdateOptions) error
 }
 
 type dbCollection struct {
@@ -53,6 +57,12 @@ func (m *dbCollection) InsertOne(ctx context.Context, document interface{}, opts
 	return m.collection.InsertOne(ctx, document, opts...)
 }
 
+func (m *dbCollection) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+	return m.collection.InsertMany(ctx, documents, opts...)
+}
+
 func (m *dbCollection) FindOne(ctx context.Context, filter interface{}, res interface{}, opts ...*options.FindOneOptions) error {
 	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
 	defer cancel()
@@ -78,3 +88,21 @@ func (m *dbCollection) Aggregate(ctx context.Context, pipeline mongo.Pipeline, r
 
 	return cur.All(ctx, res)
 }
+
+func (m *dbCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+	return m.collection.UpdateOne(ctx, filter, update, opts...)
+}
+
+func (m *dbCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+	return m.collection.DeleteOne(ctx, filter, opts...)
+}
+
+func (m *dbCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, res interface{}, opts ...*options.FindOneAndUpdateOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+	return m.collection.FindOneAndUpdate(ctx, filter, update, opts...).Decode(res)
+}
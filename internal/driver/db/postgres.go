@@ -0,0 +1,51 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strconv"
+	"strings"
+)
+
+// uniqueViolation - sql state of a unique constraint violation
+const uniqueViolation = "23505"
+
+var ErrUnsupportedFilter = errors.New("not supported by the postgres filters")
+
+// IsUniqueViolation - whether the error of postgres violates a unique constraint, like mongo.IsDuplicateKeyError.
+func IsUniqueViolation(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == uniqueViolation
+}
+
+// Where - sql conditions of an equality filter of the use cases joined by AND, with the arguments of its
+// placeholders. The fields are mapped to the columns, a field without one or an operator is ErrUnsupportedFilter.
+func Where(filter bson.D, columns map[string]string) (string, []interface{}, error) {
+	if len(filter) == 0 {
+		return "TRUE", nil, nil
+	}
+
+	conditions := make([]string, 0, len(filter))
+	args := make([]interface{}, 0, len(filter))
+
+	for _, e := range filter {
+		column, ok := columns[e.Key]
+		if !ok {
+			return "", nil, errors.Wrapf(ErrUnsupportedFilter, "field %s", e.Key)
+		}
+
+		value := e.Value
+		switch v := value.(type) {
+		case bson.D, bson.M, bson.A:
+			return "", nil, errors.Wrapf(ErrUnsupportedFilter, "conditions of %s other than equality", e.Key)
+		case primitive.ObjectID:
+			value = v.Hex()
+		}
+
+		args = append(args, value)
+		conditions = append(conditions, column+" = $"+strconv.Itoa(len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args, nil
+}
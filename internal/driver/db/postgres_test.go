@@ -0,0 +1,52 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWhere(t *testing.T) {
+
+	columns := map[string]string{"_id": "id", "post_id": "post_id", "depth": "depth"}
+	id := primitive.NewObjectID()
+
+	t.Run("empty", func(t *testing.T) {
+		where, args, err := Where(bson.D{}, columns)
+		require.NoError(t, err)
+		require.Equal(t, "TRUE", where)
+		require.Empty(t, args)
+	})
+
+	t.Run("equal", func(t *testing.T) {
+		where, args, err := Where(bson.D{{"post_id", "abc"}, {"depth", 0}, {"_id", id}}, columns)
+		require.NoError(t, err)
+		require.Equal(t, "post_id = $1 AND depth = $2 AND id = $3", where)
+		require.Equal(t, []interface{}{"abc", 0, id.Hex()}, args)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, _, err := Where(bson.D{{"password", "secret"}}, columns)
+		require.True(t, errors.Is(err, ErrUnsupportedFilter))
+	})
+
+	t.Run("operator", func(t *testing.T) {
+		_, _, err := Where(bson.D{{"depth", bson.D{{"$gt", 1}}}}, columns)
+		require.True(t, errors.Is(err, ErrUnsupportedFilter))
+	})
+}
+
+// sqlStateError - stands for the errors of pgconn, which report their code by SQLState
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sql state " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestIsUniqueViolation(t *testing.T) {
+	require.True(t, IsUniqueViolation(errors.Wrap(sqlStateError("23505"), "insert")))
+	require.False(t, IsUniqueViolation(sqlStateError("23503")))
+	require.False(t, IsUniqueViolation(errors.New("connection refused")))
+}
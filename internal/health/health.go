@@ -0,0 +1,145 @@
+package health
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusReady    = "ready"
+	StatusNotReady = "not ready"
+	StatusDraining = "draining"
+)
+
+// Report - readiness of the app and of every dependency.
+type Report struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks,omitempty"`
+}
+
+type Check struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Checker - tracks the state of mongo, fed by the heartbeats of the driver and checked by a ping on demand.
+type Checker struct {
+	ping    func(ctx context.Context) error
+	timeout time.Duration
+
+	// heartbeat - error of the last heartbeat, nil until one fails
+	mu        sync.RWMutex
+	heartbeat error
+
+	draining int32
+
+	// checks - further dependencies by name, pinged on every readiness check as well
+	checks map[string]func(ctx context.Context) error
+}
+
+// New - ping is called with the given timeout on every readiness check. A nil ping leaves mongo out of the
+// report, for an app running without it.
+func New(ping func(ctx context.Context) error, timeout time.Duration) *Checker {
+	return &Checker{
+		ping:    ping,
+		timeout: timeout,
+	}
+}
+
+// AddCheck - adds a dependency reported under the name, the app is not ready while its ping fails.
+// The checks must be added before the readiness is checked.
+func (h *Checker) AddCheck(name string, ping func(ctx context.Context) error) {
+	if h.checks == nil {
+		h.checks = make(map[string]func(ctx context.Context) error)
+	}
+	h.checks[name] = ping
+}
+
+// HeartbeatSucceeded - records a successful heartbeat of the mongo server.
+func (h *Checker) HeartbeatSucceeded() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.heartbeat = nil
+}
+
+// HeartbeatFailed - records a failed heartbeat, the app is not ready until the next successful one.
+func (h *Checker) HeartbeatFailed(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.heartbeat = err
+}
+
+// Drain - marks the app as shutting down, it is never ready again.
+func (h *Checker) Drain() {
+	atomic.StoreInt32(&h.draining, 1)
+}
+
+// Check - returns the readiness report and whether the app is ready.
+func (h *Checker) Check(ctx context.Context) (*Report, bool) {
+	if atomic.LoadInt32(&h.draining) == 1 {
+		return &Report{Status: StatusDraining}, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	report := &Report{
+		Status: StatusReady,
+		Checks: make(map[string]Check),
+	}
+
+	if h.ping != nil {
+		mongo := Check{Status: StatusUp}
+
+		h.mu.RLock()
+		heartbeat := h.heartbeat
+		h.mu.RUnlock()
+
+		// a fresh ping catches an outage the last heartbeat, up to a heartbeat interval old, has not seen yet
+		if err := h.ping(ctx); err != nil {
+			mongo = Check{Status: StatusDown, Error: "ping: " + err.Error()}
+		} else if heartbeat != nil {
+			mongo = Check{Status: StatusDown, Error: "heartbeat: " + heartbeat.Error()}
+		}
+
+		report.Checks["mongo"] = mongo
+	}
+
+	for name, ping := range h.checks {
+		check := Check{Status: StatusUp}
+		if err := ping(ctx); err != nil {
+			check = Check{Status: StatusDown, Error: "ping: " + err.Error()}
+		}
+		report.Checks[name] = check
+	}
+
+	for _, check := range report.Checks {
+		if check.Status != StatusUp {
+			report.Status = StatusNotReady
+			return report, false
+		}
+	}
+
+	return report, true
+}
+
+// Liveness - the process is alive as long as it serves requests.
+func (h *Checker) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, Report{Status: StatusUp})
+}
+
+// Readiness - 200 when the app can serve traffic, 503 otherwise.
+func (h *Checker) Readiness(c *gin.Context) {
+	report, ok := h.Check(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,118 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aliykh/reddit-feed/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+
+	var pingErr error
+	checker := New(func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			return errors.New("ping without a timeout")
+		}
+		return pingErr
+	}, time.Second)
+
+	router := gin.New()
+	router.GET("/healthz", checker.Liveness)
+	router.GET("/readyz", checker.Readiness)
+
+	call := func(path string) (int, *Report) {
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, path, nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+
+		report := &Report{}
+		require.NoError(t, json.Unmarshal(resp.Body, report))
+
+		return resp.StatusCode, report
+	}
+
+	t.Run("ready", func(t *testing.T) {
+		status, report := call("/readyz")
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, StatusReady, report.Status)
+		require.Equal(t, Check{Status: StatusUp}, report.Checks["mongo"])
+	})
+
+	t.Run("failed heartbeat", func(t *testing.T) {
+		checker.HeartbeatFailed(errors.New("connection refused"))
+
+		status, report := call("/readyz")
+
+		require.Equal(t, http.StatusServiceUnavailable, status)
+		require.Equal(t, StatusNotReady, report.Status)
+		require.Equal(t, StatusDown, report.Checks["mongo"].Status)
+		require.Equal(t, "heartbeat: connection refused", report.Checks["mongo"].Error)
+
+		checker.HeartbeatSucceeded()
+
+		status, _ = call("/readyz")
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("failed ping", func(t *testing.T) {
+		pingErr = context.DeadlineExceeded
+		defer func() { pingErr = nil }()
+
+		status, report := call("/readyz")
+
+		require.Equal(t, http.StatusServiceUnavailable, status)
+		require.Equal(t, "ping: "+context.DeadlineExceeded.Error(), report.Checks["mongo"].Error)
+	})
+
+	t.Run("added check", func(t *testing.T) {
+		var postgresErr error
+		checker.AddCheck("postgres", func(ctx context.Context) error {
+			return postgresErr
+		})
+		defer delete(checker.checks, "postgres")
+
+		status, report := call("/readyz")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, Check{Status: StatusUp}, report.Checks["postgres"])
+
+		postgresErr = errors.New("connection refused")
+
+		status, report = call("/readyz")
+		require.Equal(t, http.StatusServiceUnavailable, status)
+		require.Equal(t, StatusNotReady, report.Status)
+		require.Equal(t, StatusUp, report.Checks["mongo"].Status)
+		require.Equal(t, "ping: connection refused", report.Checks["postgres"].Error)
+	})
+
+	t.Run("without mongo", func(t *testing.T) {
+		report, ok := New(nil, time.Second).Check(context.Background())
+
+		require.True(t, ok)
+		require.Equal(t, StatusReady, report.Status)
+		require.Empty(t, report.Checks)
+	})
+
+	t.Run("draining", func(t *testing.T) {
+		checker.Drain()
+
+		status, report := call("/readyz")
+		require.Equal(t, http.StatusServiceUnavailable, status)
+		require.Equal(t, StatusDraining, report.Status)
+
+		// alive until the process exits
+		status, report = call("/healthz")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, StatusUp, report.Status)
+	})
+
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"github.com/aliykh/reddit-feed/internal/auth"
+	"github.com/aliykh/reddit-feed/internal/http/server/helpers"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+// Authenticate - puts the principal of a valid bearer token into the request context.
+// Requests without a token pass through anonymously, a present but invalid token is rejected.
+func Authenticate(verifier *auth.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		if !strings.HasPrefix(header, bearerPrefix) {
+			abort(c, customErrors.New(http.StatusUnauthorized, auth.ErrInvalidToken))
+			return
+		}
+
+		principal, err := verifier.Verify(strings.TrimPrefix(header, bearerPrefix))
+		if err != nil {
+			abort(c, customErrors.New(http.StatusUnauthorized, err))
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// RequireAuth - rejects anonymous requests, must run after Authenticate.
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := auth.FromContext(c.Request.Context()); !ok {
+			abort(c, customErrors.New(http.StatusUnauthorized, customErrors.Unauthorized))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireModerator - rejects requests of users who are not moderators, must run after Authenticate.
+func RequireModerator(moderators []string) gin.HandlerFunc {
+	allowed := make(map[string]struct{}, len(moderators))
+	for _, id := range moderators {
+		allowed[id] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		p, ok := auth.FromContext(c.Request.Context())
+		if !ok {
+			abort(c, customErrors.New(http.StatusUnauthorized, customErrors.Unauthorized))
+			return
+		}
+		if _, ok = allowed[p.UserID]; !ok {
+			abort(c, customErrors.New(http.StatusForbidden, customErrors.Forbidden))
+			return
+		}
+		c.Next()
+	}
+}
+
+func abort(c *gin.Context, err error) {
+	helpers.RespondError(c, err)
+	c.Abort()
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aliykh/reddit-feed/internal/auth"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth(t *testing.T) {
+
+	keys, err := auth.NewKeys(auth.HS256, "test-secret", "", "")
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(Authenticate(auth.NewVerifier(keys)))
+
+	whoami := func(c *gin.Context) {
+		p, ok := auth.FromContext(c.Request.Context())
+		if !ok {
+			c.String(http.StatusOK, "anonymous")
+			return
+		}
+		c.String(http.StatusOK, p.UserID)
+	}
+
+	router.GET("/public", whoami)
+	router.GET("/private", RequireAuth(), whoami)
+	router.GET("/moderation", RequireModerator([]string{"t2_mod"}), whoami)
+
+	token, _, err := auth.NewIssuer(keys, time.Hour).Issue(&auth.Principal{UserID: "t2_user"})
+	require.NoError(t, err)
+
+	call := func(path, authorization string) *utils.Response {
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, path, nil)
+		require.NoError(t, err)
+
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	t.Run("public anonymous", func(t *testing.T) {
+		resp := call("/public", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "anonymous", string(resp.Body))
+	})
+
+	t.Run("public authenticated", func(t *testing.T) {
+		resp := call("/public", "Bearer "+token)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "t2_user", string(resp.Body))
+	})
+
+	t.Run("private anonymous", func(t *testing.T) {
+		resp := call("/private", "")
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		errData := &customErrors.ErrorResponse{}
+		require.NoError(t, json.Unmarshal(resp.Body, &errData))
+		require.Equal(t, customErrors.New(http.StatusUnauthorized, customErrors.Unauthorized), errData)
+	})
+
+	t.Run("private authenticated", func(t *testing.T) {
+		resp := call("/private", "Bearer "+token)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "t2_user", string(resp.Body))
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		resp := call("/public", "Bearer "+token+"x")
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		errData := &customErrors.ErrorResponse{}
+		require.NoError(t, json.Unmarshal(resp.Body, &errData))
+		require.Equal(t, customErrors.New(http.StatusUnauthorized, auth.ErrInvalidToken), errData)
+	})
+
+	t.Run("moderator", func(t *testing.T) {
+		modToken, _, err := auth.NewIssuer(keys, time.Hour).Issue(&auth.Principal{UserID: "t2_mod"})
+		require.NoError(t, err)
+
+		resp := call("/moderation", "Bearer "+modToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "t2_mod", string(resp.Body))
+	})
+
+	t.Run("not a moderator", func(t *testing.T) {
+		resp := call("/moderation", "Bearer "+token)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = call("/moderation", "")
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("not a bearer token", func(t *testing.T) {
+		resp := call("/public", "Basic dXNlcjpwYXNz")
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"github.com/aliykh/reddit-feed/internal/config"
+	"sync"
+	"time"
+)
+
+// sweepInterval - how often idle buckets are dropped from memory
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	rate   float64
+	burst  float64
+}
+
+// refill - returns the tokens of the bucket at the given time.
+func (b *bucket) refill(now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.last).Seconds()*b.rate
+	if tokens > b.burst {
+		return b.burst
+	}
+	return tokens
+}
+
+// MemoryBackend - token buckets kept in the memory of a single instance.
+type MemoryBackend struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (m *MemoryBackend) Take(_ context.Context, key string, limit config.RateLimitConfig) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		m.sweep(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		burst := float64(limit.Capacity())
+		b = &bucket{tokens: burst, last: now, rate: limit.Rate(), burst: burst}
+		m.buckets[key] = b
+	}
+
+	b.tokens = b.refill(now)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// sweep - drops the buckets that have refilled completely, they are the same as missing ones.
+func (m *MemoryBackend) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if b.refill(now) >= b.burst {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aliykh/reddit-feed/internal/metrics"
+	"github.com/aliykh/reddit-feed/internal/posts/placement"
+	"github.com/aliykh/reddit-feed/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+
+	router := gin.New()
+	router.Use(Metrics())
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/post/:id", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	call := func(path string) *utils.Response {
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, path, nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	call("/post/1")
+	call("/post/2")
+	call("/missing")
+
+	metrics.ObservePlacement(placement.Result{Inserted: 1, SkippedNSFW: 1})
+
+	resp := call("/metrics")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := string(resp.Body)
+
+	// requests are labelled by the route pattern rather than the raw path
+	require.True(t, strings.Contains(body, `reddit_feed_http_requests_total{method="GET",route="/post/:id",status="404"} 2`), body)
+	require.True(t, strings.Contains(body, `reddit_feed_http_requests_total{method="GET",route="unmatched",status="404"} 1`), body)
+	require.True(t, strings.Contains(body, `reddit_feed_http_request_duration_seconds_count{method="GET",route="/post/:id",status="404"} 2`), body)
+
+	require.True(t, strings.Contains(body, `reddit_feed_feed_promoted_slots_total{outcome="inserted"} 1`), body)
+	require.True(t, strings.Contains(body, `reddit_feed_feed_promoted_slots_total{outcome="skipped_nsfw"} 1`), body)
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"github.com/aliykh/reddit-feed/internal/auth"
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// names of the rate limited routes, see config.RateLimits
+const (
+	LimitCreatePost = "create_post"
+	LimitFeed       = "feed"
+	LimitImport     = "import"
+)
+
+// Backend - storage of the token buckets, a shared store lets several instances enforce a single limit.
+type Backend interface {
+	// Take - takes a token from the bucket of the key, when the bucket is empty
+	// it reports how long to wait for the next token.
+	Take(ctx context.Context, key string, limit config.RateLimitConfig) (bool, time.Duration, error)
+}
+
+// RateLimiter - builds the rate limiting middlewares of the configured routes.
+type RateLimiter struct {
+	limits  map[string]config.RateLimitConfig
+	backend Backend
+}
+
+func NewRateLimiter(limits map[string]config.RateLimitConfig, backend Backend) *RateLimiter {
+	return &RateLimiter{
+		limits:  limits,
+		backend: backend,
+	}
+}
+
+// Limit - limits the requests of every user, or client ip for anonymous requests, to the named route.
+// Routes without a configured limit are not limited. Must run after Authenticate. The client ip is only
+// taken from X-Forwarded-For behind the trusted proxies of the router.
+func (l *RateLimiter) Limit(name string) gin.HandlerFunc {
+	limit, ok := l.limits[name]
+	if !ok || limit.Requests <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	return func(c *gin.Context) {
+		key := name + ":ip:" + c.ClientIP()
+		if p, ok := auth.FromContext(c.Request.Context()); ok {
+			key = name + ":user:" + p.UserID
+		}
+
+		allowed, retryAfter, err := l.backend.Take(c.Request.Context(), key, limit)
+
+		// an unavailable backend must not take the api down with it
+		if err != nil || allowed {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		abort(c, customErrors.New(http.StatusTooManyRequests, customErrors.TooManyRequests))
+	}
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aliykh/reddit-feed/internal/auth"
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type failingBackend struct{}
+
+func (failingBackend) Take(context.Context, string, config.RateLimitConfig) (bool, time.Duration, error) {
+	return false, 0, errors.New("unavailable")
+}
+
+func TestRateLimiter(t *testing.T) {
+
+	keys, err := auth.NewKeys(auth.HS256, "test-secret", "", "")
+	require.NoError(t, err)
+
+	limits := map[string]config.RateLimitConfig{
+		LimitCreatePost: {Requests: 2, Per: time.Minute},
+		LimitFeed:       {Requests: 0},
+	}
+
+	now := time.Now()
+	backend := NewMemoryBackend()
+	backend.now = func() time.Time { return now }
+
+	newRouter := func(backend Backend) *gin.Engine {
+		limiter := NewRateLimiter(limits, backend)
+
+		router := gin.New()
+		router.Use(Authenticate(auth.NewVerifier(keys)))
+		router.GET("/create", limiter.Limit(LimitCreatePost), func(c *gin.Context) { c.Status(http.StatusOK) })
+		router.GET("/feed", limiter.Limit(LimitFeed), func(c *gin.Context) { c.Status(http.StatusOK) })
+		router.GET("/unknown", limiter.Limit("unknown"), func(c *gin.Context) { c.Status(http.StatusOK) })
+		return router
+	}
+
+	router := newRouter(backend)
+
+	call := func(router *gin.Engine, path, user, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":1234"
+
+		if user != "" {
+			token, _, err := auth.NewIssuer(keys, time.Hour).Issue(&auth.Principal{UserID: user})
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("limited per user", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, call(router, "/create", "t2_first", "10.0.0.1").Code)
+		require.Equal(t, http.StatusOK, call(router, "/create", "t2_first", "10.0.0.2").Code)
+
+		w := call(router, "/create", "t2_first", "10.0.0.3")
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		require.Equal(t, "30", w.Header().Get("Retry-After"))
+
+		// another user from the same address has a bucket of its own
+		require.Equal(t, http.StatusOK, call(router, "/create", "t2_second", "10.0.0.1").Code)
+	})
+
+	t.Run("limited per ip when anonymous", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, call(router, "/create", "", "10.0.1.1").Code)
+		require.Equal(t, http.StatusOK, call(router, "/create", "", "10.0.1.1").Code)
+		require.Equal(t, http.StatusTooManyRequests, call(router, "/create", "", "10.0.1.1").Code)
+		require.Equal(t, http.StatusOK, call(router, "/create", "", "10.0.1.2").Code)
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		require.Equal(t, http.StatusTooManyRequests, call(router, "/create", "t2_first", "10.0.0.1").Code)
+
+		now = now.Add(30 * time.Second)
+
+		require.Equal(t, http.StatusOK, call(router, "/create", "t2_first", "10.0.0.1").Code)
+		require.Equal(t, http.StatusTooManyRequests, call(router, "/create", "t2_first", "10.0.0.1").Code)
+	})
+
+	t.Run("disabled and unknown limits", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			require.Equal(t, http.StatusOK, call(router, "/feed", "", "10.0.2.1").Code)
+			require.Equal(t, http.StatusOK, call(router, "/unknown", "", "10.0.2.1").Code)
+		}
+	})
+
+	t.Run("fails open", func(t *testing.T) {
+		router := newRouter(failingBackend{})
+		require.Equal(t, http.StatusOK, call(router, "/create", "t2_first", "10.0.0.1").Code)
+	})
+
+}
+
+func TestMemoryBackend_Sweep(t *testing.T) {
+
+	now := time.Now()
+	backend := NewMemoryBackend()
+	backend.now = func() time.Time { return now }
+
+	strict := config.RateLimitConfig{Requests: 1, Per: time.Hour}
+	loose := config.RateLimitConfig{Requests: 100, Per: time.Second}
+
+	allowed, _, err := backend.Take(context.Background(), "strict", strict)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	now = now.Add(2 * sweepInterval)
+
+	// the sweep triggered by a loose limit keeps the bucket of the strict one
+	allowed, _, err = backend.Take(context.Background(), "loose", loose)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, wait, err := backend.Take(context.Background(), "strict", strict)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.True(t, wait > 0)
+}
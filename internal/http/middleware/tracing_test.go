@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aliykh/reddit-feed/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTrace(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	router := gin.New()
+	router.Use(Trace())
+	router.GET("/post/:id", func(c *gin.Context) {
+		// the handler sees the server span on the request context
+		if !trace.SpanFromContext(c.Request.Context()).SpanContext().IsValid() {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("continues the trace of the caller", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/post/1", nil)
+		require.NoError(t, err)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+
+		span := spans[0]
+		require.Equal(t, "GET /post/:id", span.Name())
+		require.Equal(t, trace.SpanKindServer, span.SpanKind())
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+		require.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+		require.Contains(t, span.Attributes(), semconv.HTTPStatusCodeKey.Int(http.StatusOK))
+	})
+
+	t.Run("unmatched route", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/missing", nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		spans := recorder.Ended()
+		span := spans[len(spans)-1]
+
+		require.Equal(t, "GET "+unmatchedRoute, span.Name())
+		require.False(t, span.Parent().IsValid())
+		// client errors are not failures of the server
+		require.Equal(t, codes.Unset, span.Status().Code)
+	})
+
+}
@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"github.com/aliykh/reddit-feed/internal/auth"
 	"github.com/aliykh/reddit-feed/pkg/customErrors"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -19,9 +20,22 @@ func RespondCreated(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusCreated, data)
 }
 
+func RespondNoContent(c *gin.Context) {
+	c.Status(http.StatusNoContent)
+}
+
 func RespondError(c *gin.Context, err error) {
 	data := customErrors.ParseError(err)
 	// mb only set description on development env, otherwise do not set it
 	// data.Description = err.Error()
 	c.JSON(data.ErrStatus, data)
 }
+
+// UserID - returns the id of the authenticated user making the request.
+func UserID(c *gin.Context) (string, error) {
+	principal, ok := auth.FromContext(c.Request.Context())
+	if !ok {
+		return "", customErrors.New(http.StatusUnauthorized, customErrors.Unauthorized)
+	}
+	return principal.UserID, nil
+}
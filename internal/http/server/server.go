@@ -1,10 +1,29 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"github.com/aliykh/reddit-feed/internal/auth"
+	commentsHttp "github.com/aliykh/reddit-feed/internal/comments/delivery/http"
+	commentsRepository "github.com/aliykh/reddit-feed/internal/comments/repository"
+	commentsUseCase "github.com/aliykh/reddit-feed/internal/comments/usecase"
 	"github.com/aliykh/reddit-feed/internal/driver/db"
+	"github.com/aliykh/reddit-feed/internal/health"
+	"github.com/aliykh/reddit-feed/internal/http/middleware"
+	"github.com/aliykh/reddit-feed/internal/metrics"
 	postsHttp "github.com/aliykh/reddit-feed/internal/posts/delivery/http"
+	"github.com/aliykh/reddit-feed/internal/posts/live"
+	"github.com/aliykh/reddit-feed/internal/posts/placement"
 	"github.com/aliykh/reddit-feed/internal/posts/repository"
+	"github.com/aliykh/reddit-feed/internal/posts/snapshot"
+	"github.com/aliykh/reddit-feed/internal/posts/stream"
+	subredditsHttp "github.com/aliykh/reddit-feed/internal/subreddits/delivery/http"
+	subredditsRepository "github.com/aliykh/reddit-feed/internal/subreddits/repository"
+	subredditsUseCase "github.com/aliykh/reddit-feed/internal/subreddits/usecase"
+	usersHttp "github.com/aliykh/reddit-feed/internal/users/delivery/http"
+	usersRepository "github.com/aliykh/reddit-feed/internal/users/repository"
+	usersUseCase "github.com/aliykh/reddit-feed/internal/users/usecase"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"go.mongodb.org/mongo-driver/mongo"
 	"net/http"
 
@@ -22,8 +41,10 @@ import (
 
 	"github.com/aliykh/reddit-feed/internal/config"
 	"github.com/aliykh/reddit-feed/internal/posts/usecase"
+	"github.com/aliykh/reddit-feed/pkg/cache"
 	"github.com/aliykh/reddit-feed/pkg/customErrors"
 	"github.com/aliykh/reddit-feed/pkg/helpers"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
 )
 
 type Server struct {
@@ -31,28 +52,81 @@ type Server struct {
 	logger   *log.Factory
 	router   *gin.Engine
 	dbClient *mongo.Client
+	// pgPool - nil unless the storage is postgres, dbClient is nil unless it is mongo
+	pgPool *pgxpool.Pool
+	keys   *auth.Keys
+
+	// events of the created posts, the change stream feeding it runs until stopWatch is called
+	broker    *stream.Broker
+	stopWatch context.CancelFunc
+	// hub - websocket connections following the counters of the posts
+	hub *live.Hub
+	// snapshots - refreshes the hot feed snapshots once started, nil when they are disabled
+	snapshots *snapshot.Worker
 }
 
 func (s *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 	s.router.ServeHTTP(writer, request)
 }
 
-func New(cfg *config.Config, logger *log.Factory, dbClient *mongo.Client) (*Server, error) {
+// SnapshotWorker - the worker of the hot feed snapshots, nil when they are disabled. The feeds are read live
+// until it is started.
+func (s *Server) SnapshotWorker() *snapshot.Worker {
+	return s.snapshots
+}
+
+// Shutdown - disconnects the clients of the event stream, so the http server does not wait for them.
+// Websocket connections are hijacked, the http server does not close them at all.
+func (s *Server) Shutdown() {
+	if s.stopWatch != nil {
+		s.stopWatch()
+	}
+	s.broker.Close()
+	s.hub.Close()
+}
+
+func New(cfg *config.Config, logger *log.Factory, dbClient *mongo.Client, pgPool *pgxpool.Pool, checker *health.Checker) (*Server, error) {
 
 	sv := &Server{
 		cfg:      cfg,
 		logger:   logger,
 		dbClient: dbClient,
+		pgPool:   pgPool,
+	}
+
+	keys, err := auth.NewKeys(cfg.JWTAlgorithm, cfg.JWTSecret, cfg.JWTPrivateKey, cfg.JWTPublicKey)
+	if err != nil {
+		return nil, err
 	}
+	sv.keys = keys
 
 	router := gin.Default()
 
+	// gin trusts the X-Forwarded-For of any client by default, which would hand out a fresh rate limit
+	// bucket for every forged address
+	if err = router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
+
+	// count and time every request, the recovered panics included
+	router.Use(middleware.Metrics())
+
+	// start a server span for every request, continuing the trace of the caller
+	router.Use(middleware.Trace())
+
 	// configuring go-validator
 	sv.setupValidators()
 
 	// register swagger handler
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// expose prometheus metrics
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// liveness and readiness probes
+	router.GET("/healthz", checker.Liveness)
+	router.GET("/readyz", checker.Readiness)
+
 	// set gin router as our default http server router
 	sv.router = router
 
@@ -64,6 +138,9 @@ func New(cfg *config.Config, logger *log.Factory, dbClient *mongo.Client) (*Serv
 		c.AbortWithStatus(http.StatusInternalServerError)
 	}))
 
+	// identify the user of every request carrying a bearer token, routes decide whether one is required
+	router.Use(middleware.Authenticate(auth.NewVerifier(keys)))
+
 	// register all endpoints in our application
 	sv.mapHandlers()
 
@@ -82,16 +159,109 @@ func (s *Server) setupValidators() {
 
 }
 
+// repositories - the repositories of every module, kept in the configured storage.
+type repositories struct {
+	posts      repository.Repository
+	votes      repository.VoteRepository
+	audit      repository.AuditRepository
+	keys       repository.IdempotencyRepository
+	subreddits subredditsRepository.Repository
+	users      usersRepository.Repository
+	comments   commentsRepository.Repository
+}
+
+func (s *Server) repositories() *repositories {
+	if s.cfg.Storage == "postgres" {
+		return &repositories{
+			posts:      repository.NewPostgres(s.logger, s.pgPool),
+			votes:      repository.NewPostgresVoteRepository(s.logger, s.pgPool),
+			audit:      repository.NewPostgresAuditRepository(s.logger, s.pgPool),
+			keys:       repository.NewPostgresIdempotencyRepository(s.logger, s.pgPool, s.cfg.IdempotencyTTL),
+			subreddits: subredditsRepository.NewPostgres(s.logger, s.pgPool),
+			users:      usersRepository.NewPostgres(s.logger, s.pgPool),
+			comments:   commentsRepository.NewPostgres(s.logger, s.pgPool),
+		}
+	}
+
+	var posts repository.Repository
+	if s.cfg.Storage == "memory" {
+		posts = repository.NewMemory(s.logger)
+	} else {
+		posts = repository.New(s.logger, s.collection("posts"))
+	}
+
+	return &repositories{
+		posts:      posts,
+		votes:      repository.NewVoteRepository(s.logger, s.collection("votes", db.Index{Keys: []string{"user", "post"}, Unique: true})),
+		audit:      repository.NewAuditRepository(s.logger, s.collection("post_audit")),
+		keys:       repository.NewIdempotencyRepository(s.logger, s.collection("post_idempotency_keys"), s.cfg.IdempotencyTTL),
+		subreddits: subredditsRepository.New(s.logger, s.collection("subreddits", db.Index{Keys: []string{"name"}, Unique: true})),
+		users:      usersRepository.New(s.logger, s.collection("users", db.Index{Keys: []string{"username"}, Unique: true})),
+		comments:   commentsRepository.New(s.logger, s.collection("comments")),
+	}
+}
+
 func (s *Server) mapHandlers() {
 
-	postsCollectionRepo := db.New(s.logger, s.dbClient, s.cfg.DatabaseName, "posts")
+	repos := s.repositories()
 
-	postRepo := repository.New(s.logger, postsCollectionRepo)
-	postsUC := usecase.New(s.logger, postRepo)
-	postsHandlers := postsHttp.New(s.logger, postsUC)
+	// score and comment count changes are sent to the websocket clients following the posts
+	s.hub = live.NewHub(s.cfg.Live)
+	postRepo := repository.NewObserved(repos.posts, s.hub)
+
+	// feeds read through the cache, the writes of every module go through it to purge the cached pages
+	var feedRepo repository.Repository = postRepo
+	if s.cfg.FeedCache.Size > 0 {
+		feedRepo = repository.NewCached(s.logger, postRepo, cache.NewLRU(s.cfg.FeedCache.Size), s.cfg.FeedCache.TTL)
+	}
+
+	cursors := pagination.NewCursorCodec(s.cfg.CursorSecret, s.cfg.CursorTTL)
+	subredditsUC := subredditsUseCase.New(s.logger, repos.subreddits)
+	subredditsHandlers := subredditsHttp.New(s.logger, subredditsUC)
+
+	s.broker = stream.NewBroker(s.cfg.Stream)
+	if s.cfg.Stream.Source == "mongo" {
+		// posts created by the other instances as well, the local ones are not published twice
+		var ctx context.Context
+		ctx, s.stopWatch = context.WithCancel(context.Background())
+		go stream.Watch(ctx, s.logger, s.dbClient.Database(s.cfg.DatabaseName).Collection("posts"), s.broker)
+	}
+
+	// the snapshots are computed from the stored posts, not from the cached pages
+	var snapshots *snapshot.Store
+	if s.cfg.FeedSnapshot.Interval > 0 {
+		snapshots = snapshot.NewStore(s.cfg.FeedSnapshot.MaxAge)
+		s.snapshots = snapshot.NewWorker(s.logger, s.cfg.FeedSnapshot, postRepo, repos.subreddits, snapshots)
+	}
+
+	postsUC := usecase.New(s.logger, feedRepo, repos.votes, repos.audit, repos.keys, repos.subreddits, cursors, placement.New(s.cfg.Promoted), s.broker, snapshots)
+	postsHandlers := postsHttp.New(s.logger, usecase.NewTraced(postsUC))
+
+	usersUC := usersUseCase.New(s.logger, repos.users, auth.NewIssuer(s.keys, s.cfg.JWTTTL))
+	usersHandlers := usersHttp.New(s.logger, usersUC)
+
+	// the comment counts are written through the cache, so they purge the cached pages
+	commentsUC := commentsUseCase.New(s.logger, repos.comments, feedRepo)
+	commentsHandlers := commentsHttp.New(s.logger, commentsUC)
 
 	v1 := s.router.Group("/api/v1")
 
-	postsHttp.RegisterHandlers(v1, postsHandlers)
+	// buckets are kept in memory, every instance enforces the limits on its own
+	limiter := middleware.NewRateLimiter(s.cfg.RateLimits, middleware.NewMemoryBackend())
 
+	postsHttp.RegisterHandlers(v1, postsHandlers, limiter, s.cfg.Moderators)
+	live.RegisterHandlers(v1, live.NewHandler(s.logger, s.hub))
+	commentsHttp.RegisterHandlers(v1, commentsHandlers)
+	subredditsHttp.RegisterHandlers(v1, subredditsHandlers)
+	usersHttp.RegisterHandlers(v1, usersHandlers)
+
+}
+
+// collection - the named collection of the configured storage. The indexes are only needed in memory,
+// they repeat the unique indexes the migrations create in mongo.
+func (s *Server) collection(name string, indexes ...db.Index) db.Collection {
+	if s.cfg.Storage == "memory" {
+		return db.NewMemory(indexes...)
+	}
+	return db.New(s.logger, s.dbClient, s.cfg.DatabaseName, name)
 }
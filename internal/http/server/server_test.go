@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/aliykh/reddit-feed/internal/health"
+	postsModels "github.com/aliykh/reddit-feed/internal/posts/models"
+	usersModels "github.com/aliykh/reddit-feed/internal/users/models"
+	"github.com/aliykh/reddit-feed/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServer_Memory - the whole api on the in-memory storage, no mongo is needed.
+func TestServer_Memory(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	cfg, err := config.Load("../../../config/local.yml", nil)
+	require.NoError(t, err)
+	cfg.Storage = "memory"
+	cfg.Stream.Source = "local"
+
+	checker := health.New(nil, time.Second)
+
+	sv, err := New(cfg, logr.NewFactory(logr.Mock, "test"), nil, nil, checker)
+	require.NoError(t, err)
+	defer sv.Shutdown()
+
+	call := func(method, api, token string, body interface{}, result interface{}) int {
+		req, err := utils.MakeRequest(method, utils.JSON, "/api/v1"+api, body)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := utils.InvokeHandler(req, sv.router)
+		require.NoError(t, err)
+
+		if result != nil && resp.StatusCode < http.StatusBadRequest {
+			require.NoError(t, json.Unmarshal(resp.Body, result), string(resp.Body))
+		}
+		return resp.StatusCode
+	}
+
+	credentials := map[string]string{"username": "gopher", "password": "password1"}
+	require.Equal(t, http.StatusCreated, call(utils.POST, "/u/register", "", credentials, nil))
+	require.Equal(t, http.StatusConflict, call(utils.POST, "/u/register", "", credentials, nil))
+
+	login := &usersModels.Session{}
+	require.Equal(t, http.StatusOK, call(utils.POST, "/u/login", "", credentials, login))
+	token := login.Token
+
+	subreddit := map[string]interface{}{"name": "/r/golang", "description": "go", "nsfw": false}
+	require.Equal(t, http.StatusCreated, call(utils.POST, "/r/", token, subreddit, nil))
+
+	newPost := func(title string, promoted bool) *postsModels.Post {
+		body := map[string]interface{}{
+			"title": title, "subreddit": "/r/golang", "content": title + " content",
+			"score": 100, "promoted": promoted, "nsfw": false,
+		}
+		post := &postsModels.Post{}
+		require.Equal(t, http.StatusCreated, call(utils.POST, "/post/", token, body, post))
+		// only the votes change the score, the one sent by the client is ignored
+		require.Equal(t, 0, *post.Score)
+		return post
+	}
+
+	low := newPost("generics in go", false)
+	high := newPost("error handling", false)
+	newPost("buy our course", true)
+
+	require.Equal(t, http.StatusOK, call(utils.POST, "/post/"+high.Id+"/vote", token, map[string]string{"direction": "up"}, nil))
+
+	t.Run("feed", func(t *testing.T) {
+		feed := &postsModels.Feed{}
+		require.Equal(t, http.StatusOK, call(utils.GET, "/post/generate", "", nil, feed))
+
+		require.Equal(t, int64(2), feed.TotalCount)
+
+		var organic []string
+		for _, p := range feed.Posts {
+			if !*p.Promoted {
+				organic = append(organic, p.Id)
+			}
+		}
+		require.Equal(t, []string{high.Id, low.Id}, organic)
+	})
+
+	t.Run("cursor pages", func(t *testing.T) {
+		first := &postsModels.Feed{}
+		require.Equal(t, http.StatusOK, call(utils.GET, "/post/generate?sort=hot&size=1", "", nil, first))
+		require.NotEmpty(t, first.NextCursor)
+
+		second := &postsModels.Feed{}
+		require.Equal(t, http.StatusOK, call(utils.GET, "/post/generate?sort=hot&size=1&cursor="+first.NextCursor, "", nil, second))
+
+		require.Equal(t, high.Id, first.Posts[0].Id)
+		require.Equal(t, low.Id, second.Posts[0].Id)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		feed := &postsModels.Feed{}
+		require.Equal(t, http.StatusOK, call(utils.GET, "/post/search?q=Generics", "", nil, feed))
+
+		require.Len(t, feed.Posts, 1)
+		require.Equal(t, low.Id, feed.Posts[0].Id)
+	})
+
+	t.Run("vote and comment", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, call(utils.POST, "/post/"+low.Id+"/vote", token, map[string]string{"direction": "up"}, nil))
+		require.Equal(t, http.StatusOK, call(utils.POST, "/post/"+low.Id+"/vote", token, map[string]string{"direction": "up"}, nil))
+		require.Equal(t, http.StatusCreated, call(utils.POST, "/post/"+low.Id+"/comments", token, map[string]string{"body": "nice"}, nil))
+
+		post := &postsModels.Post{}
+		require.Equal(t, http.StatusOK, call(utils.GET, "/post/"+low.Id, "", nil, post))
+		require.Equal(t, 1, *post.Score)
+		require.Equal(t, 1, post.Ups)
+		require.Equal(t, 1, post.CommentCount)
+	})
+
+	t.Run("spoofed forwarded for", func(t *testing.T) {
+		limited := false
+		for i := 0; i < 30 && !limited; i++ {
+			req, err := utils.MakeRequest(utils.GET, utils.JSON, "/api/v1/post/generate", nil)
+			require.NoError(t, err)
+			req.RemoteAddr = "192.0.2.1:1234"
+			// a new address on every request still counts against the address of the connection
+			req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i))
+
+			resp, err := utils.InvokeHandler(req, sv.router)
+			require.NoError(t, err)
+			limited = resp.StatusCode == http.StatusTooManyRequests
+		}
+		require.True(t, limited)
+	})
+}
@@ -0,0 +1,186 @@
+package metrics
+
+import (
+	"context"
+	"github.com/aliykh/reddit-feed/internal/posts/placement"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+	"net/http"
+)
+
+const namespace = "reddit_feed"
+
+var (
+	// Registry - collectors exposed on /metrics
+	Registry = prometheus.NewRegistry()
+
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of handled http requests.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the handled http requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	MongoCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "command_duration_seconds",
+		Help:      "Latency of the mongo commands, failed ones included.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"command"})
+
+	MongoCommandErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "command_errors_total",
+		Help:      "Number of failed mongo commands.",
+	}, []string{"command"})
+
+	MongoPoolConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "pool_connections",
+		Help:      "Number of open connections of the mongo pool.",
+	})
+
+	MongoPoolInUse = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "pool_connections_in_use",
+		Help:      "Number of mongo pool connections checked out by operations.",
+	})
+
+	// PromotedPlacements - slots of the feed pages by outcome: inserted, skipped_nsfw or skipped_short
+	PromotedPlacements = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "feed",
+		Name:      "promoted_slots_total",
+		Help:      "Promoted slots of the generated feed pages by outcome.",
+	}, []string{"outcome"})
+
+	StreamSubscribers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "stream",
+		Name:      "subscribers",
+		Help:      "Number of clients connected to the stream of created posts.",
+	})
+
+	// StreamSlowConsumers - events a full client buffer could not take, by action: dropped or disconnected
+	StreamSlowConsumers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "stream",
+		Name:      "slow_consumer_events_total",
+		Help:      "Events not delivered to slow stream clients by action.",
+	}, []string{"action"})
+
+	LiveConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "live",
+		Name:      "connections",
+		Help:      "Number of open websocket connections following post updates.",
+	})
+
+	LiveSlowConnections = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "live",
+		Name:      "slow_connections_total",
+		Help:      "Number of websocket connections closed for a full send buffer.",
+	})
+
+	// FeedSnapshotRefreshed - when the hot feed snapshots were last refreshed, their age is time() minus it
+	FeedSnapshotRefreshed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "feed",
+		Name:      "snapshot_refreshed_timestamp_seconds",
+		Help:      "Unix time of the last refresh of the hot feed snapshots.",
+	})
+
+	FeedSnapshotRefreshErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "feed",
+		Name:      "snapshot_refresh_errors_total",
+		Help:      "Number of feeds whose snapshot failed to refresh.",
+	})
+
+	// FeedSnapshotReads - hot feed pages by source: hit, or missing, stale and beyond for the live query
+	FeedSnapshotReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "feed",
+		Name:      "snapshot_reads_total",
+		Help:      "Hot feed pages read from the snapshots or, by reason, from the live query.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		MongoCommandDuration,
+		MongoCommandErrors,
+		MongoPoolConnections,
+		MongoPoolInUse,
+		PromotedPlacements,
+		StreamSubscribers,
+		StreamSlowConsumers,
+		LiveConnections,
+		LiveSlowConnections,
+		FeedSnapshotRefreshed,
+		FeedSnapshotRefreshErrors,
+		FeedSnapshotReads,
+	)
+}
+
+// Handler - serves the collected metrics in the prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// CommandMonitor - records the latency and failures of every mongo command.
+func CommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			MongoCommandDuration.WithLabelValues(evt.CommandName).Observe(float64(evt.DurationNanos) / 1e9)
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			MongoCommandDuration.WithLabelValues(evt.CommandName).Observe(float64(evt.DurationNanos) / 1e9)
+			MongoCommandErrors.WithLabelValues(evt.CommandName).Inc()
+		},
+	}
+}
+
+// PoolMonitor - tracks the open and checked out connections of the mongo pool.
+func PoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(evt *event.PoolEvent) {
+			switch evt.Type {
+			case event.ConnectionCreated:
+				MongoPoolConnections.Inc()
+			case event.ConnectionClosed:
+				MongoPoolConnections.Dec()
+			case event.GetSucceeded:
+				MongoPoolInUse.Inc()
+			case event.ConnectionReturned:
+				MongoPoolInUse.Dec()
+			}
+		},
+	}
+}
+
+// ObservePlacement - counts the promoted slots of a feed page.
+func ObservePlacement(res placement.Result) {
+	PromotedPlacements.WithLabelValues("inserted").Add(float64(res.Inserted))
+	PromotedPlacements.WithLabelValues("skipped_nsfw").Add(float64(res.SkippedNSFW))
+	PromotedPlacements.WithLabelValues("skipped_short").Add(float64(res.SkippedShort))
+}
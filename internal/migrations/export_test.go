@@ -0,0 +1,8 @@
+package migrations
+
+import "time"
+
+// SetRenewInterval - shortens the lock renewal of the runner for the tests.
+func SetRenewInterval(r *Runner, d time.Duration) {
+	r.renewInterval = d
+}
@@ -0,0 +1,100 @@
+package migrations
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// fileName - <version>_<name>.<up|down>.<json|sql>, the naming of golang-migrate
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.(json|sql)$`)
+
+// SQLCommand - key of the command running the statements of a sql file, see the postgres store
+const SQLCommand = "sql"
+
+// Migration - commands applying and reverting a single version, Down is empty when it cannot be reverted.
+// A json file holds database commands, a sql file becomes a single SQLCommand with all of its statements.
+type Migration struct {
+	Version int
+	Name    string
+	Up      []bson.D
+	Down    []bson.D
+}
+
+// Load - reads the migrations of the directory, ordered by version. Other files are ignored.
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %s: version %d is also named %s", entry.Name(), version, m.Name)
+		}
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		commands := []bson.D{{{SQLCommand, string(data)}}}
+		if match[4] == "json" {
+			commands, err = parseCommands(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+
+		if match[3] == "up" {
+			m.Up = commands
+		} else {
+			m.Down = commands
+		}
+	}
+
+	result := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		result = append(result, m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result, nil
+}
+
+// parseCommands - parses the extended json array of database commands of a migration file.
+func parseCommands(data []byte) ([]bson.D, error) {
+	var doc struct {
+		Commands []bson.D `bson:"commands"`
+	}
+
+	// extended json has to be a document, so the array is wrapped into one
+	wrapped := append(append([]byte(`{"commands":`), data...), '}')
+	if err := bson.UnmarshalExtJSON(wrapped, false, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc.Commands, nil
+}
@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	embedded "github.com/aliykh/reddit-feed/migrations"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestLoad(t *testing.T) {
+
+	t.Run("ordered by version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"002_second.up.json":   {Data: []byte(`[{"createIndexes": "posts", "indexes": [{"key": {"a": 1, "b": -1}, "name": "a_b"}]}]`)},
+			"002_second.down.json": {Data: []byte(`[{"dropIndexes": "posts", "index": "a_b"}]`)},
+			"001_first.up.json":    {Data: []byte(`[]`)},
+			"embed.go":             {Data: []byte(`package migrations`)},
+		}
+
+		result, err := Load(fsys)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+
+		require.Equal(t, 1, result[0].Version)
+		require.Equal(t, "first", result[0].Name)
+		require.Nil(t, result[0].Down)
+
+		second := result[1]
+		require.Equal(t, 2, second.Version)
+		require.Equal(t, []bson.D{{{"dropIndexes", "posts"}, {"index", "a_b"}}}, second.Down)
+
+		// the command name has to stay the first element, as well as the order of the index keys
+		require.Equal(t, "createIndexes", second.Up[0][0].Key)
+		indexes := second.Up[0][1].Value.(bson.A)
+		require.Equal(t, bson.D{{"a", int32(1)}, {"b", int32(-1)}}, indexes[0].(bson.D)[0].Value)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{"001_first.up.json": {Data: []byte(`{"createIndexes": "posts"}`)}})
+		require.Error(t, err)
+	})
+
+	t.Run("conflicting names", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{
+			"001_first.up.json":   {Data: []byte(`[]`)},
+			"001_other.down.json": {Data: []byte(`[]`)},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("sql files", func(t *testing.T) {
+		statements := "CREATE TABLE a (id TEXT);\nCREATE INDEX a_id ON a (id);\n"
+
+		result, err := Load(fstest.MapFS{
+			"001_first.up.sql":   {Data: []byte(statements)},
+			"001_first.down.sql": {Data: []byte("DROP TABLE a;")},
+		})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+
+		// the statements of a file run as a single command
+		require.Equal(t, []bson.D{{{SQLCommand, statements}}}, result[0].Up)
+		require.Equal(t, []bson.D{{{SQLCommand, "DROP TABLE a;"}}}, result[0].Down)
+	})
+
+	t.Run("embedded migrations", func(t *testing.T) {
+		result, err := Load(embedded.FS)
+		require.NoError(t, err)
+		require.NotEmpty(t, result)
+
+		for _, m := range result {
+			require.NotEmpty(t, m.Up, "version %d", m.Version)
+		}
+	})
+
+	t.Run("embedded postgres migrations", func(t *testing.T) {
+		fsys, err := embedded.Postgres()
+		require.NoError(t, err)
+
+		result, err := Load(fsys)
+		require.NoError(t, err)
+		require.NotEmpty(t, result)
+
+		for _, m := range result {
+			require.Equal(t, SQLCommand, m.Up[0][0].Key, "version %d", m.Version)
+			require.NotNil(t, m.Down, "version %d", m.Version)
+		}
+	})
+
+}
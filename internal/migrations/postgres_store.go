@@ -0,0 +1,133 @@
+package migrations
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+)
+
+const (
+	// Table - applied versions of the postgres storage, named apart from the table of the golang-migrate cli
+	Table = "app_schema_migrations"
+
+	// schemaLockKey - advisory lock serializing the creation of the tables by instances starting together
+	schemaLockKey = 7077
+)
+
+type postgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore - keeps the applied versions in Table, the lock is a row of the lock table expiring like
+// the one of mongo, since an advisory lock would be bound to a single connection of the pool.
+func NewPostgresStore(pool *pgxpool.Pool) Store {
+	return &postgresStore{pool: pool}
+}
+
+// init - creates the tables of the store unless they exist.
+func (s *postgresStore) init(ctx context.Context) error {
+	err := s.pool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, schemaLockKey); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, `
+			CREATE TABLE IF NOT EXISTS `+Table+` (
+				version    INTEGER PRIMARY KEY,
+				name       TEXT NOT NULL,
+				applied_at TIMESTAMPTZ NOT NULL
+			);
+			CREATE TABLE IF NOT EXISTS `+Table+`_lock (
+				id         TEXT PRIMARY KEY,
+				owner      TEXT NOT NULL,
+				expires_at TIMESTAMPTZ NOT NULL
+			)`)
+		return err
+	})
+
+	return errors.Wrap(err, "migrations.init")
+}
+
+func (s *postgresStore) Applied(ctx context.Context) ([]Record, error) {
+	if err := s.init(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pool.Query(ctx, `SELECT version, name, applied_at FROM `+Table+` ORDER BY version`)
+	if err != nil {
+		return nil, errors.Wrap(err, "migrations.Applied")
+	}
+	defer rows.Close()
+
+	var result []Record
+	for rows.Next() {
+		var record Record
+		if err = rows.Scan(&record.Version, &record.Name, &record.AppliedAt); err != nil {
+			return nil, errors.Wrap(err, "migrations.Applied")
+		}
+		result = append(result, record)
+	}
+
+	return result, errors.Wrap(rows.Err(), "migrations.Applied")
+}
+
+func (s *postgresStore) Save(ctx context.Context, record Record) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO `+Table+` (version, name, applied_at) VALUES ($1, $2, $3)
+		ON CONFLICT (version) DO UPDATE SET name = EXCLUDED.name, applied_at = EXCLUDED.applied_at`,
+		record.Version, record.Name, record.AppliedAt)
+	return errors.Wrap(err, "migrations.Save")
+}
+
+func (s *postgresStore) Remove(ctx context.Context, version int) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM `+Table+` WHERE version = $1`, version)
+	return errors.Wrap(err, "migrations.Remove")
+}
+
+// Run - runs the statements of a SQLCommand in a transaction, a failed migration leaves no partial changes.
+func (s *postgresStore) Run(ctx context.Context, command bson.D) error {
+	if len(command) != 1 || command[0].Key != SQLCommand {
+		return errors.New("migrations.Run: only sql commands run on postgres")
+	}
+
+	statements, ok := command[0].Value.(string)
+	if !ok {
+		return errors.New("migrations.Run: the sql command needs a string")
+	}
+
+	err := s.pool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		// without arguments the statements are sent as a single simple query
+		_, err := tx.Exec(ctx, statements)
+		return err
+	})
+
+	return errors.Wrap(err, "migrations.Run")
+}
+
+func (s *postgresStore) Lock(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	if err := s.init(ctx); err != nil {
+		return false, err
+	}
+
+	now := time.Now().UTC()
+
+	// takes the lock when there is none, it expired or the owner holds it, one held by another owner is left as is
+	tag, err := s.pool.Exec(ctx, `
+		INSERT INTO `+Table+`_lock (id, owner, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+		WHERE `+Table+`_lock.expires_at < $4 OR `+Table+`_lock.owner = EXCLUDED.owner`,
+		lockID, owner, now.Add(ttl), now)
+	if err != nil {
+		return false, errors.Wrap(err, "migrations.Lock")
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *postgresStore) Unlock(ctx context.Context, owner string) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM `+Table+`_lock WHERE id = $1 AND owner = $2`, lockID, owner)
+	return errors.Wrap(err, "migrations.Unlock")
+}
@@ -0,0 +1,250 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliykh/log"
+	"github.com/dchest/uniuri"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"os"
+	"time"
+)
+
+const (
+	// lockTTL - a crashed instance holds the lock at most this long, a running one extends it
+	lockTTL = time.Minute * 5
+
+	lockPollInterval = time.Second
+)
+
+var (
+	ErrNoDown = errors.New("migration cannot be reverted")
+
+	// ErrLockLost - the lock expired while the migrations ran, another instance may have taken it
+	ErrLockLost = errors.New("migrations lock lost")
+)
+
+// Status - a known migration and whether it is applied.
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Runner - applies and reverts the migrations, one instance at a time.
+type Runner struct {
+	logger     *log.Factory
+	store      Store
+	migrations []*Migration
+
+	// identifies the lock of this process
+	owner string
+	// renewInterval - the held lock is extended this often, well within lockTTL
+	renewInterval time.Duration
+}
+
+func NewRunner(logger *log.Factory, store Store, migrations []*Migration) *Runner {
+	host, _ := os.Hostname()
+	return &Runner{
+		logger:     logger,
+		store:      store,
+		migrations: migrations,
+		owner:      fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uniuri.NewLen(8)),
+
+		renewInterval: lockTTL / 3,
+	}
+}
+
+// Up - applies the pending migrations in version order, returns how many were applied.
+// A failed migration is not recorded, its commands run again on the next attempt.
+func (r *Runner) Up(ctx context.Context) (int, error) {
+	var count int
+
+	err := r.withLock(ctx, func(ctx context.Context) error {
+		applied, err := r.appliedVersions(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range r.migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+
+			if err = r.run(ctx, m, m.Up); err != nil {
+				return err
+			}
+
+			if err = r.store.Save(ctx, Record{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}); err != nil {
+				return err
+			}
+
+			r.logger.For(ctx).Info("migration applied", zap.Int("version", m.Version), zap.String("name", m.Name))
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Down - reverts up to steps applied migrations, the latest first. Returns how many were reverted.
+func (r *Runner) Down(ctx context.Context, steps int) (int, error) {
+	var count int
+
+	err := r.withLock(ctx, func(ctx context.Context) error {
+		applied, err := r.appliedVersions(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(r.migrations) - 1; i >= 0 && count < steps; i-- {
+			m := r.migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+
+			if m.Down == nil {
+				return errors.Wrapf(ErrNoDown, "version %d", m.Version)
+			}
+
+			if err = r.run(ctx, m, m.Down); err != nil {
+				return err
+			}
+
+			if err = r.store.Remove(ctx, m.Version); err != nil {
+				return err
+			}
+
+			r.logger.For(ctx).Info("migration reverted", zap.Int("version", m.Version), zap.String("name", m.Name))
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Status - every known migration in version order.
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := r.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		record, ok := applied[m.Version]
+		result = append(result, Status{
+			Version:   m.Version,
+			Name:      m.Name,
+			Applied:   ok,
+			AppliedAt: record.AppliedAt,
+		})
+	}
+
+	return result, nil
+}
+
+func (r *Runner) run(ctx context.Context, m *Migration, commands []bson.D) error {
+	for i, command := range commands {
+		if err := r.store.Run(ctx, command); err != nil {
+			return errors.Wrapf(err, "migration %d_%s, command %d", m.Version, m.Name, i)
+		}
+	}
+	return nil
+}
+
+func (r *Runner) appliedVersions(ctx context.Context) (map[int]Record, error) {
+	records, err := r.store.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]Record, len(records))
+	for _, record := range records {
+		result[record.Version] = record
+	}
+
+	return result, nil
+}
+
+// withLock - waits for the lock until ctx is done, so concurrently starting instances apply the migrations once.
+// The lock is extended while fn runs, fn's context is cancelled when it is lost.
+func (r *Runner) withLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	for {
+		ok, err := r.store.Lock(ctx, r.owner, lockTTL)
+		if err != nil {
+			return err
+		}
+		if ok {
+			break
+		}
+
+		r.logger.For(ctx).Info("waiting for the migrations lock")
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "migrations lock")
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	defer func() {
+		// released on a fresh context, the lock would otherwise stay until it expires
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+		defer cancel()
+
+		if err := r.store.Unlock(ctx, r.owner); err != nil {
+			r.logger.Default().Error("migrations unlock", zap.String("err", err.Error()))
+		}
+	}()
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	renewed := make(chan error, 1)
+
+	go func() {
+		renewed <- r.renew(lockCtx, cancel)
+	}()
+
+	err := fn(lockCtx)
+
+	// the renewal stops before the lock is released, it would take it again otherwise
+	cancel()
+	if lost := <-renewed; lost != nil {
+		return lost
+	}
+
+	return err
+}
+
+// renew - extends the lock every renewInterval until ctx is done. A lost lock cancels ctx and is returned,
+// a failed extension is tried again on the next tick while the lock has not expired yet.
+func (r *Runner) renew(ctx context.Context, cancel context.CancelFunc) error {
+	ticker := time.NewTicker(r.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		ok, err := r.store.Lock(ctx, r.owner, lockTTL)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			r.logger.Default().Error("migrations lock renewal", zap.String("err", err.Error()))
+		case !ok:
+			cancel()
+			return ErrLockLost
+		}
+	}
+}
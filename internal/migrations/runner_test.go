@@ -0,0 +1,198 @@
+package migrations_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/migrations"
+	"github.com/aliykh/reddit-feed/internal/migrations/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRunner(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	first := &migrations.Migration{Version: 1, Name: "first", Up: []bson.D{{{"createIndexes", "posts"}}}}
+	second := &migrations.Migration{
+		Version: 2,
+		Name:    "second",
+		Up:      []bson.D{{{"createIndexes", "users"}}},
+		Down:    []bson.D{{{"dropIndexes", "users"}}},
+	}
+
+	ctx := context.Background()
+
+	t.Run("up applies the pending migrations", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mock.NewMockStore(ctrl)
+		runner := migrations.NewRunner(logger, store, []*migrations.Migration{first, second})
+
+		gomock.InOrder(
+			store.EXPECT().Lock(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil),
+			store.EXPECT().Applied(gomock.Any()).Return([]migrations.Record{{Version: 1, Name: "first"}}, nil),
+			store.EXPECT().Run(gomock.Any(), second.Up[0]).Return(nil),
+			store.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, record migrations.Record) error {
+				require.Equal(t, 2, record.Version)
+				require.Equal(t, "second", record.Name)
+				return nil
+			}),
+			store.EXPECT().Unlock(gomock.Any(), gomock.Any()).Return(nil),
+		)
+
+		count, err := runner.Up(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("failed migration is not recorded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mock.NewMockStore(ctrl)
+		runner := migrations.NewRunner(logger, store, []*migrations.Migration{first, second})
+
+		store.EXPECT().Lock(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+		store.EXPECT().Applied(gomock.Any()).Return(nil, nil)
+		store.EXPECT().Run(gomock.Any(), first.Up[0]).Return(errors.New("index conflict"))
+		store.EXPECT().Save(gomock.Any(), gomock.Any()).Times(0)
+		store.EXPECT().Unlock(gomock.Any(), gomock.Any()).Return(nil)
+
+		count, err := runner.Up(ctx)
+		require.Error(t, err)
+		require.Equal(t, 0, count)
+	})
+
+	t.Run("waits for the lock", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mock.NewMockStore(ctrl)
+		runner := migrations.NewRunner(logger, store, []*migrations.Migration{first})
+
+		ctx, cancel := context.WithTimeout(ctx, time.Millisecond*100)
+		defer cancel()
+
+		store.EXPECT().Lock(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).Times(1)
+		store.EXPECT().Applied(gomock.Any()).Times(0)
+		store.EXPECT().Unlock(gomock.Any(), gomock.Any()).Times(0)
+
+		start := time.Now()
+		_, err := runner.Up(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("extends the lock while running", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mock.NewMockStore(ctrl)
+		runner := migrations.NewRunner(logger, store, []*migrations.Migration{first})
+		migrations.SetRenewInterval(runner, time.Millisecond*10)
+
+		var owners []string
+		store.EXPECT().Lock(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, owner string, _ time.Duration) (bool, error) {
+			owners = append(owners, owner)
+			return true, nil
+		}).MinTimes(3)
+		store.EXPECT().Applied(gomock.Any()).Return(nil, nil)
+		store.EXPECT().Run(gomock.Any(), first.Up[0]).DoAndReturn(func(context.Context, bson.D) error {
+			// a long index build
+			time.Sleep(time.Millisecond * 50)
+			return nil
+		})
+		store.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+		store.EXPECT().Unlock(gomock.Any(), gomock.Any()).Return(nil)
+
+		_, err := runner.Up(ctx)
+		require.NoError(t, err)
+
+		for _, owner := range owners {
+			require.Equal(t, owners[0], owner)
+		}
+	})
+
+	t.Run("lost lock stops the migrations", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mock.NewMockStore(ctrl)
+		runner := migrations.NewRunner(logger, store, []*migrations.Migration{first})
+		migrations.SetRenewInterval(runner, time.Millisecond*10)
+
+		gomock.InOrder(
+			store.EXPECT().Lock(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil),
+			store.EXPECT().Lock(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil),
+		)
+		store.EXPECT().Applied(gomock.Any()).Return(nil, nil)
+		store.EXPECT().Run(gomock.Any(), first.Up[0]).DoAndReturn(func(ctx context.Context, _ bson.D) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		store.EXPECT().Save(gomock.Any(), gomock.Any()).Times(0)
+		store.EXPECT().Unlock(gomock.Any(), gomock.Any()).Return(nil)
+
+		_, err := runner.Up(ctx)
+		require.ErrorIs(t, err, migrations.ErrLockLost)
+	})
+
+	t.Run("down reverts the latest", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mock.NewMockStore(ctrl)
+		runner := migrations.NewRunner(logger, store, []*migrations.Migration{first, second})
+
+		store.EXPECT().Lock(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+		store.EXPECT().Applied(gomock.Any()).Return([]migrations.Record{{Version: 1}, {Version: 2}}, nil)
+		store.EXPECT().Run(gomock.Any(), second.Down[0]).Return(nil)
+		store.EXPECT().Remove(gomock.Any(), 2).Return(nil)
+		store.EXPECT().Unlock(gomock.Any(), gomock.Any()).Return(nil)
+
+		count, err := runner.Down(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("down without a down migration", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mock.NewMockStore(ctrl)
+		runner := migrations.NewRunner(logger, store, []*migrations.Migration{first, second})
+
+		store.EXPECT().Lock(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+		store.EXPECT().Applied(gomock.Any()).Return([]migrations.Record{{Version: 1}}, nil)
+		store.EXPECT().Unlock(gomock.Any(), gomock.Any()).Return(nil)
+
+		_, err := runner.Down(ctx, 1)
+		require.ErrorIs(t, err, migrations.ErrNoDown)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mock.NewMockStore(ctrl)
+		runner := migrations.NewRunner(logger, store, []*migrations.Migration{first, second})
+
+		appliedAt := time.Now().UTC()
+		store.EXPECT().Applied(gomock.Any()).Return([]migrations.Record{{Version: 1, Name: "first", AppliedAt: appliedAt}}, nil)
+
+		result, err := runner.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []migrations.Status{
+			{Version: 1, Name: "first", Applied: true, AppliedAt: appliedAt},
+			{Version: 2, Name: "second"},
+		}, result)
+	})
+
+}
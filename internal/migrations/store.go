@@ -0,0 +1,112 @@
+//go:generate mockgen -source store.go -destination mock/store_mock.go -package mock
+package migrations
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+	// Collection - applied versions and the lock, shared with the golang-migrate cli which keeps its own document there
+	Collection = "schema_migrations"
+
+	lockID = "lock"
+)
+
+// Record - an applied migration.
+type Record struct {
+	Version   int       `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// Store - keeps the applied versions and runs the migration commands.
+type Store interface {
+	// Applied - applied migrations ordered by version
+	Applied(ctx context.Context) ([]Record, error)
+	Save(ctx context.Context, record Record) error
+	Remove(ctx context.Context, version int) error
+
+	// Run - runs a database command
+	Run(ctx context.Context, command bson.D) error
+
+	// Lock - takes the lock for ttl unless another owner holds it, the owner holding it extends it.
+	// Reports whether it was taken
+	Lock(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, owner string) error
+}
+
+type mongoStore struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewMongoStore(client *mongo.Client, dbName string) Store {
+	db := client.Database(dbName)
+	return &mongoStore{
+		db:         db,
+		collection: db.Collection(Collection),
+	}
+}
+
+func (s *mongoStore) Applied(ctx context.Context) ([]Record, error) {
+	// numeric ids only, skipping the lock and the document of the migrate cli
+	filter := bson.D{{"_id", bson.D{{"$type", "number"}}}}
+
+	cursor, err := s.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{"_id", 1}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "migrations.Applied")
+	}
+
+	var result []Record
+	if err = cursor.All(ctx, &result); err != nil {
+		return nil, errors.Wrap(err, "migrations.Applied")
+	}
+
+	return result, nil
+}
+
+func (s *mongoStore) Save(ctx context.Context, record Record) error {
+	_, err := s.collection.ReplaceOne(ctx, bson.D{{"_id", record.Version}}, record, options.Replace().SetUpsert(true))
+	return errors.Wrap(err, "migrations.Save")
+}
+
+func (s *mongoStore) Remove(ctx context.Context, version int) error {
+	_, err := s.collection.DeleteOne(ctx, bson.D{{"_id", version}})
+	return errors.Wrap(err, "migrations.Remove")
+}
+
+func (s *mongoStore) Run(ctx context.Context, command bson.D) error {
+	return errors.Wrap(s.db.RunCommand(ctx, command).Err(), "migrations.Run")
+}
+
+func (s *mongoStore) Lock(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+
+	// matches an expired lock or one of the owner only, one held by another owner makes the upsert insert
+	// a second "lock" id and fail
+	filter := bson.D{{"_id", lockID}, {"$or", bson.A{
+		bson.D{{"expires_at", bson.D{{"$lt", now}}}},
+		bson.D{{"owner", owner}},
+	}}}
+	update := bson.D{{"$set", bson.D{{"owner", owner}, {"expires_at", now.Add(ttl)}}}}
+
+	_, err := s.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "migrations.Lock")
+	}
+
+	return true, nil
+}
+
+func (s *mongoStore) Unlock(ctx context.Context, owner string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.D{{"_id", lockID}, {"owner", owner}})
+	return errors.Wrap(err, "migrations.Unlock")
+}
@@ -4,5 +4,17 @@ import "github.com/gin-gonic/gin"
 
 type Handlers interface {
 	Create(c *gin.Context)
+	Import(c *gin.Context)
 	Generate(c *gin.Context)
-}
\ No newline at end of file
+	SubredditFeed(c *gin.Context)
+	Search(c *gin.Context)
+	Stream(c *gin.Context)
+	UserPosts(c *gin.Context)
+	GetByID(c *gin.Context)
+	Update(c *gin.Context)
+	Patch(c *gin.Context)
+	Delete(c *gin.Context)
+	Moderate(c *gin.Context)
+	AuditLog(c *gin.Context)
+	Vote(c *gin.Context)
+}
@@ -1,14 +1,26 @@
 package http
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/aliykh/log"
 	"github.com/aliykh/reddit-feed/internal/http/server/helpers"
 	"github.com/aliykh/reddit-feed/internal/posts"
 	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
 	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"time"
 )
 
 type handlers struct {
@@ -25,35 +37,61 @@ func New(logger *log.Factory, uc posts.UseCase) *handlers {
 
 // Create godoc
 // @Summary Create - create a new post
-// @Description - create a new post
+// @Description - create a new post, authored by the requesting user
+// @Description - a retry with the same Idempotency-Key and body returns the post created first
 // @Tags Posts
+// @Security BearerAuth
+// @Param Idempotency-Key header string false "unique key of the request, at most 255 characters"
 // @Param params body models.Post true "body"
 // @Accept json
 // @Produce json
 // @Success 200 {object} models.Post
+// @Failure 401 {object} customErrors.ErrorResponse
+// @Failure 409 {object} customErrors.ErrorResponse
+// @Failure 422 {object} customErrors.ErrorResponse
+// @Failure 429 {object} customErrors.ErrorResponse
 // @Router /post [POST]
 func (h *handlers) Create(c *gin.Context) {
 
 	model := &models.Post{}
 
+	body, err := c.GetRawData()
+	if err != nil {
+		h.logger.For(c.Request.Context()).Error(fmt.Sprintf("error while reading body: %v\n", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
 	// go-validator validations
-	if err := c.ShouldBindJSON(model); err != nil {
-		h.logger.Default().Error(fmt.Sprintf("error while binding json body: %v\n", err.Error()))
+	if err = binding.JSON.BindBody(body, model); err != nil {
+		h.logger.For(c.Request.Context()).Error(fmt.Sprintf("error while binding json body: %v\n", err.Error()))
 		helpers.RespondError(c, err)
 		return
 	}
 
 	// custom validations
-	if err := model.CheckValidity(); err != nil {
-		h.logger.Default().Error(fmt.Sprintf("post model: %v\n", err.Error()))
+	if err = model.CheckValidity(); err != nil {
+		h.logger.For(c.Request.Context()).Error(fmt.Sprintf("post model: %v\n", err.Error()))
 		helpers.RespondError(c, err)
 		return
 	}
 
-	result, err := h.uc.Create(c.Request.Context(), model)
+	var result *models.Post
+
+	if key := c.GetHeader(idempotencyKeyHeader); key != "" {
+		if len(key) > maxIdempotencyKeyLength {
+			helpers.RespondError(c, customErrors.New(http.StatusBadRequest, models.ErrIdempotencyKeyInvalid))
+			return
+		}
+
+		hash := sha256.Sum256(body)
+		result, err = h.uc.CreateIdempotent(c.Request.Context(), key, hex.EncodeToString(hash[:]), model)
+	} else {
+		result, err = h.uc.Create(c.Request.Context(), model)
+	}
 
 	if err != nil {
-		h.logger.Default().Error("post create", zap.String("err", err.Error()))
+		h.logger.For(c.Request.Context()).Error("post create", zap.String("err", err.Error()))
 		helpers.RespondError(c, err)
 		return
 	}
@@ -61,33 +99,629 @@ func (h *handlers) Create(c *gin.Context) {
 	helpers.RespondCreated(c, result)
 }
 
+// Import godoc
+// @Summary Import - creates posts in bulk
+// @Description - creates up to 1000 posts authored by the requesting user, from a json array or a ndjson stream (Content-Type: application/x-ndjson).
+// @Description Every post is validated like a created one, the invalid ones are reported by their index and do not stop the others.
+// @Tags Posts
+// @Security BearerAuth
+// @Param params body []models.Post true "body"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ImportReport
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 401 {object} customErrors.ErrorResponse
+// @Failure 413 {object} customErrors.ErrorResponse
+// @Failure 429 {object} customErrors.ErrorResponse
+// @Router /post/bulk [POST]
+func (h *handlers) Import(c *gin.Context) {
+
+	items, err := decodeImport(c)
+	if err != nil {
+		h.logger.For(c.Request.Context()).Error("post import body", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	report := &models.ImportReport{
+		Results: make([]*models.ImportResult, len(items)),
+	}
+
+	valid := make([]*models.Post, 0, len(items))
+	indexes := make([]int, 0, len(items))
+
+	for i, item := range items {
+		model := &models.Post{}
+
+		// the validations of a created post, go-validator and then the custom ones
+		err = binding.JSON.BindBody(item, model)
+		if err == nil {
+			err = model.CheckValidity()
+		}
+		if err != nil {
+			report.Results[i] = &models.ImportResult{Index: i, Errors: customErrors.Validations(err)}
+			continue
+		}
+
+		valid = append(valid, model)
+		indexes = append(indexes, i)
+	}
+
+	if len(valid) > 0 {
+		results, err := h.uc.Import(c.Request.Context(), valid)
+		if err != nil {
+			h.logger.For(c.Request.Context()).Error("post import", zap.String("err", err.Error()))
+			helpers.RespondError(c, err)
+			return
+		}
+
+		for j, result := range results {
+			result.Index = indexes[j]
+			report.Results[result.Index] = result
+		}
+	}
+
+	for _, result := range report.Results {
+		if len(result.Errors) > 0 {
+			report.Failed++
+		} else {
+			report.Created++
+		}
+	}
+
+	helpers.RespondOK(c, report)
+}
+
+// decodeImport - splits the body of an import into the raw posts. A json array is decoded as a whole,
+// a ndjson stream line by line, so a malformed line fails only its own post.
+func decodeImport(c *gin.Context) ([][]byte, error) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	var items [][]byte
+
+	if c.ContentType() == ndjsonContentType {
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxImportBytes)
+
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			if len(items) == models.MaxImportPosts {
+				return nil, customErrors.New(http.StatusRequestEntityTooLarge, models.ErrImportTooLarge)
+			}
+			items = append(items, append([]byte(nil), line...))
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, customErrors.New(http.StatusBadRequest, err)
+		}
+	} else {
+		var raw []json.RawMessage
+		if err := json.NewDecoder(body).Decode(&raw); err != nil {
+			return nil, customErrors.New(http.StatusBadRequest, err)
+		}
+		if len(raw) > models.MaxImportPosts {
+			return nil, customErrors.New(http.StatusRequestEntityTooLarge, models.ErrImportTooLarge)
+		}
+
+		for _, item := range raw {
+			items = append(items, item)
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, customErrors.New(http.StatusBadRequest, models.ErrImportEmpty)
+	}
+
+	return items, nil
+}
+
 // Generate godoc
 // @Summary Generate - generates a feed of posts
 // @Description returns a list of posts
 // @Tags Posts
+// @Param page query int false "page number, ignored when cursor is set"
+// @Param size query int false "page size, max 25"
+// @Param cursor query string false "next_cursor of the previous page, rising is only paginated by page"
+// @Param sort query string false "ranking: hot, new, top (default), rising or controversial"
+// @Param t query string false "time window of top: hour, day, week, month, year or all (default)"
+// @Param include_nsfw query bool false "false hides the nsfw posts, including the promoted ones"
+// @Param exclude_subreddits query string false "comma separated subreddits hidden from the feed, e.g. /r/a,/r/b"
+// @Param only_subreddits query string false "comma separated subreddits the feed is limited to"
 // @Accept json
 // @Produce json
 // @Success 200 {object} models.Feed
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 429 {object} customErrors.ErrorResponse
 // @Router /post/generate [GET]
 func (h *handlers) Generate(c *gin.Context) {
+	h.generate(c, "")
+}
+
+// SubredditFeed godoc
+// @Summary SubredditFeed - generates the feed of a single subreddit
+// @Description returns a list of posts of the subreddit, ranked and paginated like the main feed
+// @Tags Posts
+// @Param name path string true "subreddit name"
+// @Param page query int false "page number, ignored when cursor is set"
+// @Param size query int false "page size, max 25"
+// @Param cursor query string false "next_cursor of the previous page, rising is only paginated by page"
+// @Param sort query string false "ranking: hot, new, top (default), rising or controversial"
+// @Param t query string false "time window of top: hour, day, week, month, year or all (default)"
+// @Param include_nsfw query bool false "false hides the nsfw posts, including the promoted ones"
+// @Param exclude_subreddits query string false "comma separated subreddits hidden from the feed, e.g. /r/a,/r/b"
+// @Param only_subreddits query string false "comma separated subreddits the feed is limited to"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Feed
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Failure 429 {object} customErrors.ErrorResponse
+// @Router /r/{name}/feed [GET]
+func (h *handlers) SubredditFeed(c *gin.Context) {
+	h.generate(c, subredditPrefix+c.Param("name"))
+}
+
+// generate - binds the feed query and responds with the feed, an empty subreddit means all of them.
+func (h *handlers) generate(c *gin.Context, subreddit string) {
+
+	pg := &pagination.Query{
+		Size: 25,
+	}
+
+	if err := c.ShouldBindQuery(pg); err != nil {
+		h.logger.For(c.Request.Context()).Error("pagination query binding err", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	params := &models.FeedParams{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		h.logger.For(c.Request.Context()).Error("feed params binding err", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	if err := params.Normalize(); err != nil {
+		h.logger.For(c.Request.Context()).Error("feed content filter err", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	params.Subreddit = subreddit
+
+	res, err := h.uc.GenerateFeeds(c.Request.Context(), params, pg)
+
+	if err != nil {
+		//h.logger.For(c.Request.Context()).Error("generate feeds", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, res)
+}
+
+// Search godoc
+// @Summary Search - full-text search over the posts
+// @Description returns the organic posts whose title or content match the query, most relevant first. Relevance is blended with the post score
+// @Tags Posts
+// @Param q query string true "search terms, quoted phrases and -negations are supported"
+// @Param subreddit query string false "subreddit name, without the /r/ prefix"
+// @Param nsfw query bool false "true - only nsfw posts, false - no nsfw posts, both when unset"
+// @Param page query int false "page number, ignored when cursor is set"
+// @Param size query int false "page size, max 25"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Feed
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Failure 429 {object} customErrors.ErrorResponse
+// @Router /post/search [GET]
+func (h *handlers) Search(c *gin.Context) {
 
 	pg := &pagination.Query{
 		Size: 25,
 	}
 
 	if err := c.ShouldBindQuery(pg); err != nil {
-		h.logger.Default().Error("pagination query binding err", zap.String("err", err.Error()))
+		h.logger.For(c.Request.Context()).Error("pagination query binding err", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	params := &models.SearchParams{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		h.logger.For(c.Request.Context()).Error("search params binding err", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	if params.Subreddit != "" {
+		params.Subreddit = subredditPrefix + params.Subreddit
+	}
+
+	res, err := h.uc.Search(c.Request.Context(), params, pg)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, res)
+}
+
+// Stream godoc
+// @Summary Stream - server-sent events of the created posts
+// @Description every created post is sent as a "post" event with the id of the post. A client reconnecting with
+// @Description Last-Event-ID gets the posts it missed first, a "reset" event tells it the id is too old to resume
+// @Description and the feed should be reloaded. Connections are closed every 25s, clients reconnect after 1s.
+// @Tags Posts
+// @Param subreddit query string false "subreddit name, without the /r/ prefix"
+// @Param nsfw query bool false "only NSFW posts when true, none when false"
+// @Param Last-Event-ID header string false "id of the last received event"
+// @Produce text/event-stream
+// @Success 200 {object} models.Post
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 503 {object} customErrors.ErrorResponse
+// @Router /post/stream [GET]
+func (h *handlers) Stream(c *gin.Context) {
+
+	params := &models.StreamParams{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		h.logger.For(c.Request.Context()).Error("stream params binding err", zap.String("err", err.Error()))
 		helpers.RespondError(c, err)
 		return
 	}
 
-	res, err := h.uc.GenerateFeeds(c.Request.Context(), pg)
+	if params.Subreddit != "" {
+		params.Subreddit = subredditPrefix + params.Subreddit
+	}
 
+	sub, err := h.uc.Subscribe(c.Request.Context(), params, c.GetHeader(lastEventIDHeader))
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+	defer sub.Close()
+
+	// proxies must not buffer the events
+	c.Header("X-Accel-Buffering", "no")
+
+	// an event without data is not dispatched, it only sets the reconnection delay
+	c.Render(-1, sse.Event{Retry: uint(streamRetry.Milliseconds()), Data: ""})
+	if sub.Missed {
+		c.Render(-1, sse.Event{Event: "reset", Data: ""})
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	// ends before the write timeout of the server, the client resumes from its last event
+	lifetime := time.NewTimer(streamLifetime)
+	defer lifetime.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-sub.Events():
+			// closed for a slow client or on shutdown
+			if !ok {
+				return false
+			}
+			c.Render(-1, sse.Event{Id: event.ID, Event: "post", Data: event.Post})
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		case <-lifetime.C:
+			return false
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// UserPosts godoc
+// @Summary UserPosts - lists the posts of a user
+// @Description returns the posts authored by the user, newest first
+// @Tags Posts
+// @Param id path string true "user id"
+// @Param page query int false "page number"
+// @Param size query int false "page size, max 25"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Feed
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Router /u/{id}/posts [GET]
+func (h *handlers) UserPosts(c *gin.Context) {
+
+	pg := &pagination.Query{
+		Size: 25,
+	}
+
+	if err := c.ShouldBindQuery(pg); err != nil {
+		h.logger.For(c.Request.Context()).Error("pagination query binding err", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	res, err := h.uc.ListByAuthor(c.Request.Context(), c.Param("id"), pg)
 	if err != nil {
-		//h.logger.Default().Error("generate feeds", zap.String("err", err.Error()))
 		helpers.RespondError(c, err)
 		return
 	}
 
 	helpers.RespondOK(c, res)
 }
+
+// GetByID godoc
+// @Summary GetByID - returns a single post
+// @Description returns a post by its id
+// @Tags Posts
+// @Param id path string true "post id"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Post
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Router /post/{id} [GET]
+func (h *handlers) GetByID(c *gin.Context) {
+
+	id, err := parseID(c)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	result, err := h.uc.GetByID(c.Request.Context(), id)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, result)
+}
+
+// Update godoc
+// @Summary Update - replaces a post
+// @Description replaces all editable fields of the post, the author is kept
+// @Tags Posts
+// @Security BearerAuth
+// @Param id path string true "post id"
+// @Param params body models.Post true "body"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Post
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 401 {object} customErrors.ErrorResponse
+// @Failure 403 {object} customErrors.ErrorResponse
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Router /post/{id} [PUT]
+func (h *handlers) Update(c *gin.Context) {
+
+	id, err := parseID(c)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	model := &models.Post{}
+
+	// go-validator validations
+	if err = c.ShouldBindJSON(model); err != nil {
+		h.logger.For(c.Request.Context()).Error(fmt.Sprintf("error while binding json body: %v\n", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	result, err := h.uc.Update(c.Request.Context(), id, model)
+	if err != nil {
+		h.logger.For(c.Request.Context()).Error("post update", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, result)
+}
+
+// Patch godoc
+// @Summary Patch - partially updates a post
+// @Description updates only the provided fields of the post
+// @Tags Posts
+// @Security BearerAuth
+// @Param id path string true "post id"
+// @Param params body models.PostPatch true "body"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Post
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 401 {object} customErrors.ErrorResponse
+// @Failure 403 {object} customErrors.ErrorResponse
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Router /post/{id} [PATCH]
+func (h *handlers) Patch(c *gin.Context) {
+
+	id, err := parseID(c)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	patch := &models.PostPatch{}
+
+	// go-validator validations
+	if err = c.ShouldBindJSON(patch); err != nil {
+		h.logger.For(c.Request.Context()).Error(fmt.Sprintf("error while binding json body: %v\n", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	result, err := h.uc.Patch(c.Request.Context(), id, patch)
+	if err != nil {
+		h.logger.For(c.Request.Context()).Error("post patch", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, result)
+}
+
+// Delete godoc
+// @Summary Delete - deletes a post
+// @Description hides the post as deleted by its author, it is kept for the audit
+// @Tags Posts
+// @Security BearerAuth
+// @Param id path string true "post id"
+// @Success 204
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 401 {object} customErrors.ErrorResponse
+// @Failure 403 {object} customErrors.ErrorResponse
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Router /post/{id} [DELETE]
+func (h *handlers) Delete(c *gin.Context) {
+
+	id, err := parseID(c)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	if err = h.uc.Delete(c.Request.Context(), id); err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondNoContent(c)
+}
+
+// Moderate godoc
+// @Summary Moderate - sets the status of a post
+// @Description removes, locks or restores a post, only moderators may do so. Removed posts are hidden but kept for the audit, locked posts take no votes and comments
+// @Tags Posts
+// @Security BearerAuth
+// @Param id path string true "post id"
+// @Param params body models.Moderation true "body"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Post
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 401 {object} customErrors.ErrorResponse
+// @Failure 403 {object} customErrors.ErrorResponse
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Failure 409 {object} customErrors.ErrorResponse
+// @Router /post/{id}/status [PUT]
+func (h *handlers) Moderate(c *gin.Context) {
+
+	id, err := parseID(c)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	moderation := &models.Moderation{}
+
+	if err = c.ShouldBindJSON(moderation); err != nil {
+		h.logger.For(c.Request.Context()).Error(fmt.Sprintf("error while binding json body: %v\n", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	result, err := h.uc.Moderate(c.Request.Context(), id, moderation)
+	if err != nil {
+		h.logger.For(c.Request.Context()).Error("post moderate", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, result)
+}
+
+// AuditLog godoc
+// @Summary AuditLog - status changes of a post
+// @Description returns the status changes of the post oldest first, deleted and removed posts included. Only moderators may read it
+// @Tags Posts
+// @Security BearerAuth
+// @Param id path string true "post id"
+// @Produce json
+// @Success 200 {array} models.AuditEntry
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 401 {object} customErrors.ErrorResponse
+// @Failure 403 {object} customErrors.ErrorResponse
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Router /post/{id}/audit [GET]
+func (h *handlers) AuditLog(c *gin.Context) {
+
+	id, err := parseID(c)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	result, err := h.uc.AuditLog(c.Request.Context(), id)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, result)
+}
+
+// Vote godoc
+// @Summary Vote - up or down votes a post
+// @Description sets the vote of the user on the post, repeating the same vote has no effect
+// @Tags Posts
+// @Param id path string true "post id"
+// @Security BearerAuth
+// @Param params body models.VoteRequest true "body"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Post
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 401 {object} customErrors.ErrorResponse
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Router /post/{id}/vote [POST]
+func (h *handlers) Vote(c *gin.Context) {
+
+	id, err := parseID(c)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	user, err := helpers.UserID(c)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	req := &models.VoteRequest{}
+
+	// go-validator validations
+	if err = c.ShouldBindJSON(req); err != nil {
+		h.logger.For(c.Request.Context()).Error(fmt.Sprintf("error while binding json body: %v\n", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	result, err := h.uc.Vote(c.Request.Context(), id, user, req.Value())
+	if err != nil {
+		h.logger.For(c.Request.Context()).Error("post vote", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, result)
+}
+
+// parseID - parses the id uri param as mongo ObjectID
+func parseID(c *gin.Context) (primitive.ObjectID, error) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return primitive.NilObjectID, customErrors.New(http.StatusBadRequest, customErrors.InvalidUriParam)
+	}
+	return id, nil
+}
@@ -2,11 +2,17 @@ package http
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/auth"
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/aliykh/reddit-feed/internal/http/middleware"
 	"github.com/aliykh/reddit-feed/internal/posts/mock"
 	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/stream"
 	"github.com/aliykh/reddit-feed/pkg/customErrors"
 	"github.com/aliykh/reddit-feed/pkg/helpers"
 	"github.com/aliykh/reddit-feed/pkg/pagination"
@@ -19,9 +25,12 @@ import (
 	en_translations "github.com/go-playground/validator/v10/translations/en"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -30,6 +39,7 @@ var router *gin.Engine
 func init() {
 
 	router = gin.Default()
+	router.Use(middleware.Authenticate(auth.NewVerifier(utils.TestKeys)))
 
 	binding.Validator = new(helpers.DefaultValidator)
 
@@ -42,6 +52,22 @@ func init() {
 
 }
 
+// withPrincipal - matches a context carrying the principal with the given user id
+type withPrincipal string
+
+func (w withPrincipal) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	if !ok {
+		return false
+	}
+	p, ok := auth.FromContext(ctx)
+	return ok && p.UserID == string(w)
+}
+
+func (w withPrincipal) String() string {
+	return "has principal " + string(w)
+}
+
 func TestHandlers_Create(t *testing.T) {
 
 	reqBody := &models.Post{
@@ -71,14 +97,15 @@ func TestHandlers_Create(t *testing.T) {
 
 	postHandlers := New(logger, mockPostUC)
 
-	router.POST("/ok", postHandlers.Create)
+	router.POST("/ok", middleware.RequireAuth(), postHandlers.Create)
 
 	t.Run("passes", func(t *testing.T) {
 
 		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/ok", reqBody)
 		require.NoError(t, err)
+		req.Header.Set("Authorization", utils.Bearer(utils.TestKeys, "t2_user"))
 
-		mockPostUC.EXPECT().Create(context.Background(), gomock.Eq(model)).Return(model, nil)
+		mockPostUC.EXPECT().Create(withPrincipal("t2_user"), gomock.Eq(model)).Return(model, nil)
 
 		resp, err := utils.InvokeHandler(req, router)
 		require.NoError(t, err)
@@ -94,9 +121,10 @@ func TestHandlers_Create(t *testing.T) {
 
 		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/ok", reqBody)
 		require.NoError(t, err)
+		req.Header.Set("Authorization", utils.Bearer(utils.TestKeys, "t2_user"))
 
 		expectedErr := customErrors.New(http.StatusBadRequest, mongo.ErrNilValue)
-		mockPostUC.EXPECT().Create(context.Background(), gomock.Eq(model)).Return(nil, expectedErr)
+		mockPostUC.EXPECT().Create(withPrincipal("t2_user"), gomock.Eq(model)).Return(nil, expectedErr)
 
 		httpResult, err := utils.InvokeHandler(req, router)
 		require.NoError(t, err)
@@ -109,6 +137,197 @@ func TestHandlers_Create(t *testing.T) {
 
 	})
 
+	t.Run("no user", func(t *testing.T) {
+
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/ok", reqBody)
+		require.NoError(t, err)
+
+		httpResult, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, httpResult.StatusCode)
+
+	})
+
+}
+
+func TestHandlers_Create_Idempotent(t *testing.T) {
+
+	reqBody := &models.Post{
+		Title:     "Title101",
+		Content:   "content",
+		Subreddit: "/r/subreddit",
+		Score:     new(int),
+		Promoted:  new(bool),
+		NSFW:      new(bool),
+	}
+
+	body, err := json.Marshal(reqBody)
+	require.NoError(t, err)
+	hash := sha256.Sum256(body)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+
+	postHandlers := New(logger, mockPostUC)
+
+	router.POST("/idempotent", middleware.RequireAuth(), postHandlers.Create)
+
+	create := func(key string) *utils.Response {
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/idempotent", reqBody)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", utils.Bearer(utils.TestKeys, "t2_user"))
+		req.Header.Set("Idempotency-Key", key)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	t.Run("keyed by the body hash", func(t *testing.T) {
+		mockPostUC.EXPECT().CreateIdempotent(withPrincipal("t2_user"), "key", hex.EncodeToString(hash[:]), gomock.Eq(reqBody)).Return(reqBody, nil)
+
+		resp := create("key")
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("reused key", func(t *testing.T) {
+		mockPostUC.EXPECT().CreateIdempotent(gomock.Any(), "key", gomock.Any(), gomock.Any()).
+			Return(nil, customErrors.New(http.StatusUnprocessableEntity, models.ErrIdempotencyKeyReused))
+
+		resp := create("key")
+		require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	t.Run("key too long", func(t *testing.T) {
+		resp := create(strings.Repeat("k", maxIdempotencyKeyLength+1))
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+}
+
+func TestHandlers_Import(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+
+	postHandlers := New(logger, mockPostUC)
+
+	router.POST("/import", middleware.RequireAuth(), postHandlers.Import)
+
+	valid := &models.Post{
+		Title:     "Title101",
+		Content:   "content",
+		Subreddit: "/r/subreddit",
+		Score:     new(int),
+		Promoted:  new(bool),
+		NSFW:      new(bool),
+	}
+
+	// the use case sees the valid posts only, the results are mapped back to the body
+	importValid := func(count int) {
+		mockPostUC.EXPECT().Import(withPrincipal("t2_user"), gomock.Len(count)).DoAndReturn(func(_ context.Context, posts []*models.Post) ([]*models.ImportResult, error) {
+			results := make([]*models.ImportResult, len(posts))
+			for i := range posts {
+				results[i] = &models.ImportResult{Index: i, Id: "id"}
+			}
+			return results, nil
+		})
+	}
+
+	invoke := func(req *http.Request) (*utils.Response, *models.ImportReport) {
+		req.Header.Set("Authorization", utils.Bearer(utils.TestKeys, "t2_user"))
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+
+		report := &models.ImportReport{}
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.Unmarshal(resp.Body, report))
+		}
+
+		return resp, report
+	}
+
+	t.Run("json array", func(t *testing.T) {
+		both := &models.Post{Title: "both", Content: "content", Link: "https://example.com", Subreddit: "/r/subreddit", Score: new(int), Promoted: new(bool), NSFW: new(bool)}
+
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/import", []interface{}{valid, map[string]string{"title": "no subreddit"}, both, valid})
+		require.NoError(t, err)
+
+		importValid(2)
+
+		resp, report := invoke(req)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 2, report.Created)
+		require.Equal(t, 2, report.Failed)
+
+		require.Equal(t, &models.ImportResult{Index: 0, Id: "id"}, report.Results[0])
+		require.Equal(t, 1, report.Results[1].Index)
+		require.NotEmpty(t, report.Results[1].Errors)
+		require.Equal(t, 2, report.Results[2].Index)
+		require.NotEmpty(t, report.Results[2].Errors)
+		require.Equal(t, &models.ImportResult{Index: 3, Id: "id"}, report.Results[3])
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		line, err := json.Marshal(valid)
+		require.NoError(t, err)
+
+		body := string(line) + "\n\n{\"title\": \n" + string(line) + "\n"
+		req, err := http.NewRequest(utils.POST, "/import", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-ndjson")
+
+		importValid(2)
+
+		resp, report := invoke(req)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 2, report.Created)
+
+		// blank lines are not counted, a malformed line fails alone
+		require.Len(t, report.Results, 3)
+		require.NotEmpty(t, report.Results[1].Errors)
+		require.Equal(t, &models.ImportResult{Index: 2, Id: "id"}, report.Results[2])
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/import", []interface{}{})
+		require.NoError(t, err)
+
+		resp, _ := invoke(req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("too many", func(t *testing.T) {
+		posts := make([]*models.Post, models.MaxImportPosts+1)
+		for i := range posts {
+			posts[i] = valid
+		}
+
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/import", posts)
+		require.NoError(t, err)
+
+		resp, _ := invoke(req)
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+
+	t.Run("not an array", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/import", valid)
+		require.NoError(t, err)
+
+		resp, _ := invoke(req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
 }
 
 func TestHandlers_Create_Validation_Errs(t *testing.T) {
@@ -191,6 +410,7 @@ func TestHandlers_Create_Validation_Errs(t *testing.T) {
 
 		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/validation/fail", c.ReqBody)
 		require.NoError(t, err)
+		req.Header.Set("Authorization", utils.Bearer(utils.TestKeys, "t2_user"))
 
 		httpResult, err := utils.InvokeHandler(req, router)
 		require.NoError(t, err)
@@ -260,7 +480,7 @@ func TestHandlers_Generate(t *testing.T) {
 			},
 		}
 
-		mockPostUC.EXPECT().GenerateFeeds(context.Background(), gomock.Eq(query)).Return(feed, nil)
+		mockPostUC.EXPECT().GenerateFeeds(context.Background(), gomock.Eq(&models.FeedParams{}), gomock.Eq(query)).Return(feed, nil)
 
 		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/generate/ok", *query)
 		require.NoError(t, err)
@@ -280,7 +500,7 @@ func TestHandlers_Generate(t *testing.T) {
 
 	t.Run("usecase fail", func(t *testing.T) {
 
-		mockPostUC.EXPECT().GenerateFeeds(context.Background(), gomock.Eq(query)).Return(nil, errors.New("fails"))
+		mockPostUC.EXPECT().GenerateFeeds(context.Background(), gomock.Eq(&models.FeedParams{}), gomock.Eq(query)).Return(nil, errors.New("fails"))
 
 		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/generate/ok", *query)
 		require.NoError(t, err)
@@ -317,6 +537,608 @@ func TestHandlers_Generate(t *testing.T) {
 
 	})
 
+	t.Run("sort", func(t *testing.T) {
+		params := &models.FeedParams{Sort: "top", Window: "week"}
 
+		mockPostUC.EXPECT().GenerateFeeds(context.Background(), gomock.Eq(params), gomock.Eq(&pagination.Query{Size: 25})).Return(&models.Feed{}, nil)
 
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/generate/ok", *params)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("unknown sort", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/generate/ok", map[string]string{
+			"sort": "best",
+		})
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		errData := &customErrors.ErrorResponse{}
+		err = json.Unmarshal(resp.Body, &errData)
+		require.NoError(t, err)
+		require.Equal(t, "sort", errData.Errors[0].Field)
+	})
+
+
+
+}
+
+func TestHandlers_GetByID(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	postHandlers := New(logger, mockPostUC)
+
+	// routes with uri params need their own engine, contexts pooled by the shared router have no room for params
+	router := gin.Default()
+	router.GET("/get/:id", postHandlers.GetByID)
+
+	id := primitive.NewObjectID()
+
+	t.Run("ok", func(t *testing.T) {
+		model := &models.Post{
+			Id:        id.Hex(),
+			Title:     "Title101",
+			Author:    "t2_6wmjk11m",
+			Link:      "https://www.example.com",
+			Subreddit: "/r/subreddit",
+			Score:     new(int),
+			Promoted:  new(bool),
+			NSFW:      new(bool),
+		}
+
+		mockPostUC.EXPECT().GetByID(context.Background(), id).Return(model, nil)
+
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/get/"+id.Hex(), nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		data := &models.Post{}
+		err = json.Unmarshal(resp.Body, &data)
+		require.NoError(t, err)
+		require.Equal(t, model, data)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/get/1234", nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		errData := &customErrors.ErrorResponse{}
+		err = json.Unmarshal(resp.Body, &errData)
+		require.NoError(t, err)
+		require.Equal(t, customErrors.New(http.StatusBadRequest, customErrors.InvalidUriParam), errData)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		expectedErr := customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		mockPostUC.EXPECT().GetByID(context.Background(), id).Return(nil, expectedErr)
+
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/get/"+id.Hex(), nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		errData := &customErrors.ErrorResponse{}
+		err = json.Unmarshal(resp.Body, &errData)
+		require.NoError(t, err)
+		require.Equal(t, expectedErr, errData)
+	})
+}
+
+func TestHandlers_Update(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	postHandlers := New(logger, mockPostUC)
+
+	router := gin.Default()
+	router.PUT("/update/:id", postHandlers.Update)
+
+	id := primitive.NewObjectID()
+
+	model := &models.Post{
+		Title:     "Title101",
+		Content:   "content",
+		Subreddit: "/r/subreddit",
+		Score:     new(int),
+		Promoted:  new(bool),
+		NSFW:      new(bool),
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		mockPostUC.EXPECT().Update(context.Background(), id, gomock.Eq(model)).Return(model, nil)
+
+		req, err := utils.MakeRequest(utils.PUT, utils.JSON, "/update/"+id.Hex(), model)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("validation fails", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.PUT, utils.JSON, "/update/"+id.Hex(), &models.Post{Title: "Title101"})
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestHandlers_Patch(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	postHandlers := New(logger, mockPostUC)
+
+	router := gin.Default()
+	router.PATCH("/patch/:id", postHandlers.Patch)
+
+	id := primitive.NewObjectID()
+
+	t.Run("ok", func(t *testing.T) {
+		title := "new title"
+		patch := &models.PostPatch{Title: &title}
+
+		model := &models.Post{
+			Id:        id.Hex(),
+			Title:     title,
+			Content:   "content",
+			Subreddit: "/r/subreddit",
+			Score:     new(int),
+			Promoted:  new(bool),
+			NSFW:      new(bool),
+		}
+
+		mockPostUC.EXPECT().Patch(context.Background(), id, gomock.Eq(patch)).Return(model, nil)
+
+		req, err := utils.MakeRequest(utils.PATCH, utils.JSON, "/patch/"+id.Hex(), patch)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		data := &models.Post{}
+		err = json.Unmarshal(resp.Body, &data)
+		require.NoError(t, err)
+		require.Equal(t, model, data)
+	})
+
+	t.Run("invalid subreddit", func(t *testing.T) {
+		subreddit := "subreddit"
+
+		req, err := utils.MakeRequest(utils.PATCH, utils.JSON, "/patch/"+id.Hex(), &models.PostPatch{Subreddit: &subreddit})
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestHandlers_Delete(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	postHandlers := New(logger, mockPostUC)
+
+	router := gin.Default()
+	router.DELETE("/delete/:id", postHandlers.Delete)
+
+	id := primitive.NewObjectID()
+
+	t.Run("ok", func(t *testing.T) {
+		mockPostUC.EXPECT().Delete(context.Background(), id).Return(nil)
+
+		req, err := utils.MakeRequest(utils.DELETE, utils.FORM, "/delete/"+id.Hex(), nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Empty(t, resp.Body)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockPostUC.EXPECT().Delete(context.Background(), id).Return(customErrors.New(http.StatusNotFound, customErrors.NotFound))
+
+		req, err := utils.MakeRequest(utils.DELETE, utils.FORM, "/delete/"+id.Hex(), nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestHandlers_Vote(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	postHandlers := New(logger, mockPostUC)
+
+	router := gin.Default()
+	router.Use(middleware.Authenticate(auth.NewVerifier(utils.TestKeys)))
+	router.POST("/vote/:id", postHandlers.Vote)
+
+	id := primitive.NewObjectID()
+
+	vote := func(body interface{}, user string) *utils.Response {
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/vote/"+id.Hex(), body)
+		require.NoError(t, err)
+
+		if user != "" {
+			req.Header.Set("Authorization", utils.Bearer(utils.TestKeys, user))
+		}
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		score := -1
+		model := &models.Post{Id: id.Hex(), Score: &score, Downs: 1}
+
+		mockPostUC.EXPECT().Vote(withPrincipal("t2_user"), id, "t2_user", -1).Return(model, nil)
+
+		resp := vote(&models.VoteRequest{Direction: models.VoteDown}, "t2_user")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		data := &models.Post{}
+		err := json.Unmarshal(resp.Body, &data)
+		require.NoError(t, err)
+		require.Equal(t, model, data)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		mockPostUC.EXPECT().Vote(withPrincipal("t2_user"), id, "t2_user", 0).Return(&models.Post{}, nil)
+
+		resp := vote(&models.VoteRequest{Direction: models.VoteClear}, "t2_user")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("no user", func(t *testing.T) {
+		resp := vote(&models.VoteRequest{Direction: models.VoteUp}, "")
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("invalid direction", func(t *testing.T) {
+		resp := vote(&models.VoteRequest{Direction: "sideways"}, "t2_user")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestHandlers_SubredditFeed(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	postHandlers := New(logger, mockPostUC)
+
+	router := gin.Default()
+	RegisterHandlers(router.Group(""), postHandlers, middleware.NewRateLimiter(nil, middleware.NewMemoryBackend()), nil)
+
+	t.Run("ok", func(t *testing.T) {
+		feed := &models.Feed{Page: 1, Size: 10, Posts: []*models.Post{}}
+
+		mockPostUC.EXPECT().GenerateFeeds(context.Background(), &models.FeedParams{Sort: "new", Subreddit: "/r/golang"}, &pagination.Query{Page: 1, Size: 10}).Return(feed, nil)
+
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/r/golang/feed?page=1&size=10&sort=new", nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		data := &models.Feed{}
+		err = json.Unmarshal(resp.Body, &data)
+		require.NoError(t, err)
+		require.Equal(t, feed, data)
+	})
+
+	t.Run("unknown subreddit", func(t *testing.T) {
+		expectedErr := customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		mockPostUC.EXPECT().GenerateFeeds(context.Background(), &models.FeedParams{Subreddit: "/r/missing"}, gomock.Any()).Return(nil, expectedErr)
+
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/r/missing/feed", nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestHandlers_Generate_ContentFilter(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	postHandlers := New(logger, mockPostUC)
+
+	router := gin.Default()
+	RegisterHandlers(router.Group(""), postHandlers, middleware.NewRateLimiter(nil, middleware.NewMemoryBackend()), nil)
+
+	t.Run("comma separated and repeated", func(t *testing.T) {
+		sfw := false
+		params := &models.FeedParams{ContentFilter: models.ContentFilter{
+			IncludeNSFW:       &sfw,
+			ExcludeSubreddits: []string{"/r/a", "/r/b", "/r/c"},
+		}}
+		mockPostUC.EXPECT().GenerateFeeds(context.Background(), params, gomock.Any()).Return(&models.Feed{}, nil)
+
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/post/generate?include_nsfw=false&exclude_subreddits=/r/a,/r/b&exclude_subreddits=/r/c", nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("subreddit without prefix", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/post/generate?only_subreddits=golang", nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		data := &customErrors.ErrorResponse{}
+		require.NoError(t, json.Unmarshal(resp.Body, data))
+		require.Equal(t, "only_subreddits", data.Errors[0].Field)
+	})
+}
+
+func TestHandlers_UserPosts(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	postHandlers := New(logger, mockPostUC)
+
+	router := gin.Default()
+	RegisterHandlers(router.Group(""), postHandlers, middleware.NewRateLimiter(nil, middleware.NewMemoryBackend()), nil)
+
+	feed := &models.Feed{TotalCount: 1, TotalPages: 1, Page: 1, Size: 1, Posts: []*models.Post{{Author: "t2_author"}}}
+	mockPostUC.EXPECT().ListByAuthor(context.Background(), "t2_author", &pagination.Query{Page: 1, Size: 25}).Return(feed, nil)
+
+	req, err := utils.MakeRequest(utils.GET, utils.FORM, "/u/t2_author/posts?page=1", nil)
+	require.NoError(t, err)
+
+	resp, err := utils.InvokeHandler(req, router)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data := &models.Feed{}
+	err = json.Unmarshal(resp.Body, &data)
+	require.NoError(t, err)
+	require.Equal(t, feed, data)
+}
+
+func TestHandlers_Search(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	postHandlers := New(logger, mockPostUC)
+
+	router := gin.Default()
+	RegisterHandlers(router.Group(""), postHandlers, middleware.NewRateLimiter(nil, middleware.NewMemoryBackend()), nil)
+
+	t.Run("ok", func(t *testing.T) {
+		nsfw := false
+		feed := &models.Feed{TotalCount: 1, TotalPages: 1, Page: 1, Size: 1, Posts: []*models.Post{{Title: "golang generics"}}}
+
+		params := &models.SearchParams{Query: "golang generics", Subreddit: "/r/golang", NSFW: &nsfw}
+		mockPostUC.EXPECT().Search(context.Background(), params, &pagination.Query{Page: 1, Size: 25}).Return(feed, nil)
+
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/post/search?q=golang+generics&subreddit=golang&nsfw=false&page=1", nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		data := &models.Feed{}
+		err = json.Unmarshal(resp.Body, &data)
+		require.NoError(t, err)
+		require.Equal(t, feed, data)
+	})
+
+	t.Run("no query", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/post/search?page=1", nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+// streamRecorder - gin streams need a http.CloseNotifier, the response recorder is not one
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (r streamRecorder) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+func TestHandlers_Stream(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	postHandlers := New(logger, mockPostUC)
+
+	router := gin.Default()
+	RegisterHandlers(router.Group(""), postHandlers, middleware.NewRateLimiter(nil, middleware.NewMemoryBackend()), nil)
+
+	// subscribes to a broker holding the given posts, then closes it so the stream ends after the replay
+	subscribe := func(posts ...*models.Post) func(context.Context, *models.StreamParams, string) (*stream.Subscription, error) {
+		return func(_ context.Context, params *models.StreamParams, lastEventID string) (*stream.Subscription, error) {
+			broker := stream.NewBroker(config.StreamConfig{Buffer: 8, SlowClient: stream.SlowClientDisconnect, History: 8})
+			for _, post := range posts {
+				broker.Publish(post)
+			}
+
+			sub, err := broker.Subscribe(stream.Filter{Subreddit: params.Subreddit, NSFW: params.NSFW}, lastEventID)
+			broker.Close()
+			return sub, err
+		}
+	}
+
+	get := func(uri, lastEventID string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(utils.GET, uri, nil)
+		require.NoError(t, err)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(streamRecorder{rec}, req)
+
+		return rec
+	}
+
+	t.Run("resumes after the last event", func(t *testing.T) {
+		nsfw := false
+		params := &models.StreamParams{Subreddit: "/r/golang", NSFW: &nsfw}
+
+		mockPostUC.EXPECT().Subscribe(gomock.Any(), params, "post1").DoAndReturn(subscribe(
+			&models.Post{Id: "post1", Title: "first", Subreddit: "/r/golang"},
+			&models.Post{Id: "post2", Title: "second", Subreddit: "/r/golang"},
+			&models.Post{Id: "post3", Title: "other", Subreddit: "/r/rust"},
+		))
+
+		rec := get("/post/stream?subreddit=golang&nsfw=false", "post1")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+
+		body := rec.Body.String()
+		require.True(t, strings.HasPrefix(body, "retry:1000\n"))
+		require.Contains(t, body, "id:post2\nevent:post\ndata:{\"id\":\"post2\",\"title\":\"second\"")
+		require.NotContains(t, body, "id:post1")
+		require.NotContains(t, body, "id:post3")
+		require.NotContains(t, body, "event:reset")
+	})
+
+	t.Run("unknown last event", func(t *testing.T) {
+		mockPostUC.EXPECT().Subscribe(gomock.Any(), &models.StreamParams{}, "gone").DoAndReturn(subscribe())
+
+		rec := get("/post/stream", "gone")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, rec.Body.String(), "event:reset\n")
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		mockPostUC.EXPECT().Subscribe(gomock.Any(), gomock.Any(), "").Return(nil, customErrors.New(http.StatusServiceUnavailable, stream.ErrClosed))
+
+		rec := get("/post/stream", "")
+
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+}
+
+func TestHandlers_Moderate(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	postHandlers := New(logger, mockPostUC)
+
+	router := gin.Default()
+	router.Use(middleware.Authenticate(auth.NewVerifier(utils.TestKeys)))
+	RegisterHandlers(router.Group(""), postHandlers, middleware.NewRateLimiter(nil, middleware.NewMemoryBackend()), []string{"t2_mod"})
+
+	id := primitive.NewObjectID()
+
+	moderate := func(body interface{}, user string) *utils.Response {
+		req, err := utils.MakeRequest(utils.PUT, utils.JSON, "/post/"+id.Hex()+"/status", body)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", utils.Bearer(utils.TestKeys, user))
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	t.Run("moderator", func(t *testing.T) {
+		moderation := &models.Moderation{Status: models.StatusRemovedByMod, Reason: "spam"}
+		mockPostUC.EXPECT().Moderate(withPrincipal("t2_mod"), id, moderation).Return(&models.Post{Status: models.StatusRemovedByMod}, nil)
+
+		resp := moderate(moderation, "t2_mod")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("not a moderator", func(t *testing.T) {
+		resp := moderate(&models.Moderation{Status: models.StatusRemovedByMod}, "t2_author")
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("deleted_by_author is left to the author", func(t *testing.T) {
+		resp := moderate(&models.Moderation{Status: models.StatusDeletedByAuthor}, "t2_mod")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
 }
@@ -1,17 +1,56 @@
 package http
 
 import (
+	"github.com/aliykh/reddit-feed/internal/http/middleware"
 	"github.com/aliykh/reddit-feed/internal/posts"
 	"github.com/gin-gonic/gin"
+	"time"
 )
 
-const path = "/post"
+const (
+	path = "/post"
 
-func RegisterHandlers(router *gin.RouterGroup, handlers posts.Handlers)  {
+	subredditPrefix = "/r/"
+
+	// idempotencyKeyHeader - makes the retries of a created post return the post created first
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+
+	// lastEventIDHeader - id of the last event an event stream client received, sent when it reconnects
+	lastEventIDHeader = "Last-Event-ID"
+
+	// streamHeartbeat - interval of the keep-alive comments of an event stream
+	streamHeartbeat = time.Second * 15
+	// streamLifetime - an event stream is closed after it, below the write timeout of the server
+	streamLifetime = time.Second * 25
+	// streamRetry - reconnection delay of the event stream clients
+	streamRetry = time.Second
+
+	// ndjsonContentType - newline delimited posts of a bulk import, other bodies are read as a json array
+	ndjsonContentType = "application/x-ndjson"
+
+	// maxImportBytes - size limit of the body of a bulk import
+	maxImportBytes = 8 << 20
+)
+
+func RegisterHandlers(router *gin.RouterGroup, handlers posts.Handlers, limiter *middleware.RateLimiter, moderators []string) {
 
 	r1Group := router.Group(path)
-	r1Group.POST("/", handlers.Create)
+	r1Group.POST("/", middleware.RequireAuth(), limiter.Limit(middleware.LimitCreatePost), handlers.Create)
+	r1Group.POST("/bulk", middleware.RequireAuth(), limiter.Limit(middleware.LimitImport), handlers.Import)
+
+	r1Group.GET("/generate", limiter.Limit(middleware.LimitFeed), handlers.Generate)
+	r1Group.GET("/search", limiter.Limit(middleware.LimitFeed), handlers.Search)
+	r1Group.GET("/stream", handlers.Stream)
+	r1Group.GET("/:id", handlers.GetByID)
+	r1Group.PUT("/:id", middleware.RequireAuth(), handlers.Update)
+	r1Group.PATCH("/:id", middleware.RequireAuth(), handlers.Patch)
+	r1Group.DELETE("/:id", middleware.RequireAuth(), handlers.Delete)
+	r1Group.POST("/:id/vote", middleware.RequireAuth(), handlers.Vote)
+	r1Group.PUT("/:id/status", middleware.RequireModerator(moderators), handlers.Moderate)
+	r1Group.GET("/:id/audit", middleware.RequireModerator(moderators), handlers.AuditLog)
 
-	r1Group.GET("/generate", handlers.Generate)
+	router.GET(subredditPrefix+":name/feed", limiter.Limit(middleware.LimitFeed), handlers.SubredditFeed)
+	router.GET("/u/:id/posts", handlers.UserPosts)
 
 }
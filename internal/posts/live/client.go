@@ -0,0 +1,143 @@
+package live
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"time"
+)
+
+const (
+	// ActionSubscribe - follows the posts in addition to the current ones
+	ActionSubscribe = "subscribe"
+	// ActionUnsubscribe - stops following the posts
+	ActionUnsubscribe = "unsubscribe"
+	// ActionSet - follows exactly the posts, e.g. the ones on the page after scrolling
+	ActionSet = "set"
+
+	// writeWait - time allowed to write a message to the client
+	writeWait = time.Second * 10
+	// maxMessageSize - size limit of a message of the client
+	maxMessageSize = 16 << 10
+)
+
+// Request - message of a client changing the posts it follows.
+type Request struct {
+	Action string   `json:"action"`
+	Posts  []string `json:"posts"`
+}
+
+// ErrorMessage - sent to a client for a request it got wrong, the connection stays open.
+type ErrorMessage struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+// Client - a websocket connection and the posts it follows, the subscriptions are guarded by the hub.
+type Client struct {
+	hub  *Hub
+	conn *websocket.Conn
+	// send - updates and replies written by the write pump, closed when the hub removes the client
+	send  chan interface{}
+	posts map[string]struct{}
+	// closeCode - sent in the close frame once send is closed
+	closeCode int
+}
+
+// Serve - follows the updates requested on the connection until either side closes it. The connection is
+// closed on return.
+func (h *Hub) Serve(conn *websocket.Conn) error {
+	client := &Client{
+		hub:   h,
+		conn:  conn,
+		send:  make(chan interface{}, h.cfg.SendBuffer),
+		posts: make(map[string]struct{}),
+	}
+
+	if err := h.register(client); err != nil {
+		// the limit was reached after the upgrade
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseTryAgainLater, err.Error()), time.Now().Add(writeWait))
+		_ = conn.Close()
+		return err
+	}
+
+	go client.writePump()
+	client.readPump()
+
+	return nil
+}
+
+// readPump - handles the requests of the client, a missed pong closes the connection.
+func (c *Client) readPump() {
+	defer func() {
+		c.hub.unregister(c)
+		_ = c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(c.hub.cfg.PongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(c.hub.cfg.PongWait))
+	})
+
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		req := &Request{}
+		if err = json.Unmarshal(message, req); err != nil {
+			c.hub.reply(c, ErrorMessage{Type: "error", Error: "invalid request"})
+			continue
+		}
+
+		c.handle(req)
+	}
+}
+
+func (c *Client) handle(req *Request) {
+	switch req.Action {
+	case ActionSubscribe, ActionSet:
+		if !c.hub.follow(c, req.Posts, req.Action == ActionSet) {
+			c.hub.reply(c, ErrorMessage{
+				Type:  "error",
+				Error: fmt.Sprintf("at most %d posts can be followed", c.hub.cfg.MaxSubscriptions),
+			})
+		}
+	case ActionUnsubscribe:
+		c.hub.unfollow(c, req.Posts)
+	default:
+		c.hub.reply(c, ErrorMessage{Type: "error", Error: fmt.Sprintf("unknown action %q", req.Action)})
+	}
+}
+
+// writePump - the only writer of the connection, it pings the client between the messages.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(c.hub.cfg.PingInterval)
+	defer func() {
+		ticker.Stop()
+		_ = c.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				// removed by the hub, closeCode is set before send is closed
+				_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(c.closeCode, ""))
+				return
+			}
+			if err := c.conn.WriteJSON(message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
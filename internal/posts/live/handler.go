@@ -0,0 +1,67 @@
+package live
+
+import (
+	"errors"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/http/server/helpers"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type Handler struct {
+	logger   *log.Factory
+	hub      *Hub
+	upgrader websocket.Upgrader
+}
+
+func NewHandler(logger *log.Factory, hub *Hub) *Handler {
+	return &Handler{
+		logger: logger,
+		hub:    hub,
+		upgrader: websocket.Upgrader{
+			// the counters are public and requests carry no cookies, any page may follow them
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+func RegisterHandlers(router *gin.RouterGroup, handler *Handler) {
+
+	router.GET("/post/live", handler.Connect)
+
+}
+
+// Connect godoc
+// @Summary Connect - websocket of the score and comment count changes of the posts
+// @Description the client sends {"action": "subscribe" | "unsubscribe" | "set", "posts": ["<post id>", ...]}, "set"
+// @Description replaces the followed posts. Changes of the followed posts are sent as deltas,
+// @Description {"type": "score", "post": "<id>", "score": 1, "ups": 1, "downs": 0} or
+// @Description {"type": "comments", "post": "<id>", "comments": 1}. A rejected request is answered with
+// @Description {"type": "error", "error": "..."}. A client too slow to read its updates is closed with 1013 and
+// @Description should reload the counters after reconnecting.
+// @Tags Posts
+// @Success 101
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 503 {object} customErrors.ErrorResponse
+// @Router /post/live [GET]
+func (h *Handler) Connect(c *gin.Context) {
+
+	if err := h.hub.Available(); err != nil {
+		helpers.RespondError(c, customErrors.New(http.StatusServiceUnavailable, err))
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader has responded already
+		h.logger.For(c.Request.Context()).Error("live upgrade err", zap.String("err", err.Error()))
+		return
+	}
+
+	if err = h.hub.Serve(conn); err != nil && !errors.Is(err, ErrClosed) {
+		h.logger.For(c.Request.Context()).Error("live serve err", zap.String("err", err.Error()))
+	}
+}
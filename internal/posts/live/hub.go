@@ -0,0 +1,211 @@
+package live
+
+import (
+	"errors"
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/aliykh/reddit-feed/internal/metrics"
+	"github.com/gorilla/websocket"
+	"sync"
+)
+
+var (
+	ErrTooManyConnections = errors.New("too many live connections")
+	ErrClosed             = errors.New("live updates are closed")
+)
+
+// Update - change of the counters of a post, the fields are deltas.
+type Update struct {
+	Type     string `json:"type"`
+	Post     string `json:"post"`
+	Score    int    `json:"score,omitempty"`
+	Ups      int    `json:"ups,omitempty"`
+	Downs    int    `json:"downs,omitempty"`
+	Comments int    `json:"comments,omitempty"`
+}
+
+// Hub - routes the updates of the posts to the connections following them.
+type Hub struct {
+	cfg config.LiveConfig
+
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+	// followers - connections by the posts they follow
+	followers map[string]map[*Client]struct{}
+	closed    bool
+}
+
+func NewHub(cfg config.LiveConfig) *Hub {
+	return &Hub{
+		cfg:       cfg,
+		clients:   make(map[*Client]struct{}),
+		followers: make(map[string]map[*Client]struct{}),
+	}
+}
+
+// ScoreChanged - sends the vote of a post to its followers.
+func (h *Hub) ScoreChanged(post string, score, ups, downs int) {
+	h.publish(Update{Type: "score", Post: post, Score: score, Ups: ups, Downs: downs})
+}
+
+// CommentsChanged - sends the change of the comment count of a post to its followers.
+func (h *Hub) CommentsChanged(post string, delta int) {
+	h.publish(Update{Type: "comments", Post: post, Comments: delta})
+}
+
+// Close - closes every connection, later ones are refused.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for client := range h.clients {
+		h.remove(client, websocket.CloseGoingAway)
+	}
+}
+
+func (h *Hub) publish(update Update) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.followers[update.Post] {
+		select {
+		case client.send <- update:
+		default:
+			// the send buffer is full, the client reloads its page after reconnecting
+			metrics.LiveSlowConnections.Inc()
+			h.remove(client, websocket.CloseTryAgainLater)
+		}
+	}
+}
+
+// Available - whether a new connection would be accepted, checked before the upgrade.
+func (h *Hub) Available() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.available()
+}
+
+func (h *Hub) available() error {
+	if h.closed {
+		return ErrClosed
+	}
+	if len(h.clients) >= h.cfg.MaxConnections {
+		return ErrTooManyConnections
+	}
+	return nil
+}
+
+// register - adds a connection unless the limit is reached.
+func (h *Hub) register(client *Client) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.available(); err != nil {
+		return err
+	}
+
+	h.clients[client] = struct{}{}
+	metrics.LiveConnections.Inc()
+
+	return nil
+}
+
+// unregister - removes the connection and its subscriptions.
+func (h *Hub) unregister(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(client, websocket.CloseNormalClosure)
+}
+
+// reply - queues a message for the client unless it is removed, the message is dropped when the buffer is full.
+func (h *Hub) reply(client *Client, message interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+
+	select {
+	case client.send <- message:
+	default:
+	}
+}
+
+// follow - adds the posts to the subscriptions of the client up to the limit, replace drops the current ones first.
+// Returns false when some posts are left out.
+func (h *Hub) follow(client *Client, posts []string, replace bool) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[client]; !ok {
+		return true
+	}
+
+	if replace {
+		for post := range client.posts {
+			h.unfollowPost(client, post)
+		}
+	}
+
+	for _, post := range posts {
+		if _, ok := client.posts[post]; ok {
+			continue
+		}
+		if len(client.posts) >= h.cfg.MaxSubscriptions {
+			return false
+		}
+
+		client.posts[post] = struct{}{}
+
+		followers, ok := h.followers[post]
+		if !ok {
+			followers = make(map[*Client]struct{})
+			h.followers[post] = followers
+		}
+		followers[client] = struct{}{}
+	}
+
+	return true
+}
+
+// unfollow - removes the posts from the subscriptions of the client.
+func (h *Hub) unfollow(client *Client, posts []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, post := range posts {
+		h.unfollowPost(client, post)
+	}
+}
+
+// unfollowPost - called with the lock held.
+func (h *Hub) unfollowPost(client *Client, post string) {
+	delete(client.posts, post)
+
+	followers := h.followers[post]
+	delete(followers, client)
+	if len(followers) == 0 {
+		delete(h.followers, post)
+	}
+}
+
+// remove - drops the client and closes its send buffer, the client closes the connection with the code.
+// Called with the lock held.
+func (h *Hub) remove(client *Client, code int) {
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+
+	client.closeCode = code
+
+	for post := range client.posts {
+		h.unfollowPost(client, post)
+	}
+
+	delete(h.clients, client)
+	close(client.send)
+	metrics.LiveConnections.Dec()
+}
@@ -0,0 +1,163 @@
+package live
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func newServer(t *testing.T, cfg config.LiveConfig) (*Hub, *httptest.Server) {
+	gin.SetMode(gin.TestMode)
+
+	hub := NewHub(cfg)
+	router := gin.New()
+	RegisterHandlers(router.Group("/"), NewHandler(logr.NewFactory(logr.Mock, "test"), hub))
+
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		hub.Close()
+		server.Close()
+	})
+
+	return hub, server
+}
+
+func dial(t *testing.T, server *httptest.Server) *websocket.Conn {
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/post/live", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+// send - sends the request and waits for the hub to apply it, the hub has no acknowledgements
+func send(t *testing.T, hub *Hub, conn *websocket.Conn, req Request, following int) {
+	require.NoError(t, conn.WriteJSON(req))
+	require.Eventually(t, func() bool {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		for client := range hub.clients {
+			if len(client.posts) == following {
+				return true
+			}
+		}
+		return false
+	}, time.Second, time.Millisecond*10)
+}
+
+func read(t *testing.T, conn *websocket.Conn, v interface{}) {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	require.NoError(t, conn.ReadJSON(v))
+}
+
+func TestHub_Updates(t *testing.T) {
+
+	hub, server := newServer(t, config.LiveConfig{
+		MaxConnections: 10, MaxSubscriptions: 2, SendBuffer: 8, PingInterval: time.Second, PongWait: time.Second * 2,
+	})
+	conn := dial(t, server)
+
+	send(t, hub, conn, Request{Action: ActionSubscribe, Posts: []string{"post1", "post2"}}, 2)
+
+	hub.ScoreChanged("post3", 1, 1, 0)
+	hub.ScoreChanged("post1", 2, 1, -1)
+	hub.CommentsChanged("post2", 1)
+
+	var score, comments Update
+	read(t, conn, &score)
+	require.Equal(t, Update{Type: "score", Post: "post1", Score: 2, Ups: 1, Downs: -1}, score)
+	read(t, conn, &comments)
+	require.Equal(t, Update{Type: "comments", Post: "post2", Comments: 1}, comments)
+
+	t.Run("subscription limit", func(t *testing.T) {
+		require.NoError(t, conn.WriteJSON(Request{Action: ActionSubscribe, Posts: []string{"post3"}}))
+
+		var msg ErrorMessage
+		read(t, conn, &msg)
+		require.Equal(t, "error", msg.Type)
+		require.Contains(t, msg.Error, "at most 2 posts")
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		require.NoError(t, conn.WriteJSON(Request{Action: "follow"}))
+
+		var msg ErrorMessage
+		read(t, conn, &msg)
+		require.Equal(t, `unknown action "follow"`, msg.Error)
+	})
+
+	t.Run("set replaces the followed posts", func(t *testing.T) {
+		send(t, hub, conn, Request{Action: ActionSet, Posts: []string{"post3"}}, 1)
+
+		hub.CommentsChanged("post1", 1)
+		hub.CommentsChanged("post3", -1)
+
+		var update Update
+		read(t, conn, &update)
+		require.Equal(t, Update{Type: "comments", Post: "post3", Comments: -1}, update)
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		send(t, hub, conn, Request{Action: ActionUnsubscribe, Posts: []string{"post3"}}, 0)
+
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		require.Empty(t, hub.followers)
+	})
+}
+
+func TestHub_Limits(t *testing.T) {
+
+	t.Run("too many connections", func(t *testing.T) {
+		_, server := newServer(t, config.LiveConfig{
+			MaxConnections: 1, MaxSubscriptions: 1, SendBuffer: 1, PingInterval: time.Second, PongWait: time.Second * 2,
+		})
+		dial(t, server)
+
+		require.Eventually(t, func() bool {
+			resp, err := http.Get(server.URL + "/post/live")
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			return resp.StatusCode == http.StatusServiceUnavailable
+		}, time.Second, time.Millisecond*10)
+	})
+
+	t.Run("slow connection", func(t *testing.T) {
+		hub := NewHub(config.LiveConfig{MaxConnections: 1, MaxSubscriptions: 1, SendBuffer: 1})
+
+		// without a write pump nothing drains the buffer
+		client := &Client{hub: hub, send: make(chan interface{}, 1), posts: make(map[string]struct{})}
+		require.NoError(t, hub.register(client))
+		require.True(t, hub.follow(client, []string{"post1"}, false))
+
+		hub.CommentsChanged("post1", 1)
+		hub.CommentsChanged("post1", 1)
+
+		require.Equal(t, Update{Type: "comments", Post: "post1", Comments: 1}, <-client.send)
+		_, ok := <-client.send
+		require.False(t, ok)
+		require.Equal(t, websocket.CloseTryAgainLater, client.closeCode)
+		require.NoError(t, hub.Available())
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		hub, server := newServer(t, config.LiveConfig{
+			MaxConnections: 1, MaxSubscriptions: 1, SendBuffer: 1, PingInterval: time.Second, PongWait: time.Second * 2,
+		})
+		conn := dial(t, server)
+		hub.Close()
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		_, _, err := conn.ReadMessage()
+		require.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+	})
+}
@@ -9,8 +9,10 @@ import (
 	reflect "reflect"
 
 	models "github.com/aliykh/reddit-feed/internal/posts/models"
+	stream "github.com/aliykh/reddit-feed/internal/posts/stream"
 	pagination "github.com/aliykh/reddit-feed/pkg/pagination"
 	gomock "github.com/golang/mock/gomock"
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // MockUseCase is a mock of UseCase interface.
@@ -36,6 +38,21 @@ func (m *MockUseCase) EXPECT() *MockUseCaseMockRecorder {
 	return m.recorder
 }
 
+// AuditLog mocks base method.
+func (m *MockUseCase) AuditLog(arg0 context.Context, arg1 primitive.ObjectID) ([]*models.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuditLog", arg0, arg1)
+	ret0, _ := ret[0].([]*models.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuditLog indicates an expected call of AuditLog.
+func (mr *MockUseCaseMockRecorder) AuditLog(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuditLog", reflect.TypeOf((*MockUseCase)(nil).AuditLog), arg0, arg1)
+}
+
 // Create mocks base method.
 func (m *MockUseCase) Create(arg0 context.Context, arg1 *models.Post) (*models.Post, error) {
 	m.ctrl.T.Helper()
@@ -51,17 +68,181 @@ func (mr *MockUseCaseMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUseCase)(nil).Create), arg0, arg1)
 }
 
+// CreateIdempotent mocks base method.
+func (m *MockUseCase) CreateIdempotent(ctx context.Context, key, bodyHash string, model *models.Post) (*models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdempotent", ctx, key, bodyHash, model)
+	ret0, _ := ret[0].(*models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIdempotent indicates an expected call of CreateIdempotent.
+func (mr *MockUseCaseMockRecorder) CreateIdempotent(ctx, key, bodyHash, model interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdempotent", reflect.TypeOf((*MockUseCase)(nil).CreateIdempotent), ctx, key, bodyHash, model)
+}
+
+// Delete mocks base method.
+func (m *MockUseCase) Delete(arg0 context.Context, arg1 primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUseCaseMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUseCase)(nil).Delete), arg0, arg1)
+}
+
 // GenerateFeeds mocks base method.
-func (m *MockUseCase) GenerateFeeds(arg0 context.Context, arg1 *pagination.Query) (*models.Feed, error) {
+func (m *MockUseCase) GenerateFeeds(arg0 context.Context, arg1 *models.FeedParams, arg2 *pagination.Query) (*models.Feed, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateFeeds", arg0, arg1)
+	ret := m.ctrl.Call(m, "GenerateFeeds", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*models.Feed)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateFeeds indicates an expected call of GenerateFeeds.
-func (mr *MockUseCaseMockRecorder) GenerateFeeds(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) GenerateFeeds(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateFeeds", reflect.TypeOf((*MockUseCase)(nil).GenerateFeeds), arg0, arg1, arg2)
+}
+
+// GetByID mocks base method.
+func (m *MockUseCase) GetByID(arg0 context.Context, arg1 primitive.ObjectID) (*models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockUseCaseMockRecorder) GetByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUseCase)(nil).GetByID), arg0, arg1)
+}
+
+// Import mocks base method.
+func (m *MockUseCase) Import(arg0 context.Context, arg1 []*models.Post) ([]*models.ImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", arg0, arg1)
+	ret0, _ := ret[0].([]*models.ImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockUseCaseMockRecorder) Import(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockUseCase)(nil).Import), arg0, arg1)
+}
+
+// ListByAuthor mocks base method.
+func (m *MockUseCase) ListByAuthor(ctx context.Context, author string, query *pagination.Query) (*models.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByAuthor", ctx, author, query)
+	ret0, _ := ret[0].(*models.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByAuthor indicates an expected call of ListByAuthor.
+func (mr *MockUseCaseMockRecorder) ListByAuthor(ctx, author, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByAuthor", reflect.TypeOf((*MockUseCase)(nil).ListByAuthor), ctx, author, query)
+}
+
+// Moderate mocks base method.
+func (m *MockUseCase) Moderate(arg0 context.Context, arg1 primitive.ObjectID, arg2 *models.Moderation) (*models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Moderate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Moderate indicates an expected call of Moderate.
+func (mr *MockUseCaseMockRecorder) Moderate(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Moderate", reflect.TypeOf((*MockUseCase)(nil).Moderate), arg0, arg1, arg2)
+}
+
+// Patch mocks base method.
+func (m *MockUseCase) Patch(arg0 context.Context, arg1 primitive.ObjectID, arg2 *models.PostPatch) (*models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockUseCaseMockRecorder) Patch(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockUseCase)(nil).Patch), arg0, arg1, arg2)
+}
+
+// Search mocks base method.
+func (m *MockUseCase) Search(arg0 context.Context, arg1 *models.SearchParams, arg2 *pagination.Query) (*models.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockUseCaseMockRecorder) Search(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockUseCase)(nil).Search), arg0, arg1, arg2)
+}
+
+// Subscribe mocks base method.
+func (m *MockUseCase) Subscribe(ctx context.Context, params *models.StreamParams, lastEventID string) (*stream.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, params, lastEventID)
+	ret0, _ := ret[0].(*stream.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockUseCaseMockRecorder) Subscribe(ctx, params, lastEventID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockUseCase)(nil).Subscribe), ctx, params, lastEventID)
+}
+
+// Update mocks base method.
+func (m *MockUseCase) Update(arg0 context.Context, arg1 primitive.ObjectID, arg2 *models.Post) (*models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockUseCaseMockRecorder) Update(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUseCase)(nil).Update), arg0, arg1, arg2)
+}
+
+// Vote mocks base method.
+func (m *MockUseCase) Vote(ctx context.Context, id primitive.ObjectID, user string, direction int) (*models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Vote", ctx, id, user, direction)
+	ret0, _ := ret[0].(*models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Vote indicates an expected call of Vote.
+func (mr *MockUseCaseMockRecorder) Vote(ctx, id, user, direction interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateFeeds", reflect.TypeOf((*MockUseCase)(nil).GenerateFeeds), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vote", reflect.TypeOf((*MockUseCase)(nil).Vote), ctx, id, user, direction)
 }
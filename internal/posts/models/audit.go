@@ -0,0 +1,23 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Moderation - body of the status endpoint of the moderators, deleted_by_author is left to the author.
+type Moderation struct {
+	Status string `json:"status" binding:"required,oneof=active removed_by_mod locked"`
+	Reason string `json:"reason" binding:"max=512"`
+}
+
+// AuditEntry - a status change of a post, kept after the post is hidden.
+type AuditEntry struct {
+	Id        string             `json:"id" bson:"_id,omitempty"`
+	Post      primitive.ObjectID `json:"post" bson:"post"`
+	Actor     string             `json:"actor" bson:"actor"`
+	From      string             `json:"from" bson:"from"`
+	To        string             `json:"to" bson:"to"`
+	Reason    string             `json:"reason,omitempty" bson:"reason,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrIdempotencyKeyInvalid    = errors.New("Idempotency-Key must be at most 255 characters")
+	ErrIdempotencyKeyReused     = errors.New("Idempotency-Key was already used with a different request body")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this Idempotency-Key is in progress")
+)
+
+// IdempotencyKey - a create request by its Idempotency-Key header, keys of different users do not collide.
+type IdempotencyKey struct {
+	// Id - the user and the key of the header
+	Id       string `bson:"_id"`
+	BodyHash string `bson:"body_hash"`
+	// PostId - the created post, empty while the request is in progress
+	PostId    string    `bson:"post_id"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"errors"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+)
+
+// MaxImportPosts - posts accepted by a single bulk import
+const MaxImportPosts = 1000
+
+var (
+	ErrImportEmpty    = errors.New("no posts to import")
+	ErrImportTooLarge = errors.New("too many posts to import, at most 1000 per request")
+)
+
+// ImportResult - outcome of a single post of a bulk import, either the id of the created post or its errors.
+type ImportResult struct {
+	// Index - position of the post in the request body, blank lines of a ndjson body are not counted
+	Index  int                            `json:"index"`
+	Id     string                         `json:"id,omitempty"`
+	Errors []customErrors.ErrorValidation `json:"errors,omitempty"`
+}
+
+// ImportReport - response of a bulk import, the results are ordered by index.
+type ImportReport struct {
+	Created int             `json:"created"`
+	Failed  int             `json:"failed"`
+	Results []*ImportResult `json:"results"`
+}
@@ -2,22 +2,117 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"github.com/aliykh/reddit-feed/pkg/customErrors"
-	"github.com/dchest/uniuri"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type Feed struct {
-	TotalCount int64     `json:"total_count"`
+	TotalCount int64   `json:"total_count"`
 	TotalPages int     `json:"total_pages"`
 	Page       int     `json:"page"`
 	Size       int     `json:"size"`
 	HasMore    bool    `json:"has_more"`
+	NextCursor string  `json:"next_cursor,omitempty"`
 	Posts      []*Post `json:"posts"`
+	// SnapshotAt - when the hot feed snapshot the page was read from was computed, unset for pages read live
+	SnapshotAt *time.Time `json:"snapshot_at,omitempty"`
 }
 
+// FeedParams - ranking of the feed, see the ranking package for the available strategies.
+type FeedParams struct {
+	Sort   string `json:"sort,omitempty" form:"sort" binding:"omitempty,oneof=hot new top rising controversial"`
+	Window string `json:"t,omitempty" form:"t" binding:"omitempty,oneof=hour day week month year all"`
+	// Subreddit - scopes the feed to a single subreddit, taken from the uri rather than the query
+	Subreddit string `json:"-" form:"-"`
+
+	ContentFilter
+}
+
+// ContentFilter - posts the reader does not want to see. It applies to the organic and the promoted posts alike,
+// so the counts of the feed match its posts.
+type ContentFilter struct {
+	// IncludeNSFW - false hides the NSFW posts, they are shown when unset
+	IncludeNSFW *bool `json:"include_nsfw,omitempty" form:"include_nsfw"`
+	// ExcludeSubreddits - subreddits hidden from the feed, repeated or comma separated
+	ExcludeSubreddits []string `json:"exclude_subreddits,omitempty" form:"exclude_subreddits"`
+	// OnlySubreddits - limits the feed to these subreddits, repeated or comma separated
+	OnlySubreddits []string `json:"only_subreddits,omitempty" form:"only_subreddits"`
+}
+
+// Normalize - splits the comma separated subreddits of the query, every one of them has to start with /r/.
+func (f *ContentFilter) Normalize() error {
+	var errs []customErrors.ErrorValidation
+
+	lists := []struct {
+		field  string
+		values *[]string
+	}{
+		{"exclude_subreddits", &f.ExcludeSubreddits},
+		{"only_subreddits", &f.OnlySubreddits},
+	}
+
+	for _, list := range lists {
+		var result []string
+		for _, value := range *list.values {
+			for _, name := range strings.Split(value, ",") {
+				if name = strings.TrimSpace(name); name == "" {
+					continue
+				}
+				if !strings.HasPrefix(name, "/r/") {
+					errs = append(errs, customErrors.ErrorValidation{Field: list.field, Message: fmt.Sprintf("%s must start with /r/", name)})
+					continue
+				}
+				result = append(result, name)
+			}
+		}
+		*list.values = result
+	}
+
+	if len(errs) > 0 {
+		return &customErrors.ErrorResponse{ErrStatus: http.StatusBadRequest, Errors: errs}
+	}
+
+	return nil
+}
+
+// SearchParams - full-text search over the titles and contents of the posts.
+type SearchParams struct {
+	Query string `json:"q" form:"q" binding:"required,max=256"`
+	// Subreddit - scopes the search to a single subreddit, the name is given without the /r/ prefix
+	Subreddit string `json:"subreddit,omitempty" form:"subreddit"`
+	// NSFW - only NSFW posts when true, none when false, both when unset
+	NSFW *bool `json:"nsfw,omitempty" form:"nsfw"`
+}
+
+// StreamParams - posts pushed to a client of the event stream.
+type StreamParams struct {
+	// Subreddit - only the posts of a single subreddit, the name is given without the /r/ prefix
+	Subreddit string `json:"subreddit,omitempty" form:"subreddit"`
+	// NSFW - only NSFW posts when true, none when false, both when unset
+	NSFW *bool `json:"nsfw,omitempty" form:"nsfw"`
+}
+
+const (
+	StatusActive          = "active"
+	StatusDeletedByAuthor = "deleted_by_author"
+	StatusRemovedByMod    = "removed_by_mod"
+	StatusLocked          = "locked"
+)
+
+// HiddenStatuses - posts in these statuses are kept for the audit but are not shown anywhere
+var HiddenStatuses = []string{StatusDeletedByAuthor, StatusRemovedByMod}
+
+var (
+	ErrUnknownSubreddit = errors.New("unknown subreddit")
+	ErrLocked           = errors.New("post is locked")
+	ErrDeletedByAuthor  = errors.New("post was deleted by its author")
+)
+
 type Post struct {
 	Id        string `json:"id" bson:"_id,omitempty"`
 	Title     string `json:"title" bson:"title" binding:"required"`
@@ -25,9 +120,57 @@ type Post struct {
 	Link      string `json:"link,omitempty" bson:"link,omitempty"`
 	Subreddit string `json:"subreddit" bson:"subreddit" binding:"required,startswith=/r/"`
 	Content   string `json:"content,omitempty" bson:"content,omitempty"`
-	Score     *int   `json:"score" bson:"score" binding:"required"`
-	Promoted  *bool  `json:"promoted" bson:"promoted" binding:"required"`
-	NSFW      *bool  `json:"nsfw" bson:"nsfw" binding:"required"`
+	// Score - up votes minus down votes, only the votes change it
+	Score    *int  `json:"score" bson:"score" binding:"-"`
+	Promoted *bool `json:"promoted" bson:"promoted" binding:"required"`
+	NSFW     *bool `json:"nsfw" bson:"nsfw" binding:"required"`
+	Ups      int   `json:"ups" bson:"ups"`
+	Downs    int   `json:"downs" bson:"downs"`
+	// CommentCount - denormalized number of comments, maintained by the comments module
+	CommentCount int       `json:"comment_count" bson:"comment_count"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at,omitempty"`
+	// Status - lifecycle of the post, set by the use case rather than the body of a request
+	Status string `json:"status" bson:"status,omitempty" binding:"-"`
+
+	// Rank - computed by time-decayed feed rankings, never stored
+	Rank float64 `json:"-" bson:"rank,omitempty"`
+}
+
+// PostPatch - partial update of a post, only non-nil fields are applied.
+type PostPatch struct {
+	Title     *string `json:"title" binding:"omitempty,min=1"`
+	Link      *string `json:"link"`
+	Subreddit *string `json:"subreddit" binding:"omitempty,startswith=/r/"`
+	Content   *string `json:"content"`
+	Promoted  *bool   `json:"promoted"`
+	NSFW      *bool   `json:"nsfw"`
+}
+
+// Apply - merges the patch into the given post.
+func (pp *PostPatch) Apply(p *Post) {
+	if pp.Title != nil {
+		p.Title = *pp.Title
+	}
+	if pp.Link != nil {
+		p.Link = *pp.Link
+	}
+	if pp.Subreddit != nil {
+		p.Subreddit = *pp.Subreddit
+	}
+	if pp.Content != nil {
+		p.Content = *pp.Content
+	}
+	if pp.Promoted != nil {
+		p.Promoted = pp.Promoted
+	}
+	if pp.NSFW != nil {
+		p.NSFW = pp.NSFW
+	}
+}
+
+// Visible - whether the post is shown, posts stored before statuses were introduced are active.
+func (p Post) Visible() bool {
+	return p.Status != StatusDeletedByAuthor && p.Status != StatusRemovedByMod
 }
 
 func (p Post) CheckValidity() error {
@@ -55,12 +198,3 @@ func (p Post) CheckValidity() error {
 
 	return nil
 }
-
-var chars = []byte("abcdefghijklmnopqrstuvwxyz0123456789")
-
-func (p *Post) GenerateAuthorName() {
-	inBytes := make([]byte, 0, 11)
-	inBytes = append(inBytes, []byte("t2_")...)
-	inBytes = append(inBytes, uniuri.NewLenChars(8, chars)...)
-	p.Author = string(inBytes)
-}
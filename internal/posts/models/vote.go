@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	VoteUp    = "up"
+	VoteDown  = "down"
+	VoteClear = "clear"
+)
+
+// Vote - vote of a user on a post, a user has at most one vote per post.
+type Vote struct {
+	Id        string             `json:"id" bson:"_id,omitempty"`
+	User      string             `json:"user" bson:"user"`
+	Post      primitive.ObjectID `json:"post" bson:"post"`
+	Direction int                `json:"direction" bson:"direction"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// VoteRequest - body of the vote endpoint.
+type VoteRequest struct {
+	Direction string `json:"direction" binding:"required,oneof=up down clear"`
+}
+
+// Value - the direction as +1, -1 or 0 for a cleared vote.
+func (v VoteRequest) Value() int {
+	switch v.Direction {
+	case VoteUp:
+		return 1
+	case VoteDown:
+		return -1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,143 @@
+package placement
+
+import (
+	"sort"
+
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+)
+
+const (
+	// NSFWNeighbours - a promoted post is never placed next to a NSFW post
+	NSFWNeighbours = "neighbours"
+	// NSFWPage - no promoted posts on a page containing any NSFW post
+	NSFWPage = "page"
+	// NSFWNone - NSFW posts do not restrict the placement
+	NSFWNone = "none"
+
+	// FallbackSkip - a slot that cannot be filled stays empty
+	FallbackSkip = "skip"
+	// FallbackShift - a slot that cannot be filled moves down to the next safe position
+	FallbackShift = "shift"
+)
+
+// Result - outcome of placing promoted posts on a page.
+type Result struct {
+	Inserted int
+	// SkippedNSFW - slots left empty because of NSFW posts
+	SkippedNSFW int
+	// SkippedShort - slots left empty because the page is too short
+	SkippedShort int
+}
+
+// Placer - inserts promoted posts into a page of organic posts.
+type Placer struct {
+	slots      []int
+	maxPerPage int
+	nsfwPolicy string
+	fallback   string
+}
+
+func New(cfg config.PromotedConfig) *Placer {
+	slots := make([]int, len(cfg.Slots))
+	copy(slots, cfg.Slots)
+	sort.Ints(slots)
+
+	return &Placer{
+		slots:      slots,
+		maxPerPage: cfg.MaxPerPage,
+		nsfwPolicy: cfg.NSFWPolicy,
+		fallback:   cfg.Fallback,
+	}
+}
+
+// Sample - number of promoted posts needed to fill a page.
+func (p *Placer) Sample() int {
+	if p.maxPerPage < len(p.slots) {
+		return p.maxPerPage
+	}
+	return len(p.slots)
+}
+
+// Place - returns the page with the promoted posts inserted at the configured slots.
+// Slots are 1-based positions in the resulting page, the organic posts keep their relative order.
+func (p *Placer) Place(organic []*models.Post, promoted []*models.Post) ([]*models.Post, Result) {
+	var res Result
+
+	if len(organic) == 0 || len(promoted) == 0 {
+		return organic, res
+	}
+
+	if p.nsfwPolicy == NSFWPage && hasNSFW(organic) {
+		res.SkippedNSFW = p.Sample()
+		return organic, res
+	}
+
+	page := make([]*models.Post, len(organic), len(organic)+p.Sample())
+	copy(page, organic)
+
+	// index of the last inserted promoted post, two promoted posts are never adjacent
+	last := -2
+
+	for _, slot := range p.slots {
+		if res.Inserted == p.Sample() || res.Inserted == len(promoted) {
+			break
+		}
+
+		idx := slot - 1
+		if idx <= last+1 {
+			idx = last + 2
+		}
+
+		if idx > len(page) {
+			res.SkippedShort++
+			continue
+		}
+
+		for ; idx <= len(page) && !p.safe(page, idx); idx++ {
+			if p.fallback != FallbackShift {
+				break
+			}
+		}
+
+		if idx > len(page) || !p.safe(page, idx) {
+			res.SkippedNSFW++
+			continue
+		}
+
+		page = append(page, nil)
+		copy(page[idx+1:], page[idx:])
+		page[idx] = promoted[res.Inserted]
+
+		last = idx
+		res.Inserted++
+	}
+
+	return page, res
+}
+
+// safe - reports whether a promoted post can be inserted before page[idx].
+func (p *Placer) safe(page []*models.Post, idx int) bool {
+	if p.nsfwPolicy != NSFWNeighbours {
+		return true
+	}
+
+	if idx > 0 && isNSFW(page[idx-1]) {
+		return false
+	}
+
+	return idx == len(page) || !isNSFW(page[idx])
+}
+
+func hasNSFW(posts []*models.Post) bool {
+	for _, v := range posts {
+		if isNSFW(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNSFW(p *models.Post) bool {
+	return p.NSFW != nil && *p.NSFW
+}
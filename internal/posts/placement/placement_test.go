@@ -0,0 +1,177 @@
+package placement
+
+import (
+	"testing"
+
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/stretchr/testify/require"
+)
+
+var defaultRules = config.PromotedConfig{
+	Slots:      []int{2, 16},
+	MaxPerPage: 2,
+	NSFWPolicy: NSFWNeighbours,
+	Fallback:   FallbackSkip,
+}
+
+// page - returns organic posts, the ones at the given indexes are NSFW
+func page(n int, nsfw ...int) []*models.Post {
+	result := make([]*models.Post, 0, n)
+	for i := 0; i < n; i++ {
+		result = append(result, &models.Post{Promoted: new(bool), NSFW: new(bool)})
+	}
+	for _, v := range nsfw {
+		*result[v].NSFW = true
+	}
+	return result
+}
+
+func promoted(n int) []*models.Post {
+	result := make([]*models.Post, 0, n)
+	for i := 0; i < n; i++ {
+		p := &models.Post{Promoted: new(bool), NSFW: new(bool)}
+		*p.Promoted = true
+		result = append(result, p)
+	}
+	return result
+}
+
+// positions - returns the indexes of the promoted posts of the page
+func positions(posts []*models.Post) []int {
+	result := make([]int, 0)
+	for i, v := range posts {
+		if *v.Promoted {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+func TestPlacer_Place(t *testing.T) {
+
+	shift := defaultRules
+	shift.Fallback = FallbackShift
+
+	pagePolicy := defaultRules
+	pagePolicy.NSFWPolicy = NSFWPage
+
+	ignoreNSFW := defaultRules
+	ignoreNSFW.NSFWPolicy = NSFWNone
+
+	single := defaultRules
+	single.MaxPerPage = 1
+
+	cases := []struct {
+		name      string
+		rules     config.PromotedConfig
+		organic   []*models.Post
+		promoted  []*models.Post
+		positions []int
+		result    Result
+	}{
+		{
+			name:      "full page",
+			rules:     defaultRules,
+			organic:   page(25),
+			promoted:  promoted(2),
+			positions: []int{1, 15},
+			result:    Result{Inserted: 2},
+		},
+		{
+			name:      "nsfw neighbour",
+			rules:     defaultRules,
+			organic:   page(25, 1),
+			promoted:  promoted(2),
+			positions: []int{15},
+			result:    Result{Inserted: 1, SkippedNSFW: 1},
+		},
+		{
+			name:      "nsfw neighbour shifted",
+			rules:     shift,
+			organic:   page(25, 1),
+			promoted:  promoted(2),
+			positions: []int{3, 15},
+			result:    Result{Inserted: 2},
+		},
+		{
+			name:      "short page",
+			rules:     defaultRules,
+			organic:   page(5),
+			promoted:  promoted(2),
+			positions: []int{1},
+			result:    Result{Inserted: 1, SkippedShort: 1},
+		},
+		{
+			name:      "single post page",
+			rules:     defaultRules,
+			organic:   page(1),
+			promoted:  promoted(2),
+			positions: []int{1},
+			result:    Result{Inserted: 1, SkippedShort: 1},
+		},
+		{
+			name:      "empty page",
+			rules:     defaultRules,
+			organic:   page(0),
+			promoted:  promoted(2),
+			positions: []int{},
+		},
+		{
+			name:      "not enough promoted posts",
+			rules:     defaultRules,
+			organic:   page(25),
+			promoted:  promoted(1),
+			positions: []int{1},
+			result:    Result{Inserted: 1},
+		},
+		{
+			name:      "max per page",
+			rules:     single,
+			organic:   page(25),
+			promoted:  promoted(2),
+			positions: []int{1},
+			result:    Result{Inserted: 1},
+		},
+		{
+			name:      "nsfw page",
+			rules:     pagePolicy,
+			organic:   page(25, 20),
+			promoted:  promoted(2),
+			positions: []int{},
+			result:    Result{SkippedNSFW: 2},
+		},
+		{
+			name:      "nsfw ignored",
+			rules:     ignoreNSFW,
+			organic:   page(25, 0, 1, 14, 15),
+			promoted:  promoted(2),
+			positions: []int{1, 15},
+			result:    Result{Inserted: 2},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			placer := New(c.rules)
+
+			result, res := placer.Place(c.organic, c.promoted)
+
+			require.Equal(t, c.positions, positions(result))
+			require.Equal(t, c.result, res)
+			require.Len(t, result, len(c.organic)+res.Inserted)
+		})
+	}
+}
+
+func TestPlacer_Sample(t *testing.T) {
+
+	require.Equal(t, 2, New(defaultRules).Sample())
+
+	rules := defaultRules
+	rules.MaxPerPage = 5
+	require.Equal(t, 2, New(rules).Sample())
+
+	rules.MaxPerPage = 0
+	require.Equal(t, 0, New(rules).Sample())
+}
@@ -0,0 +1,135 @@
+package ranking
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	Hot           = "hot"
+	New           = "new"
+	Top           = "top"
+	Rising        = "rising"
+	Controversial = "controversial"
+
+	// Relevance - ranking of the search results, not available to the feeds
+	Relevance = "relevance"
+
+	// Default - ranking used when no sort is requested, raw score keeps the feed backward compatible
+	Default = Top
+)
+
+var (
+	ErrUnknownSort   = errors.New("unknown sort")
+	ErrUnknownWindow = errors.New("unknown time window")
+	ErrNoCursor      = errors.New("the sort is paginated by page, not by cursor")
+)
+
+// Strategy - ranks the organic posts of a feed.
+// Posts are always returned in descending order of Field, _id breaks ties.
+type Strategy interface {
+	// Name - identifies the ranking, pagination cursors are only valid for the ranking that issued them.
+	Name() string
+
+	// Match - restricts the ranked posts, e.g. to the time window of top. Returns nil when all posts are ranked.
+	Match(now time.Time) bson.D
+
+	// Stages - pipeline stages computing Field, when it is not a stored field.
+	Stages(now time.Time) []bson.D
+
+	// Field - the field posts are sorted by.
+	Field() string
+
+	// Key - value of Field for the given post, it is stored in the pagination cursor.
+	Key(p *models.Post) float64
+
+	// Value - converts a cursor key back into a value comparable with Field.
+	Value(key float64) interface{}
+
+	// Rank - value of Field for the given post computed in Go, for storage that does not run the Stages.
+	Rank(p *models.Post, now time.Time) float64
+}
+
+// Get - returns the strategy for the sort and time window query params.
+// The window is only meaningful for top, empty values fall back to the defaults.
+func Get(sort, window string) (Strategy, error) {
+	switch sort {
+	case Hot:
+		return hot{}, nil
+	case New:
+		return newest{}, nil
+	case Rising:
+		return rising{}, nil
+	case Controversial:
+		return controversial{}, nil
+	case Top, "":
+		return newTop(window)
+	default:
+		return nil, ErrUnknownSort
+	}
+}
+
+// Search - returns the strategy ranking the results of a full-text search.
+// It only works with a $text query in the filter, which is why Get does not offer it.
+func Search() Strategy {
+	return relevance{}
+}
+
+// Keyset - reports whether the pages of s are continued by a cursor. The rising rank changes with the time
+// of the request, so the key of the last post of a page does not mark a position in the next request.
+func Keyset(s Strategy) bool {
+	return s.Name() != Rising
+}
+
+// Pipeline - builds the aggregation returning one page of posts ranked by s.
+// When after is set the page starts right after the cursor position, otherwise the page offset is skipped.
+func Pipeline(s Strategy, filter bson.D, after *pagination.Cursor, query *pagination.Query, now time.Time) mongo.Pipeline {
+	pipeline := mongo.Pipeline{{{"$match", filter}}}
+
+	pipeline = append(pipeline, s.Stages(now)...)
+
+	if after != nil {
+		key := s.Value(after.Key)
+		pipeline = append(pipeline, bson.D{{"$match", bson.D{{"$or", bson.A{
+			bson.D{{s.Field(), bson.D{{"$lt", key}}}},
+			bson.D{{s.Field(), key}, {"_id", bson.D{{"$lt", after.ObjectID()}}}},
+		}}}}})
+	}
+
+	pipeline = append(pipeline, bson.D{{"$sort", bson.D{{s.Field(), -1}, {"_id", -1}}}})
+
+	if after == nil && query.GetOffset() > 0 {
+		pipeline = append(pipeline, bson.D{{"$skip", query.GetOffset()}})
+	}
+
+	return append(pipeline, bson.D{{"$limit", query.GetSize()}})
+}
+
+func scoreOf(p *models.Post) int {
+	if p.Score == nil {
+		return 0
+	}
+	return *p.Score
+}
+
+// createdAt - creation time of the post, posts created before created_at was introduced
+// were created at the time of their id.
+func createdAt(p *models.Post) time.Time {
+	if !p.CreatedAt.IsZero() {
+		return p.CreatedAt
+	}
+	if id, err := primitive.ObjectIDFromHex(p.Id); err == nil {
+		return id.Timestamp().UTC()
+	}
+	return time.Time{}
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,158 @@
+package ranking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestGet(t *testing.T) {
+
+	cases := map[[2]string]string{
+		{"", ""}:              "top:all",
+		{"top", ""}:           "top:all",
+		{"top", "hour"}:       "top:hour",
+		{"hot", ""}:           "hot",
+		{"hot", "week"}:       "hot",
+		{"new", ""}:           "new",
+		{"rising", ""}:        "rising",
+		{"controversial", ""}: "controversial",
+	}
+
+	for params, name := range cases {
+		s, err := Get(params[0], params[1])
+		require.NoError(t, err)
+		require.Equal(t, name, s.Name())
+	}
+
+	_, err := Get("best", "")
+	require.Equal(t, ErrUnknownSort, err)
+
+	// relevance needs a $text query, the feeds cannot ask for it
+	_, err = Get(Relevance, "")
+	require.Equal(t, ErrUnknownSort, err)
+
+	_, err = Get("top", "decade")
+	require.Equal(t, ErrUnknownWindow, err)
+}
+
+func TestTop_Match(t *testing.T) {
+
+	now := time.Now()
+
+	s, _ := Get(Top, "day")
+	require.Equal(t, bson.D{{"created_at", bson.D{{"$gte", now.Add(-time.Hour * 24)}}}}, s.Match(now))
+
+	s, _ = Get(Top, "all")
+	require.Nil(t, s.Match(now))
+}
+
+func TestNew_Key(t *testing.T) {
+
+	s, _ := Get(New, "")
+
+	createdAt := time.Date(2022, 4, 10, 12, 30, 15, int(time.Millisecond)*250, time.UTC)
+
+	require.Equal(t, createdAt, s.Value(s.Key(&models.Post{CreatedAt: createdAt})))
+
+	// posts stored before created_at was introduced are keyed by the time of their id
+	legacy := primitive.NewObjectIDFromTimestamp(createdAt)
+	require.Equal(t, createdAt.Truncate(time.Second), s.Value(s.Key(&models.Post{Id: legacy.Hex()})))
+}
+
+func TestRank(t *testing.T) {
+
+	now := time.Now()
+	score := func(v int) *int { return &v }
+
+	get := func(sort string) Strategy {
+		s, err := Get(sort, "")
+		require.NoError(t, err)
+		return s
+	}
+
+	cases := map[string]struct {
+		strategy Strategy
+		post     *models.Post
+		rank     float64
+	}{
+		"top":              {get(Top), &models.Post{Score: score(5)}, 5},
+		"hot":              {get(Hot), &models.Post{Score: score(10), CreatedAt: time.Unix(hotEpoch+hotDecay, 0)}, 2},
+		"hot without date": {get(Hot), &models.Post{Score: score(-10)}, -1},
+		"hot legacy":       {get(Hot), &models.Post{Id: primitive.NewObjectIDFromTimestamp(time.Unix(hotEpoch+hotDecay, 0)).Hex(), Score: score(10)}, 2},
+		"rising":           {get(Rising), &models.Post{Score: score(8), CreatedAt: now.Add(-time.Hour * 2)}, 1},
+		"controversial":    {get(Controversial), &models.Post{Ups: 10, Downs: 10}, 20},
+		"one sided":        {get(Controversial), &models.Post{Ups: 10}, 0},
+		"relevance":        {Search(), &models.Post{Score: score(100), Rank: 2}, 6},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.InDelta(t, tc.rank, tc.strategy.Rank(tc.post, now), 1e-9)
+		})
+	}
+}
+
+func TestPipeline(t *testing.T) {
+
+	now := time.Now()
+	filter := bson.D{{"promoted", false}}
+
+	t.Run("first page", func(t *testing.T) {
+		s, _ := Get(Top, "")
+
+		pipeline := Pipeline(s, filter, nil, &pagination.Query{Size: 10}, now)
+
+		require.Equal(t, mongo.Pipeline{
+			{{"$match", filter}},
+			{{"$sort", bson.D{{"score", -1}, {"_id", -1}}}},
+			{{"$limit", 10}},
+		}, pipeline)
+	})
+
+	t.Run("page", func(t *testing.T) {
+		s, _ := Get(Hot, "")
+
+		pipeline := Pipeline(s, filter, nil, &pagination.Query{Size: 10, Page: 3}, now)
+
+		require.Len(t, pipeline, 5)
+		require.Equal(t, "$addFields", pipeline[1][0].Key)
+		require.Equal(t, bson.D{{"$sort", bson.D{{"rank", -1}, {"_id", -1}}}}, pipeline[2])
+		require.Equal(t, bson.D{{"$skip", 20}}, pipeline[3])
+	})
+
+	t.Run("search", func(t *testing.T) {
+		text := bson.D{{"$text", bson.D{{"$search", "golang"}}}}
+
+		pipeline := Pipeline(Search(), text, nil, &pagination.Query{Size: 10}, now)
+
+		require.Len(t, pipeline, 4)
+		// $text is only allowed in the first stage
+		require.Equal(t, bson.D{{"$match", text}}, pipeline[0])
+		require.Equal(t, "$addFields", pipeline[1][0].Key)
+		require.Equal(t, bson.D{{"$sort", bson.D{{"rank", -1}, {"_id", -1}}}}, pipeline[2])
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		s, _ := Get(Top, "")
+		id := primitive.NewObjectID()
+
+		pipeline := Pipeline(s, filter, &pagination.Cursor{Key: 42, ID: id.Hex()}, &pagination.Query{Size: 10, Page: 3}, now)
+
+		require.Equal(t, mongo.Pipeline{
+			{{"$match", filter}},
+			{{"$match", bson.D{{"$or", bson.A{
+				bson.D{{"score", bson.D{{"$lt", 42}}}},
+				bson.D{{"score", 42}, {"_id", bson.D{{"$lt", id}}}},
+			}}}}},
+			{{"$sort", bson.D{{"score", -1}, {"_id", -1}}}},
+			{{"$limit", 10}},
+		}, pipeline)
+	})
+}
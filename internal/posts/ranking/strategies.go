@@ -0,0 +1,306 @@
+package ranking
+
+import (
+	"math"
+	"time"
+
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// rankField - computed field holding the rank of time-decayed strategies
+	rankField = "rank"
+
+	// hotEpoch and hotDecay - reddit's hot formula constants, 45000 seconds of age are worth a 10x score
+	hotEpoch = 1134028003
+	hotDecay = 45000
+
+	// risingWindow - only posts younger than this are rising
+	risingWindow = time.Hour * 24
+	// risingGravity - how fast the rising rank decays with the age of the post in hours
+	risingGravity = 1.5
+)
+
+// top - highest raw score first, optionally within a time window.
+type top struct {
+	window string
+	since  time.Duration
+}
+
+var windows = map[string]time.Duration{
+	"hour":  time.Hour,
+	"day":   time.Hour * 24,
+	"week":  time.Hour * 24 * 7,
+	"month": time.Hour * 24 * 30,
+	"year":  time.Hour * 24 * 365,
+	"all":   0,
+}
+
+func newTop(window string) (Strategy, error) {
+	if window == "" {
+		window = "all"
+	}
+
+	since, ok := windows[window]
+	if !ok {
+		return nil, ErrUnknownWindow
+	}
+
+	return top{window: window, since: since}, nil
+}
+
+func (t top) Name() string {
+	return Top + ":" + t.window
+}
+
+func (t top) Match(now time.Time) bson.D {
+	if t.since == 0 {
+		return nil
+	}
+	return bson.D{{"created_at", bson.D{{"$gte", now.Add(-t.since)}}}}
+}
+
+func (t top) Stages(time.Time) []bson.D {
+	return nil
+}
+
+func (t top) Field() string {
+	return "score"
+}
+
+func (t top) Key(p *models.Post) float64 {
+	return float64(scoreOf(p))
+}
+
+func (t top) Value(key float64) interface{} {
+	return int(key)
+}
+
+func (t top) Rank(p *models.Post, _ time.Time) float64 {
+	return t.Key(p)
+}
+
+// newest - most recently created first.
+type newest struct{}
+
+func (newest) Name() string {
+	return New
+}
+
+func (newest) Match(time.Time) bson.D {
+	return nil
+}
+
+func (newest) Stages(time.Time) []bson.D {
+	return nil
+}
+
+func (newest) Field() string {
+	return "created_at"
+}
+
+func (newest) Key(p *models.Post) float64 {
+	return float64(millis(createdAt(p)))
+}
+
+func (newest) Value(key float64) interface{} {
+	return time.Unix(0, int64(key)*int64(time.Millisecond)).UTC()
+}
+
+func (n newest) Rank(p *models.Post, _ time.Time) float64 {
+	return n.Key(p)
+}
+
+// hot - reddit's hot ranking: the order of magnitude of the score plus a bonus growing with the creation time,
+// so newer posts need less score to stay on top.
+type hot struct{}
+
+func (hot) Name() string {
+	return Hot
+}
+
+func (hot) Match(time.Time) bson.D {
+	return nil
+}
+
+func (hot) Stages(time.Time) []bson.D {
+	sign := bson.D{{"$cond", bson.A{
+		bson.D{{"$gt", bson.A{"$score", 0}}},
+		1,
+		bson.D{{"$cond", bson.A{bson.D{{"$lt", bson.A{"$score", 0}}}, -1, 0}}},
+	}}}
+
+	order := bson.D{{"$log10", bson.D{{"$max", bson.A{bson.D{{"$abs", "$score"}}, 1}}}}}
+
+	// posts created before created_at was introduced were created at the time of their id
+	seconds := bson.D{{"$divide", bson.A{
+		bson.D{{"$toLong", bson.D{{"$ifNull", bson.A{"$created_at", bson.D{{"$toDate", "$_id"}}}}}}},
+		1000,
+	}}}
+
+	return []bson.D{{{"$addFields", bson.D{{rankField, bson.D{{"$add", bson.A{
+		bson.D{{"$multiply", bson.A{sign, order}}},
+		bson.D{{"$divide", bson.A{bson.D{{"$subtract", bson.A{seconds, hotEpoch}}}, hotDecay}}},
+	}}}}}}}}
+}
+
+func (hot) Field() string {
+	return rankField
+}
+
+func (hot) Key(p *models.Post) float64 {
+	return p.Rank
+}
+
+func (hot) Value(key float64) interface{} {
+	return key
+}
+
+func (hot) Rank(p *models.Post, _ time.Time) float64 {
+	score := float64(scoreOf(p))
+
+	sign := 0.0
+	switch {
+	case score > 0:
+		sign = 1
+	case score < 0:
+		sign = -1
+	}
+
+	seconds := float64(hotEpoch)
+	if t := createdAt(p); !t.IsZero() {
+		seconds = float64(millis(t)) / 1000
+	}
+
+	return sign*math.Log10(math.Max(math.Abs(score), 1)) + (seconds-hotEpoch)/hotDecay
+}
+
+// rising - recent posts gaining score quickly, the score is divided by the decayed age of the post.
+type rising struct{}
+
+func (rising) Name() string {
+	return Rising
+}
+
+func (rising) Match(now time.Time) bson.D {
+	return bson.D{{"created_at", bson.D{{"$gte", now.Add(-risingWindow)}}}}
+}
+
+func (rising) Stages(now time.Time) []bson.D {
+	hours := bson.D{{"$divide", bson.A{bson.D{{"$subtract", bson.A{now, "$created_at"}}}, int64(time.Hour / time.Millisecond)}}}
+
+	return []bson.D{{{"$addFields", bson.D{{rankField, bson.D{{"$divide", bson.A{
+		"$score",
+		bson.D{{"$pow", bson.A{bson.D{{"$add", bson.A{hours, 2}}}, risingGravity}}},
+	}}}}}}}}
+}
+
+func (rising) Field() string {
+	return rankField
+}
+
+func (rising) Key(p *models.Post) float64 {
+	return p.Rank
+}
+
+func (rising) Value(key float64) interface{} {
+	return key
+}
+
+func (rising) Rank(p *models.Post, now time.Time) float64 {
+	hours := float64(millis(now)-millis(createdAt(p))) / float64(time.Hour/time.Millisecond)
+
+	return float64(scoreOf(p)) / math.Pow(hours+2, risingGravity)
+}
+
+// controversial - many votes split evenly between up and down: the vote count raised to the power of the up/down balance.
+type controversial struct{}
+
+func (controversial) Name() string {
+	return Controversial
+}
+
+func (controversial) Match(time.Time) bson.D {
+	return nil
+}
+
+func (controversial) Stages(time.Time) []bson.D {
+	balance := bson.D{{"$cond", bson.A{
+		bson.D{{"$gt", bson.A{"$ups", "$downs"}}},
+		bson.D{{"$divide", bson.A{"$downs", "$ups"}}},
+		bson.D{{"$divide", bson.A{"$ups", "$downs"}}},
+	}}}
+
+	// posts without both up and down votes are not controversial at all
+	return []bson.D{{{"$addFields", bson.D{{rankField, bson.D{{"$cond", bson.A{
+		bson.D{{"$or", bson.A{bson.D{{"$lte", bson.A{"$ups", 0}}}, bson.D{{"$lte", bson.A{"$downs", 0}}}}}},
+		0,
+		bson.D{{"$pow", bson.A{bson.D{{"$add", bson.A{"$ups", "$downs"}}}, balance}}},
+	}}}}}}}}
+}
+
+func (controversial) Field() string {
+	return rankField
+}
+
+func (controversial) Key(p *models.Post) float64 {
+	return p.Rank
+}
+
+func (controversial) Value(key float64) interface{} {
+	return key
+}
+
+func (controversial) Rank(p *models.Post, _ time.Time) float64 {
+	if p.Ups <= 0 || p.Downs <= 0 {
+		return 0
+	}
+
+	ups, downs := float64(p.Ups), float64(p.Downs)
+
+	balance := ups / downs
+	if ups > downs {
+		balance = downs / ups
+	}
+
+	return math.Pow(ups+downs, balance)
+}
+
+// relevance - text score of the search blended with the order of magnitude of the post score,
+// so of equally relevant posts the popular ones come first. Requires a $text query in the filter.
+type relevance struct{}
+
+func (relevance) Name() string {
+	return Relevance
+}
+
+func (relevance) Match(time.Time) bson.D {
+	return nil
+}
+
+func (relevance) Stages(time.Time) []bson.D {
+	popularity := bson.D{{"$add", bson.A{1, bson.D{{"$log10", bson.D{{"$max", bson.A{"$score", 1}}}}}}}}
+
+	return []bson.D{{{"$addFields", bson.D{{rankField, bson.D{{"$multiply", bson.A{
+		bson.D{{"$meta", "textScore"}},
+		popularity,
+	}}}}}}}}
+}
+
+func (relevance) Field() string {
+	return rankField
+}
+
+func (relevance) Key(p *models.Post) float64 {
+	return p.Rank
+}
+
+func (relevance) Value(key float64) interface{} {
+	return key
+}
+
+// Rank - the text score is only known to the storage, it passes it in the rank of the post.
+func (relevance) Rank(p *models.Post, _ time.Time) float64 {
+	return p.Rank * (1 + math.Log10(math.Max(float64(scoreOf(p)), 1)))
+}
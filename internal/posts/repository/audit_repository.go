@@ -0,0 +1,64 @@
+//go:generate mockgen -source audit_repository.go -destination mock/audit_repository_mock.go -package mock
+package repository
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/driver/db"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	auditCollectionName = "post_audit"
+)
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditEntry) error
+	// FindByPost - status changes of the post, oldest first
+	FindByPost(ctx context.Context, post primitive.ObjectID) ([]*models.AuditEntry, error)
+}
+
+type auditRepo struct {
+	logger     *log.Factory
+	collection db.Collection
+}
+
+func NewAuditRepository(logger *log.Factory, collection db.Collection) *auditRepo {
+	return &auditRepo{
+		logger:     logger,
+		collection: collection,
+	}
+}
+
+func (r *auditRepo) Create(ctx context.Context, entry *models.AuditEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, entry); err != nil {
+		r.logger.For(ctx).Error("Audit.Create.InsertOne", zap.String("err", err.Error()))
+		return errors.Wrap(err, "Audit.Create.InsertOne")
+	}
+
+	return nil
+}
+
+func (r *auditRepo) FindByPost(ctx context.Context, post primitive.ObjectID) ([]*models.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	result := make([]*models.AuditEntry, 0)
+	opts := options.Find().SetSort(bson.D{{"created_at", 1}, {"_id", 1}})
+
+	if err := r.collection.Find(ctx, bson.D{{"post", post}}, &result, opts); err != nil {
+		r.logger.For(ctx).Error("Audit.FindByPost.Find", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Audit.FindByPost.Find")
+	}
+
+	return result, nil
+}
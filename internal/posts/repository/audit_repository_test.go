@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/driver/db/mock"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestAuditRepo(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := NewAuditRepository(logger, coll)
+
+	post := primitive.NewObjectID()
+	entries := []*models.AuditEntry{
+		{Post: post, Actor: "t2_mod", From: models.StatusActive, To: models.StatusLocked, CreatedAt: time.Now().UTC()},
+	}
+
+	t.Run("create", func(t *testing.T) {
+
+		coll.EXPECT().InsertOne(gomock.Any(), entries[0]).Return(&mongo.InsertOneResult{InsertedID: primitive.NewObjectID()}, nil)
+
+		require.NoError(t, repo.Create(context.Background(), entries[0]))
+	})
+
+	t.Run("find by post", func(t *testing.T) {
+
+		coll.EXPECT().Find(gomock.Any(), bson.D{{"post", post}}, gomock.Eq(&[]*models.AuditEntry{}), gomock.Any()).Return(nil).SetArg(2, entries)
+
+		result, err := repo.FindByPost(context.Background(), post)
+
+		require.NoError(t, err)
+		require.Equal(t, entries, result)
+	})
+
+	t.Run("error", func(t *testing.T) {
+
+		coll.EXPECT().Find(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mongo.CommandError{})
+
+		_, err := repo.FindByPost(context.Background(), post)
+
+		require.True(t, errors.As(err, &mongo.CommandError{}))
+	})
+
+}
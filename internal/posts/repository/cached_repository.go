@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
+	"github.com/aliykh/reddit-feed/pkg/cache"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// cachedRepo - caches the feed pages and counts of the wrapped repository.
+// Every write through it purges the cache, votes and comment counts included, they move the posts in the rankings.
+// Aggregate is never cached, promoted posts are sampled anew on every request.
+type cachedRepo struct {
+	Repository
+	logger *log.Factory
+	cache  cache.Cache
+	ttl    time.Duration
+
+	// generation - bumped by every purge, an entry read from the backend before a purge is not cached after it
+	mu         sync.RWMutex
+	generation uint64
+}
+
+func NewCached(logger *log.Factory, repo Repository, c cache.Cache, ttl time.Duration) *cachedRepo {
+	return &cachedRepo{
+		Repository: repo,
+		logger:     logger,
+		cache:      c,
+		ttl:        ttl,
+	}
+}
+
+type cachedCount struct {
+	Count int64 `bson:"count"`
+}
+
+type cachedPosts struct {
+	Posts []*models.Post `bson:"posts"`
+}
+
+func (r *cachedRepo) Create(ctx context.Context, m *models.Post) (*models.Post, error) {
+	result, err := r.Repository.Create(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	r.purge(ctx)
+	return result, nil
+}
+
+func (r *cachedRepo) CreateMany(ctx context.Context, posts []*models.Post) (map[int]error, error) {
+	failed, err := r.Repository.CreateMany(ctx, posts)
+	if err != nil {
+		return nil, err
+	}
+
+	r.purge(ctx)
+	return failed, nil
+}
+
+func (r *cachedRepo) Update(ctx context.Context, id primitive.ObjectID, m *models.Post) (*models.Post, error) {
+	result, err := r.Repository.Update(ctx, id, m)
+	if err != nil {
+		return nil, err
+	}
+
+	r.purge(ctx)
+	return result, nil
+}
+
+func (r *cachedRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if err := r.Repository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	r.purge(ctx)
+	return nil
+}
+
+func (r *cachedRepo) SetStatus(ctx context.Context, id primitive.ObjectID, status string) (*models.Post, error) {
+	result, err := r.Repository.SetStatus(ctx, id, status)
+	if err != nil {
+		return nil, err
+	}
+
+	r.purge(ctx)
+	return result, nil
+}
+
+func (r *cachedRepo) IncrementScore(ctx context.Context, id primitive.ObjectID, score, ups, downs int) (*models.Post, error) {
+	result, err := r.Repository.IncrementScore(ctx, id, score, ups, downs)
+	if err != nil {
+		return nil, err
+	}
+
+	r.purge(ctx)
+	return result, nil
+}
+
+func (r *cachedRepo) IncrementCommentCount(ctx context.Context, id primitive.ObjectID, delta int) error {
+	if err := r.Repository.IncrementCommentCount(ctx, id, delta); err != nil {
+		return err
+	}
+
+	r.purge(ctx)
+	return nil
+}
+
+func (r *cachedRepo) CountDocuments(ctx context.Context, filter bson.D) (int64, error) {
+	key := r.key("count", filter)
+
+	var cached cachedCount
+	if r.get(ctx, key, &cached) {
+		return cached.Count, nil
+	}
+
+	generation := r.currentGeneration()
+
+	count, err := r.Repository.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	r.set(ctx, key, cachedCount{Count: count}, generation)
+	return count, nil
+}
+
+func (r *cachedRepo) FindRanked(ctx context.Context, filter bson.D, strategy ranking.Strategy, after *pagination.Cursor, query *pagination.Query) ([]*models.Post, error) {
+	position := fmt.Sprintf("page=%d", query.GetPage())
+	if after != nil {
+		position = fmt.Sprintf("after=%v,%s", after.Key, after.ID)
+	}
+
+	key := r.key(fmt.Sprintf("ranked:%s:%s:size=%d", strategy.Name(), position, query.GetSize()), filter)
+
+	var cached cachedPosts
+	if r.get(ctx, key, &cached) {
+		return cached.Posts, nil
+	}
+
+	generation := r.currentGeneration()
+
+	posts, err := r.Repository.FindRanked(ctx, filter, strategy, after, query)
+	if err != nil {
+		return nil, err
+	}
+
+	r.set(ctx, key, cachedPosts{Posts: posts}, generation)
+	return posts, nil
+}
+
+// key - identifies the entry of the filter. Time bounds of the filter move with every request,
+// so they are truncated to the ttl, otherwise windowed feeds would never hit the cache.
+func (r *cachedRepo) key(prefix string, filter bson.D) string {
+	b, err := bson.MarshalExtJSON(bson.D{{"filter", truncateTimes(filter, r.ttl)}}, true, false)
+	if err != nil {
+		// not cacheable, an empty key is never looked up
+		return ""
+	}
+
+	sum := sha256.Sum256(b)
+	return "posts:" + prefix + ":" + hex.EncodeToString(sum[:])
+}
+
+// get - decodes the entry of the key into v, cache failures are treated as misses.
+func (r *cachedRepo) get(ctx context.Context, key string, v interface{}) bool {
+	if key == "" {
+		return false
+	}
+
+	b, ok, err := r.cache.Get(ctx, key)
+	if err != nil {
+		r.logger.For(ctx).Error("CachedRepo.Get", zap.String("err", err.Error()))
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	if err = bson.Unmarshal(b, v); err != nil {
+		r.logger.For(ctx).Error("CachedRepo.Get.Unmarshal", zap.String("err", err.Error()))
+		return false
+	}
+
+	return true
+}
+
+// currentGeneration - captured before a backend read, see set.
+func (r *cachedRepo) currentGeneration() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.generation
+}
+
+// set - caches v unless the cache was purged since the generation was captured, the value may predate the write
+// which purged it. The check and the write hold off purges, so a purge cannot slip in between them.
+func (r *cachedRepo) set(ctx context.Context, key string, v interface{}, generation uint64) {
+	if key == "" {
+		return
+	}
+
+	b, err := bson.Marshal(v)
+	if err != nil {
+		r.logger.For(ctx).Error("CachedRepo.Set.Marshal", zap.String("err", err.Error()))
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.generation != generation {
+		return
+	}
+
+	if err = r.cache.Set(ctx, key, b, r.ttl); err != nil {
+		r.logger.For(ctx).Error("CachedRepo.Set", zap.String("err", err.Error()))
+	}
+}
+
+// purge - drops every entry, a new or changed post may land on any page of any feed.
+func (r *cachedRepo) purge(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+
+	if err := r.cache.Purge(ctx); err != nil {
+		r.logger.For(ctx).Error("CachedRepo.Purge", zap.String("err", err.Error()))
+	}
+}
+
+func truncateTimes(v interface{}, d time.Duration) interface{} {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Truncate(d)
+	case bson.D:
+		result := make(bson.D, len(v))
+		for i, e := range v {
+			result[i] = bson.E{e.Key, truncateTimes(e.Value, d)}
+		}
+		return result
+	case bson.A:
+		result := make(bson.A, len(v))
+		for i, e := range v {
+			result[i] = truncateTimes(e, d)
+		}
+		return result
+	default:
+		return v
+	}
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
+	postsMock "github.com/aliykh/reddit-feed/internal/posts/repository/mock"
+	"github.com/aliykh/reddit-feed/pkg/cache"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCachedRepo(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inner := postsMock.NewMockRepository(ctrl)
+
+	repo := NewCached(logger, inner, cache.NewLRU(100), time.Minute)
+
+	ctx := context.Background()
+	strategy, _ := ranking.Get(ranking.Hot, "")
+	filter := bson.D{{"promoted", false}}
+	query := &pagination.Query{Page: 1, Size: 2}
+
+	posts := []*models.Post{
+		{Id: primitive.NewObjectID().Hex(), Title: "first", Subreddit: "/r/golang"},
+		{Id: primitive.NewObjectID().Hex(), Title: "second", Subreddit: "/r/golang"},
+	}
+
+	t.Run("hit", func(t *testing.T) {
+		inner.EXPECT().CountDocuments(gomock.Any(), filter).Return(int64(2), nil).Times(1)
+		inner.EXPECT().FindRanked(gomock.Any(), filter, strategy, nil, query).Return(posts, nil).Times(1)
+
+		for i := 0; i < 3; i++ {
+			count, err := repo.CountDocuments(ctx, filter)
+			require.NoError(t, err)
+			require.Equal(t, int64(2), count)
+
+			result, err := repo.FindRanked(ctx, filter, strategy, nil, query)
+			require.NoError(t, err)
+			require.Equal(t, posts, result)
+		}
+	})
+
+	t.Run("keyed by page and strategy", func(t *testing.T) {
+		newest, _ := ranking.Get(ranking.New, "")
+		next := &pagination.Query{Page: 2, Size: 2}
+
+		inner.EXPECT().FindRanked(gomock.Any(), filter, newest, nil, query).Return(posts, nil).Times(1)
+		inner.EXPECT().FindRanked(gomock.Any(), filter, strategy, nil, next).Return(posts[1:], nil).Times(1)
+
+		_, err := repo.FindRanked(ctx, filter, newest, nil, query)
+		require.NoError(t, err)
+
+		result, err := repo.FindRanked(ctx, filter, strategy, nil, next)
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+	})
+
+	t.Run("time bounds within the ttl share an entry", func(t *testing.T) {
+		now := time.Now().Truncate(time.Minute)
+		windowed := func(at time.Time) bson.D {
+			return bson.D{{"promoted", false}, {"created_at", bson.D{{"$gte", at}}}}
+		}
+
+		inner.EXPECT().CountDocuments(gomock.Any(), windowed(now)).Return(int64(1), nil).Times(1)
+
+		_, err := repo.CountDocuments(ctx, windowed(now))
+		require.NoError(t, err)
+
+		count, err := repo.CountDocuments(ctx, windowed(now.Add(time.Second*30)))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), count)
+	})
+
+	t.Run("create invalidates", func(t *testing.T) {
+		post := &models.Post{Title: "third", Subreddit: "/r/golang"}
+
+		inner.EXPECT().Create(gomock.Any(), post).Return(post, nil)
+		inner.EXPECT().CountDocuments(gomock.Any(), filter).Return(int64(3), nil).Times(1)
+
+		_, err := repo.Create(ctx, post)
+		require.NoError(t, err)
+
+		count, err := repo.CountDocuments(ctx, filter)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), count)
+	})
+
+	t.Run("votes and comments invalidate", func(t *testing.T) {
+		id := primitive.NewObjectID()
+
+		inner.EXPECT().IncrementScore(gomock.Any(), id, 1, 1, 0).Return(posts[0], nil)
+		inner.EXPECT().IncrementCommentCount(gomock.Any(), id, 1).Return(nil)
+		inner.EXPECT().FindRanked(gomock.Any(), filter, strategy, nil, query).Return(posts, nil).Times(3)
+
+		_, err := repo.FindRanked(ctx, filter, strategy, nil, query)
+		require.NoError(t, err)
+
+		_, err = repo.IncrementScore(ctx, id, 1, 1, 0)
+		require.NoError(t, err)
+
+		_, err = repo.FindRanked(ctx, filter, strategy, nil, query)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.IncrementCommentCount(ctx, id, 1))
+
+		_, err = repo.FindRanked(ctx, filter, strategy, nil, query)
+		require.NoError(t, err)
+	})
+
+	t.Run("read racing a write is not cached", func(t *testing.T) {
+		post := &models.Post{Title: "fourth", Subreddit: "/r/golang"}
+		other := bson.D{{"promoted", false}, {"subreddit", "/r/golang"}}
+
+		inner.EXPECT().Create(gomock.Any(), post).Return(post, nil)
+		// the post is created while the count is read, so the count may not include it
+		inner.EXPECT().CountDocuments(gomock.Any(), other).DoAndReturn(func(context.Context, bson.D) (int64, error) {
+			_, err := repo.Create(ctx, post)
+			require.NoError(t, err)
+			return 3, nil
+		})
+		inner.EXPECT().CountDocuments(gomock.Any(), other).Return(int64(4), nil)
+
+		count, err := repo.CountDocuments(ctx, other)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), count)
+
+		count, err = repo.CountDocuments(ctx, other)
+		require.NoError(t, err)
+		require.Equal(t, int64(4), count)
+	})
+
+	t.Run("promoted sampling is not cached", func(t *testing.T) {
+		stage := bson.D{{"$match", bson.D{{"promoted", true}}}}
+
+		inner.EXPECT().Aggregate(gomock.Any(), stage).Return(posts, nil).Times(2)
+
+		for i := 0; i < 2; i++ {
+			_, err := repo.Aggregate(ctx, stage)
+			require.NoError(t, err)
+		}
+	})
+
+}
@@ -0,0 +1,97 @@
+//go:generate mockgen -source idempotency_repository.go -destination mock/idempotency_repository_mock.go -package mock
+package repository
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/driver/db"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	idempotencyCollectionName = "post_idempotency_keys"
+
+	// reservationTTL - a request that never completes releases its key by then
+	reservationTTL = time.Minute
+)
+
+type IdempotencyRepository interface {
+	// Reserve - stores the key of a request in progress. When the key is already stored it is returned instead,
+	// the result is nil when the key is reserved by this call
+	Reserve(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error)
+	// Complete - records the post created by the request of the key, the key is kept for the ttl
+	Complete(ctx context.Context, id string, post string) error
+	// Release - removes the key of a failed request, so the request may be retried
+	Release(ctx context.Context, id string) error
+}
+
+type idempotencyRepo struct {
+	logger     *log.Factory
+	collection db.Collection
+	ttl        time.Duration
+}
+
+// NewIdempotencyRepository - keys are removed ttl after their post is created, by the ttl index on expires_at.
+func NewIdempotencyRepository(logger *log.Factory, collection db.Collection, ttl time.Duration) *idempotencyRepo {
+	return &idempotencyRepo{
+		logger:     logger,
+		collection: collection,
+		ttl:        ttl,
+	}
+}
+
+func (r *idempotencyRepo) Reserve(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	key.PostId = ""
+	key.ExpiresAt = time.Now().UTC().Add(reservationTTL)
+
+	_, err := r.collection.InsertOne(ctx, key)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		r.logger.For(ctx).Error("Idempotency.Reserve.InsertOne", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Idempotency.Reserve.InsertOne")
+	}
+
+	result := &models.IdempotencyKey{}
+	if err = r.collection.FindOne(ctx, bson.D{{"_id", key.Id}}, result); err != nil {
+		r.logger.For(ctx).Error("Idempotency.Reserve.FindOne", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Idempotency.Reserve.FindOne")
+	}
+
+	return result, nil
+}
+
+func (r *idempotencyRepo) Complete(ctx context.Context, id string, post string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	update := bson.D{{"$set", bson.D{{"post_id", post}, {"expires_at", time.Now().UTC().Add(r.ttl)}}}}
+
+	if _, err := r.collection.UpdateOne(ctx, bson.D{{"_id", id}}, update); err != nil {
+		r.logger.For(ctx).Error("Idempotency.Complete.UpdateOne", zap.String("err", err.Error()))
+		return errors.Wrap(err, "Idempotency.Complete.UpdateOne")
+	}
+
+	return nil
+}
+
+func (r *idempotencyRepo) Release(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.D{{"_id", id}}); err != nil {
+		r.logger.For(ctx).Error("Idempotency.Release.DeleteOne", zap.String("err", err.Error()))
+		return errors.Wrap(err, "Idempotency.Release.DeleteOne")
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/driver/db/mock"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestIdempotencyRepo(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := NewIdempotencyRepository(logger, coll, time.Hour)
+
+	duplicate := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}
+
+	t.Run("reserve", func(t *testing.T) {
+		key := &models.IdempotencyKey{Id: "t2_user:key", BodyHash: "hash"}
+
+		coll.EXPECT().InsertOne(gomock.Any(), key).Return(&mongo.InsertOneResult{InsertedID: key.Id}, nil)
+
+		existing, err := repo.Reserve(context.Background(), key)
+
+		require.NoError(t, err)
+		require.Nil(t, existing)
+		require.WithinDuration(t, time.Now().Add(reservationTTL), key.ExpiresAt, time.Second)
+	})
+
+	t.Run("reserve existing", func(t *testing.T) {
+		stored := models.IdempotencyKey{Id: "t2_user:key", BodyHash: "hash", PostId: "post"}
+
+		coll.EXPECT().InsertOne(gomock.Any(), gomock.Any()).Return(nil, duplicate)
+		coll.EXPECT().FindOne(gomock.Any(), bson.D{{"_id", "t2_user:key"}}, gomock.Eq(&models.IdempotencyKey{})).Return(nil).SetArg(2, stored)
+
+		existing, err := repo.Reserve(context.Background(), &models.IdempotencyKey{Id: "t2_user:key", BodyHash: "other"})
+
+		require.NoError(t, err)
+		require.Equal(t, &stored, existing)
+	})
+
+	t.Run("reserve fails", func(t *testing.T) {
+		coll.EXPECT().InsertOne(gomock.Any(), gomock.Any()).Return(nil, mongo.ErrClientDisconnected)
+
+		_, err := repo.Reserve(context.Background(), &models.IdempotencyKey{Id: "t2_user:key"})
+
+		require.True(t, errors.Is(err, mongo.ErrClientDisconnected))
+	})
+
+	t.Run("complete", func(t *testing.T) {
+		coll.EXPECT().UpdateOne(gomock.Any(), bson.D{{"_id", "t2_user:key"}}, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ interface{}, update interface{}, _ ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+				set := update.(bson.D)[0].Value.(bson.D)
+				require.Equal(t, "post", set[0].Value)
+				require.WithinDuration(t, time.Now().Add(time.Hour), set[1].Value.(time.Time), time.Second)
+				return &mongo.UpdateResult{MatchedCount: 1}, nil
+			})
+
+		require.NoError(t, repo.Complete(context.Background(), "t2_user:key", "post"))
+	})
+
+	t.Run("release", func(t *testing.T) {
+		coll.EXPECT().DeleteOne(gomock.Any(), bson.D{{"_id", "t2_user:key"}}).Return(&mongo.DeleteResult{DeletedCount: 1}, nil)
+
+		require.NoError(t, repo.Release(context.Background(), "t2_user:key"))
+	})
+}
@@ -7,11 +7,13 @@ import (
 	"github.com/aliykh/reddit-feed/internal/driver/db"
 	"github.com/aliykh/reddit-feed/internal/posts/models"
 	"github.com/aliykh/reddit-feed/internal/posts/repository"
+	usersModels "github.com/aliykh/reddit-feed/internal/users/models"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"log"
+	"os"
 	"testing"
 	"time"
 )
@@ -22,9 +24,12 @@ const (
 )
 
 var (
+	// dbClient - nil when mongo is not reachable, the tests needing it are skipped
 	dbClient *mongo.Client
+	storage  string
 )
 
+// TestMain - runs against the mongo of mongo_addr, or the in-memory storage with APP_STORAGE=memory.
 func TestMain(m *testing.M) {
 
 	// load application configurations
@@ -33,31 +38,55 @@ func TestMain(m *testing.M) {
 		log.Fatalf("failed to load application configuration: %s\n", err)
 	}
 
+	storage = cfg.Storage
+	if storage == "memory" {
+		os.Exit(m.Run())
+	}
+
 	clientOpts := options.Client()
 	clientOpts.ApplyURI(cfg.MongoAddr)
 	clientOpts.SetMaxPoolSize(5)
 
 	client, err := mongo.Connect(context.Background(), clientOpts)
-	defer func() {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-		defer cancel()
-		_ = client.Disconnect(ctx)
-	}()
-
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*8)
-	defer cancel()
 	err = client.Ping(ctx, readpref.Primary())
+	cancel()
+
 	if err != nil {
-		log.Fatal(err.Error())
+		log.Printf("mongo is not reachable, the tests needing it are skipped: %s\n", err)
+	} else {
+		dbClient = client
 	}
 
-	dbClient = client
+	code := m.Run()
 
-	m.Run()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second*3)
+	_ = client.Disconnect(ctx)
+	cancel()
+
+	os.Exit(code)
+}
+
+// collection - the collection of the configured storage, skips the test when mongo is not reachable.
+func collection(t *testing.T, logger *logr.Factory) db.Collection {
+	if storage == "memory" {
+		return db.NewMemory()
+	}
+	if dbClient == nil {
+		t.Skip("mongo is not reachable")
+	}
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+		defer cancel()
+		_ = dbClient.Database(DatabaseName).Collection(CollName).Drop(ctx)
+	})
+
+	return db.New(logger, dbClient, DatabaseName, CollName)
 }
 
 func TestPosts_Create(t *testing.T) {
@@ -71,12 +100,11 @@ func TestPosts_Create(t *testing.T) {
 		Score:     new(int),
 	}
 	*m.Score = 1000
-	m.GenerateAuthorName()
+	m.Author = usersModels.NewID()
 
 	logger := logr.NewFactory(logr.Mock, "test")
 
-	coll := db.New(logger, dbClient, DatabaseName, CollName)
-	repo := repository.New(logger, coll)
+	repo := repository.New(logger, collection(t, logger))
 
 	createdPost, err := repo.Create(context.Background(), m)
 
@@ -84,7 +112,4 @@ func TestPosts_Create(t *testing.T) {
 	require.NotEmpty(t, createdPost.Id)
 	require.Equal(t, createdPost.Score, m.Score)
 
-	ctx, _ := context.WithTimeout(context.Background(), time.Second*3)
-	_ = dbClient.Database(DatabaseName).Collection(CollName).Drop(ctx)
-
 }
@@ -0,0 +1,490 @@
+package postgres
+
+import (
+	"context"
+	logr "github.com/aliykh/log"
+	commentsModels "github.com/aliykh/reddit-feed/internal/comments/models"
+	commentsRepository "github.com/aliykh/reddit-feed/internal/comments/repository"
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/aliykh/reddit-feed/internal/migrations"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
+	"github.com/aliykh/reddit-feed/internal/posts/repository"
+	subredditsModels "github.com/aliykh/reddit-feed/internal/subreddits/models"
+	subredditsRepository "github.com/aliykh/reddit-feed/internal/subreddits/repository"
+	usersModels "github.com/aliykh/reddit-feed/internal/users/models"
+	usersRepository "github.com/aliykh/reddit-feed/internal/users/repository"
+	embedded "github.com/aliykh/reddit-feed/migrations"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	// pool - nil when postgres is not reachable, the tests are skipped
+	pool *pgxpool.Pool
+)
+
+// TestMain - runs against the postgres of postgres_addr, e.g. the one of docker-compose, the tables are
+// emptied before and after the tests. APP_POSTGRES_ADDR points them to another database.
+func TestMain(m *testing.M) {
+
+	// load application configurations
+	cfg, err := config.Load("../../../../../config/local.yml", nil)
+	if err != nil {
+		log.Fatalf("failed to load application configuration: %s\n", err)
+	}
+
+	connectCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	client, err := pgxpool.Connect(connectCtx, cfg.PostgresAddr)
+	if err == nil {
+		if err = client.Ping(connectCtx); err != nil {
+			client.Close()
+		}
+	}
+	cancel()
+
+	if err != nil {
+		log.Printf("postgres is not reachable, the tests needing it are skipped: %s\n", err)
+		os.Exit(m.Run())
+	}
+	pool = client
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	fsys, err := embedded.Postgres()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	loaded, err := migrations.Load(fsys)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	runner := migrations.NewRunner(logr.NewFactory(logr.Mock, "test"), migrations.NewPostgresStore(pool), loaded)
+	if _, err = runner.Up(ctx); err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if _, err = pool.Exec(ctx, `TRUNCATE posts, votes, comments, subreddits, users, post_audit, post_idempotency_keys`); err != nil {
+		log.Fatal(err.Error())
+	}
+
+	code := m.Run()
+
+	_, _ = pool.Exec(context.Background(), `TRUNCATE posts, votes, comments, subreddits, users, post_audit, post_idempotency_keys`)
+	pool.Close()
+
+	os.Exit(code)
+}
+
+// requirePool - skips the test when postgres is not reachable.
+func requirePool(t *testing.T) {
+	if pool == nil {
+		t.Skip("postgres is not reachable")
+	}
+}
+
+func TestPostgresRepo(t *testing.T) {
+	requirePool(t)
+
+	repo := repository.NewPostgres(logr.NewFactory(logr.Mock, "test"), pool)
+	ctx := context.Background()
+
+	newPost := func(title string, score int, promoted bool, age time.Duration) *models.Post {
+		nsfw := false
+		p, err := repo.Create(ctx, &models.Post{
+			Title: title, Subreddit: "/r/golang", Content: title, Score: &score, Promoted: &promoted, NSFW: &nsfw,
+			CreatedAt: time.Now().UTC().Add(-age),
+		})
+		require.NoError(t, err)
+		return p
+	}
+
+	objectID := func(p *models.Post) primitive.ObjectID {
+		id, err := primitive.ObjectIDFromHex(p.Id)
+		require.NoError(t, err)
+		return id
+	}
+
+	old := newPost("old but popular", 100, false, time.Hour*24*3)
+	fresh := newPost("fresh golang news", 10, false, time.Minute)
+	hidden := newPost("removed golang post", 50, false, time.Minute)
+	promoted := newPost("promoted golang course", 1000, true, time.Minute)
+
+	_, err := repo.SetStatus(ctx, objectID(hidden), models.StatusRemovedByMod)
+	require.NoError(t, err)
+
+	organic := bson.D{{"promoted", false}}
+
+	ids := func(posts []*models.Post) []string {
+		result := make([]string, 0, len(posts))
+		for _, p := range posts {
+			result = append(result, p.Id)
+		}
+		return result
+	}
+
+	t.Run("count", func(t *testing.T) {
+		count, err := repo.CountDocuments(ctx, organic)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), count)
+	})
+
+	t.Run("ranked", func(t *testing.T) {
+		for sort, expected := range map[string][]string{
+			ranking.Top:           {old.Id, fresh.Id},
+			ranking.Hot:           {fresh.Id, old.Id},
+			ranking.New:           {fresh.Id, old.Id},
+			ranking.Rising:        {fresh.Id},
+			ranking.Controversial: {fresh.Id, old.Id},
+		} {
+			s, err := ranking.Get(sort, "")
+			require.NoError(t, err)
+
+			filter := append(bson.D{}, organic...)
+			posts, err := repo.FindRanked(ctx, append(filter, s.Match(time.Now())...), s, nil, &pagination.Query{Size: 10})
+			require.NoError(t, err)
+			require.Equal(t, expected, ids(posts), sort)
+		}
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		for _, sort := range []string{ranking.Top, ranking.Hot, ranking.New} {
+			s, _ := ranking.Get(sort, "")
+
+			first, err := repo.FindRanked(ctx, organic, s, nil, &pagination.Query{Size: 1})
+			require.NoError(t, err)
+			require.Len(t, first, 1)
+
+			after := &pagination.Cursor{Key: s.Key(first[0]), ID: first[0].Id}
+			next, err := repo.FindRanked(ctx, organic, s, after, &pagination.Query{Size: 10})
+			require.NoError(t, err)
+			require.Len(t, next, 1, sort)
+			require.NotEqual(t, first[0].Id, next[0].Id, sort)
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		filter := bson.D{{"$text", bson.D{{"$search", "golang"}}}, {"promoted", false}}
+
+		posts, err := repo.FindRanked(ctx, filter, ranking.Search(), nil, &pagination.Query{Size: 10})
+		require.NoError(t, err)
+		require.Equal(t, []string{fresh.Id}, ids(posts))
+		require.Greater(t, posts[0].Rank, 0.0)
+	})
+
+	t.Run("sample", func(t *testing.T) {
+		posts, err := repo.Aggregate(ctx, bson.D{{"$match", bson.D{{"promoted", true}}}}, bson.D{{"$sample", bson.D{{"size", 2}}}})
+		require.NoError(t, err)
+		require.Equal(t, []string{promoted.Id}, ids(posts))
+	})
+
+	t.Run("counters", func(t *testing.T) {
+		updated, err := repo.IncrementScore(ctx, objectID(fresh), 2, 3, 1)
+		require.NoError(t, err)
+		require.Equal(t, 12, *updated.Score)
+		require.Equal(t, 3, updated.Ups)
+
+		require.NoError(t, repo.IncrementCommentCount(ctx, objectID(fresh), 1))
+
+		found, err := repo.FindByID(ctx, objectID(fresh))
+		require.NoError(t, err)
+		require.Equal(t, 1, found.CommentCount)
+		require.Equal(t, fresh.CreatedAt, found.CreatedAt)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		edit := *old
+		edit.Content = ""
+		edit.Link = "https://go.dev"
+
+		updated, err := repo.Update(ctx, objectID(old), &edit)
+		require.NoError(t, err)
+		require.Equal(t, "https://go.dev", updated.Link)
+		require.Empty(t, updated.Content)
+	})
+
+	t.Run("create many", func(t *testing.T) {
+		score, flag := 1, false
+		posts := []*models.Post{
+			{Title: "first", Subreddit: "/r/golang", Score: &score, Promoted: &flag, NSFW: &flag},
+			{Id: fresh.Id, Title: "same id", Subreddit: "/r/golang", Score: &score, Promoted: &flag, NSFW: &flag},
+		}
+
+		failed, err := repo.CreateMany(ctx, posts)
+		require.NoError(t, err)
+		require.Len(t, failed, 1)
+		require.Contains(t, failed, 1)
+		require.NotEmpty(t, posts[0].Id)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, objectID(promoted)))
+
+		_, err := repo.FindByID(ctx, objectID(promoted))
+		require.Error(t, err)
+	})
+}
+
+func TestPostgresVoteRepo(t *testing.T) {
+	requirePool(t)
+
+	repo := repository.NewPostgresVoteRepository(logr.NewFactory(logr.Mock, "test"), pool)
+	ctx := context.Background()
+	post := primitive.NewObjectID()
+
+	for _, step := range []struct {
+		direction, previous int
+	}{
+		{1, 0},
+		{1, 1},
+		{-1, 1},
+		{0, -1},
+		{1, 0},
+	} {
+		previous, err := repo.Upsert(ctx, "t2_voter", post, step.direction)
+		require.NoError(t, err)
+		require.Equal(t, step.previous, previous)
+	}
+
+	t.Run("concurrent first votes", func(t *testing.T) {
+		other := primitive.NewObjectID()
+
+		previous := make([]int, 2)
+		errs := make([]error, 2)
+
+		var wg sync.WaitGroup
+		for i := range previous {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				previous[i], errs[i] = repo.Upsert(ctx, "t2_voter", other, 1)
+			}(i)
+		}
+		wg.Wait()
+
+		require.NoError(t, errs[0])
+		require.NoError(t, errs[1])
+		// the second vote waits for the first one and reads it, so the vote is counted once
+		require.ElementsMatch(t, []int{0, 1}, previous)
+	})
+}
+
+func TestPostgresAuditRepo(t *testing.T) {
+	requirePool(t)
+
+	repo := repository.NewPostgresAuditRepository(logr.NewFactory(logr.Mock, "test"), pool)
+	ctx := context.Background()
+	post := primitive.NewObjectID()
+	now := time.Now().UTC()
+
+	require.NoError(t, repo.Create(ctx, &models.AuditEntry{Post: post, Actor: "t2_mod", From: models.StatusActive,
+		To: models.StatusLocked, CreatedAt: now}))
+	require.NoError(t, repo.Create(ctx, &models.AuditEntry{Post: post, Actor: "t2_mod", From: models.StatusLocked,
+		To: models.StatusRemovedByMod, Reason: "spam", CreatedAt: now.Add(time.Second)}))
+	require.NoError(t, repo.Create(ctx, &models.AuditEntry{Post: primitive.NewObjectID(), Actor: "t2_mod",
+		From: models.StatusActive, To: models.StatusLocked, CreatedAt: now}))
+
+	entries, err := repo.FindByPost(ctx, post)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, models.StatusLocked, entries[0].To)
+	require.Empty(t, entries[0].Reason)
+	require.Equal(t, "spam", entries[1].Reason)
+	require.Equal(t, post, entries[1].Post)
+}
+
+func TestPostgresIdempotencyRepo(t *testing.T) {
+	requirePool(t)
+
+	repo := repository.NewPostgresIdempotencyRepository(logr.NewFactory(logr.Mock, "test"), pool, time.Hour)
+	ctx := context.Background()
+
+	stored, err := repo.Reserve(ctx, &models.IdempotencyKey{Id: "t2_user:key", BodyHash: "hash"})
+	require.NoError(t, err)
+	require.Nil(t, stored)
+
+	t.Run("in progress", func(t *testing.T) {
+		stored, err := repo.Reserve(ctx, &models.IdempotencyKey{Id: "t2_user:key", BodyHash: "other"})
+		require.NoError(t, err)
+		require.Equal(t, "hash", stored.BodyHash)
+		require.Empty(t, stored.PostId)
+	})
+
+	t.Run("completed", func(t *testing.T) {
+		require.NoError(t, repo.Complete(ctx, "t2_user:key", "post"))
+
+		stored, err := repo.Reserve(ctx, &models.IdempotencyKey{Id: "t2_user:key", BodyHash: "hash"})
+		require.NoError(t, err)
+		require.Equal(t, "post", stored.PostId)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		_, err := pool.Exec(ctx, `UPDATE post_idempotency_keys SET expires_at = $1`, time.Now().Add(-time.Second))
+		require.NoError(t, err)
+
+		stored, err := repo.Reserve(ctx, &models.IdempotencyKey{Id: "t2_user:key", BodyHash: "new"})
+		require.NoError(t, err)
+		require.Nil(t, stored)
+	})
+
+	t.Run("released", func(t *testing.T) {
+		require.NoError(t, repo.Release(ctx, "t2_user:key"))
+
+		stored, err := repo.Reserve(ctx, &models.IdempotencyKey{Id: "t2_user:key", BodyHash: "hash"})
+		require.NoError(t, err)
+		require.Nil(t, stored)
+	})
+}
+
+func TestPostgresCommentsRepo(t *testing.T) {
+	requirePool(t)
+
+	repo := commentsRepository.NewPostgres(logr.NewFactory(logr.Mock, "test"), pool)
+	ctx := context.Background()
+	post := primitive.NewObjectID().Hex()
+	now := time.Now().UTC()
+
+	newComment := func(body string, score int, age time.Duration, parent *commentsModels.Comment) *commentsModels.Comment {
+		m := &commentsModels.Comment{PostId: post, Author: "t2_user", Body: body, Score: score, CreatedAt: now.Add(-age)}
+		if parent != nil {
+			m.ReplyTo(parent)
+		}
+		c, err := repo.Create(ctx, m)
+		require.NoError(t, err)
+		return c
+	}
+
+	old := newComment("old", 5, time.Hour, nil)
+	fresh := newComment("fresh", 1, time.Minute, nil)
+	reply := newComment("reply", 0, 0, old)
+
+	ids := func(comments []*commentsModels.Comment) []string {
+		result := make([]string, 0, len(comments))
+		for _, c := range comments {
+			result = append(result, c.Id)
+		}
+		return result
+	}
+
+	topLevel := bson.D{{"post_id", post}, {"depth", 0}}
+
+	t.Run("find", func(t *testing.T) {
+		id, err := primitive.ObjectIDFromHex(reply.Id)
+		require.NoError(t, err)
+
+		found, err := repo.FindByID(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, old.Id, found.ParentId)
+		require.Equal(t, old.Id+",", found.Path)
+		require.Equal(t, 1, found.Depth)
+
+		_, err = repo.FindByID(ctx, primitive.NewObjectID())
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		count, err := repo.CountDocuments(ctx, topLevel)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), count)
+	})
+
+	t.Run("sorted", func(t *testing.T) {
+		for sort, expected := range map[string][]string{
+			commentsModels.SortTop: {old.Id, fresh.Id},
+			commentsModels.SortNew: {fresh.Id, old.Id},
+			commentsModels.SortOld: {old.Id, fresh.Id},
+		} {
+			comments, err := repo.FindAll(ctx, topLevel, sort, &pagination.Query{Page: 1, Size: 10})
+			require.NoError(t, err)
+			require.Equal(t, expected, ids(comments), sort)
+		}
+
+		comments, err := repo.FindAll(ctx, topLevel, commentsModels.SortTop, &pagination.Query{Page: 2, Size: 1})
+		require.NoError(t, err)
+		require.Equal(t, []string{fresh.Id}, ids(comments))
+	})
+
+	t.Run("replies", func(t *testing.T) {
+		comments, err := repo.FindAll(ctx, bson.D{{"parent_id", old.Id}}, commentsModels.SortNew, &pagination.Query{Page: 1, Size: 10})
+		require.NoError(t, err)
+		require.Equal(t, []string{reply.Id}, ids(comments))
+	})
+}
+
+func TestPostgresSubredditsRepo(t *testing.T) {
+	requirePool(t)
+
+	repo := subredditsRepository.NewPostgres(logr.NewFactory(logr.Mock, "test"), pool)
+	ctx := context.Background()
+	nsfw := false
+
+	for _, name := range []string{"/r/golang", "/r/Rust", "/r/algorithms"} {
+		_, err := repo.Create(ctx, &subredditsModels.Subreddit{Name: name, NSFW: &nsfw, CreatedAt: time.Now().UTC()})
+		require.NoError(t, err)
+	}
+
+	t.Run("conflict", func(t *testing.T) {
+		_, err := repo.Create(ctx, &subredditsModels.Subreddit{Name: "/r/golang", NSFW: &nsfw, CreatedAt: time.Now().UTC()})
+		require.Equal(t, customErrors.New(http.StatusConflict, customErrors.Conflict), err)
+	})
+
+	t.Run("find", func(t *testing.T) {
+		found, err := repo.FindByName(ctx, "/r/golang")
+		require.NoError(t, err)
+		require.Equal(t, "/r/golang", found.Name)
+		require.NotEmpty(t, found.Id)
+
+		_, err = repo.FindByName(ctx, "/r/unknown")
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		count, err := repo.CountDocuments(ctx, bson.D{})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), count)
+
+		// names are ordered by their bytes like in mongo, upper case first
+		subreddits, err := repo.FindAll(ctx, bson.D{}, &pagination.Query{Page: 1, Size: 2})
+		require.NoError(t, err)
+		require.Len(t, subreddits, 2)
+		require.Equal(t, "/r/Rust", subreddits[0].Name)
+		require.Equal(t, "/r/algorithms", subreddits[1].Name)
+	})
+}
+
+func TestPostgresUsersRepo(t *testing.T) {
+	requirePool(t)
+
+	repo := usersRepository.NewPostgres(logr.NewFactory(logr.Mock, "test"), pool)
+	ctx := context.Background()
+
+	user, err := repo.Create(ctx, &usersModels.User{Id: usersModels.NewID(), Username: "gopher", Password: "hash", CreatedAt: time.Now().UTC()})
+	require.NoError(t, err)
+
+	_, err = repo.Create(ctx, &usersModels.User{Id: usersModels.NewID(), Username: "gopher", Password: "hash", CreatedAt: time.Now().UTC()})
+	require.Equal(t, customErrors.New(http.StatusConflict, customErrors.Conflict), err)
+
+	found, err := repo.FindByUsername(ctx, "gopher")
+	require.NoError(t, err)
+	require.Equal(t, user.Id, found.Id)
+	require.Equal(t, "hash", found.Password)
+
+	found, err = repo.FindByID(ctx, user.Id)
+	require.NoError(t, err)
+	require.Equal(t, "gopher", found.Username)
+
+	_, err = repo.FindByID(ctx, "t2_missing")
+	require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+}
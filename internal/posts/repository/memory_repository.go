@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/driver/db"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+// memoryIndexes - the text index of the posts searched by $text queries, see the migrations
+var memoryIndexes = []db.Index{{Keys: []string{"title", "content"}, Text: true}}
+
+// memoryRepo - posts kept in memory, for local development and tests without mongo. Queries run on an in-memory
+// collection, the ranks mongo computes in the aggregation pipeline are computed in Go.
+type memoryRepo struct {
+	*repo
+}
+
+func NewMemory(logger *log.Factory) *memoryRepo {
+	return &memoryRepo{
+		repo: New(logger, db.NewMemory(memoryIndexes...)),
+	}
+}
+
+func (r *memoryRepo) FindRanked(ctx context.Context, filter bson.D, strategy ranking.Strategy, after *pagination.Cursor, query *pagination.Query) ([]*models.Post, error) {
+	var posts []*models.Post
+
+	if err := r.collection.Find(ctx, visible(filter), &posts); err != nil {
+		r.logger.For(ctx).Error("FindRanked.Find", zap.String("err", err.Error()), zap.String("sort", strategy.Name()))
+		return nil, errors.Wrap(err, "FindRanked.Find")
+	}
+
+	search := textSearch(filter)
+	now := time.Now()
+
+	for _, p := range posts {
+		if search != "" {
+			p.Rank = db.TextScore(search, p.Title, p.Content)
+		}
+		p.Rank = strategy.Rank(p, now)
+	}
+
+	// ids are hex of the same length, their strings sort like the ids
+	sort.SliceStable(posts, func(i, j int) bool {
+		ki, kj := strategy.Key(posts[i]), strategy.Key(posts[j])
+		if ki != kj {
+			return ki > kj
+		}
+		return posts[i].Id > posts[j].Id
+	})
+
+	if after != nil {
+		id := after.ObjectID().Hex()
+
+		start := len(posts)
+		for i, p := range posts {
+			if key := strategy.Key(p); key < after.Key || key == after.Key && p.Id < id {
+				start = i
+				break
+			}
+		}
+		posts = posts[start:]
+	} else if offset := query.GetOffset(); offset > 0 {
+		if offset > len(posts) {
+			offset = len(posts)
+		}
+		posts = posts[offset:]
+	}
+
+	if size := query.GetSize(); size < len(posts) {
+		posts = posts[:size]
+	}
+
+	return posts, nil
+}
+
+// textSearch - the search of the $text query of the filter, empty without one.
+func textSearch(filter bson.D) string {
+	for _, e := range filter {
+		if e.Key != "$text" {
+			continue
+		}
+		if text, ok := e.Value.(bson.D); ok {
+			for _, arg := range text {
+				if arg.Key == "$search" {
+					search, _ := arg.Value.(string)
+					return search
+				}
+			}
+		}
+	}
+	return ""
+}
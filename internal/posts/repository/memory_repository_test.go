@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryRepo(t *testing.T) {
+
+	repo := NewMemory(logr.NewFactory(logr.Mock, "test"))
+	ctx := context.Background()
+
+	newPost := func(title string, score int, promoted bool, age time.Duration) *models.Post {
+		nsfw := false
+		p, err := repo.Create(ctx, &models.Post{
+			Title: title, Subreddit: "/r/golang", Content: title, Score: &score, Promoted: &promoted, NSFW: &nsfw,
+			CreatedAt: time.Now().UTC().Add(-age),
+		})
+		require.NoError(t, err)
+		return p
+	}
+
+	old := newPost("old but popular", 100, false, time.Hour*24*3)
+	fresh := newPost("fresh golang news", 10, false, time.Minute)
+	hidden := newPost("removed golang post", 50, false, time.Minute)
+	newPost("promoted golang course", 1000, true, time.Minute)
+
+	id, err := primitive.ObjectIDFromHex(hidden.Id)
+	require.NoError(t, err)
+	_, err = repo.SetStatus(ctx, id, models.StatusRemovedByMod)
+	require.NoError(t, err)
+
+	organic := bson.D{{"promoted", false}}
+
+	ids := func(posts []*models.Post) []string {
+		result := make([]string, 0, len(posts))
+		for _, p := range posts {
+			result = append(result, p.Id)
+		}
+		return result
+	}
+
+	t.Run("count", func(t *testing.T) {
+		count, err := repo.CountDocuments(ctx, organic)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), count)
+	})
+
+	t.Run("ranked", func(t *testing.T) {
+		top, _ := ranking.Get(ranking.Top, "")
+		hot, _ := ranking.Get(ranking.Hot, "")
+
+		posts, err := repo.FindRanked(ctx, organic, top, nil, &pagination.Query{Page: 1, Size: 10})
+		require.NoError(t, err)
+		require.Equal(t, []string{old.Id, fresh.Id}, ids(posts))
+
+		// a day of age is worth about two orders of magnitude of score
+		posts, err = repo.FindRanked(ctx, organic, hot, nil, &pagination.Query{Page: 1, Size: 10})
+		require.NoError(t, err)
+		require.Equal(t, []string{fresh.Id, old.Id}, ids(posts))
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		top, _ := ranking.Get(ranking.Top, "")
+
+		posts, err := repo.FindRanked(ctx, organic, top, nil, &pagination.Query{Page: 2, Size: 1})
+		require.NoError(t, err)
+		require.Equal(t, []string{fresh.Id}, ids(posts))
+
+		after := &pagination.Cursor{Sort: top.Name(), Key: top.Key(old), ID: old.Id}
+		posts, err = repo.FindRanked(ctx, organic, top, after, &pagination.Query{Size: 1, Cursor: "set"})
+		require.NoError(t, err)
+		require.Equal(t, []string{fresh.Id}, ids(posts))
+	})
+
+	t.Run("legacy posts", func(t *testing.T) {
+		legacy := NewMemory(logr.NewFactory(logr.Mock, "test"))
+		newest, _ := ranking.Get(ranking.New, "")
+
+		// posts stored before created_at was introduced, ordered by the time of their ids
+		var created []*models.Post
+		for _, at := range []time.Time{time.Now().Add(-time.Hour * 2), time.Now().Add(-time.Hour)} {
+			p, err := legacy.Create(ctx, &models.Post{Id: primitive.NewObjectIDFromTimestamp(at).Hex(), Title: "legacy", Subreddit: "/r/golang"})
+			require.NoError(t, err)
+			created = append(created, p)
+		}
+
+		posts, err := legacy.FindRanked(ctx, bson.D{}, newest, nil, &pagination.Query{Page: 1, Size: 1})
+		require.NoError(t, err)
+		require.Equal(t, []string{created[1].Id}, ids(posts))
+
+		after := &pagination.Cursor{Sort: newest.Name(), Key: newest.Key(posts[0]), ID: posts[0].Id}
+		posts, err = legacy.FindRanked(ctx, bson.D{}, newest, after, &pagination.Query{Size: 1, Cursor: "set"})
+		require.NoError(t, err)
+		require.Equal(t, []string{created[0].Id}, ids(posts))
+	})
+
+	t.Run("search", func(t *testing.T) {
+		posts, err := repo.FindRanked(ctx, bson.D{{"$text", bson.D{{"$search", "golang"}}}}, ranking.Search(), nil, &pagination.Query{Page: 1, Size: 10})
+		require.NoError(t, err)
+		// the promoted post is not filtered out here, the use case does that
+		require.Len(t, posts, 2)
+	})
+
+	t.Run("sample", func(t *testing.T) {
+		posts, err := repo.Aggregate(ctx, bson.D{{"$match", bson.D{{"promoted", true}}}}, bson.D{{"$sample", bson.D{{"size", 2}}}})
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		require.True(t, *posts[0].Promoted)
+	})
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: vote_repository.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MockVoteRepository is a mock of VoteRepository interface.
+type MockVoteRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockVoteRepositoryMockRecorder
+}
+
+// MockVoteRepositoryMockRecorder is the mock recorder for MockVoteRepository.
+type MockVoteRepositoryMockRecorder struct {
+	mock *MockVoteRepository
+}
+
+// NewMockVoteRepository creates a new mock instance.
+func NewMockVoteRepository(ctrl *gomock.Controller) *MockVoteRepository {
+	mock := &MockVoteRepository{ctrl: ctrl}
+	mock.recorder = &MockVoteRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVoteRepository) EXPECT() *MockVoteRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Upsert mocks base method.
+func (m *MockVoteRepository) Upsert(ctx context.Context, user string, post primitive.ObjectID, direction int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, user, post, direction)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockVoteRepositoryMockRecorder) Upsert(ctx, user, post, direction interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockVoteRepository)(nil).Upsert), ctx, user, post, direction)
+}
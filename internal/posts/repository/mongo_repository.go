@@ -1,3 +1,4 @@
+//go:generate mockgen -source mongo_repository.go -destination mock/repository_mock.go -package mock
 package repository
 
 import (
@@ -5,11 +6,16 @@ import (
 	"github.com/aliykh/log"
 	"github.com/aliykh/reddit-feed/internal/driver/db"
 	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
 	"github.com/aliykh/reddit-feed/pkg/pagination"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
+	"net/http"
 	"time"
 )
 
@@ -19,9 +25,20 @@ const (
 
 type Repository interface {
 	Create(context.Context, *models.Post) (*models.Post, error)
+	// CreateMany - inserts the posts unordered and sets the ids of the inserted ones. Posts failing to insert
+	// are reported by their index, the error is set when the batch failed as a whole
+	CreateMany(ctx context.Context, posts []*models.Post) (map[int]error, error)
 	CountDocuments(ctx context.Context, filter bson.D) (int64, error)
 	FindAll(ctx context.Context, filter bson.D, query *pagination.Query) ([]*models.Post, error)
+	FindRanked(ctx context.Context, filter bson.D, strategy ranking.Strategy, after *pagination.Cursor, query *pagination.Query) ([]*models.Post, error)
 	Aggregate(ctx context.Context, stages ...bson.D) ([]*models.Post, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error)
+	Update(ctx context.Context, id primitive.ObjectID, m *models.Post) (*models.Post, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	IncrementScore(ctx context.Context, id primitive.ObjectID, score, ups, downs int) (*models.Post, error)
+	IncrementCommentCount(ctx context.Context, id primitive.ObjectID, delta int) error
+	// SetStatus - sets the lifecycle status of the post and returns the updated post
+	SetStatus(ctx context.Context, id primitive.ObjectID, status string) (*models.Post, error)
 }
 
 type repo struct {
@@ -59,15 +76,49 @@ func (r *repo) Create(ctx context.Context, m *models.Post) (*models.Post, error)
 	return result, nil
 }
 
+func (r *repo) CreateMany(ctx context.Context, posts []*models.Post) (map[int]error, error) {
+
+	documents := make([]interface{}, len(posts))
+	for i, m := range posts {
+		documents[i] = m
+	}
+
+	failed := make(map[int]error)
+
+	// unordered, a failed post does not stop the rest of the batch
+	res, err := r.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+
+	var bulkErr mongo.BulkWriteException
+	switch {
+	case errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil:
+		for _, writeErr := range bulkErr.WriteErrors {
+			failed[writeErr.Index] = writeErr.WriteError
+		}
+	case err != nil:
+		return nil, errors.Wrap(err, "PostMongoRepo.CreateMany.InsertMany")
+	}
+
+	for i, id := range res.InsertedIDs {
+		if _, ok := failed[i]; ok {
+			continue
+		}
+		if oid, ok := id.(primitive.ObjectID); ok {
+			posts[i].Id = oid.Hex()
+		}
+	}
+
+	return failed, nil
+}
+
 func (r *repo) CountDocuments(ctx context.Context, filter bson.D) (int64, error) {
 
 	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
 	defer cancel()
 
-	totalCount, err := r.collection.CountDocuments(ctx, filter)
+	totalCount, err := r.collection.CountDocuments(ctx, visible(filter))
 
 	if err != nil {
-		r.logger.Default().Error("Generate feeds count docs", zap.String("err", err.Error()))
+		r.logger.For(ctx).Error("Generate feeds count docs", zap.String("err", err.Error()))
 		return 0, errors.Wrap(err, "GenerateFeeds.CountDocuments")
 	}
 
@@ -78,31 +129,197 @@ func (r *repo) FindAll(ctx context.Context, filter bson.D, query *pagination.Que
 
 	result := make([]*models.Post, 0, query.GetSize()+2) // max 27
 
-	opts := options.Find().SetSort(bson.D{{"score", -1}}).SetSkip(int64(query.GetOffset())).SetLimit(int64(query.GetSize()))
+	// _id breaks ties between equal scores, so the order is stable across pages
+	opts := options.Find().SetSort(bson.D{{"score", -1}, {"_id", -1}}).SetSkip(int64(query.GetOffset())).SetLimit(int64(query.GetSize()))
 
 	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
 	defer cancel()
 
-	err := r.collection.Find(ctx, filter, &result, opts)
+	err := r.collection.Find(ctx, visible(filter), &result, opts)
 
 	if err != nil {
-		r.logger.Default().Error("FindAll.Find", zap.String("err", err.Error()))
+		r.logger.For(ctx).Error("FindAll.Find", zap.String("err", err.Error()))
 		return nil, errors.Wrap(err, "FindAll.Find")
 	}
 
 	return result, nil
 }
 
+// FindRanked - returns one page of posts matching the filter in the order of the ranking strategy.
+func (r *repo) FindRanked(ctx context.Context, filter bson.D, strategy ranking.Strategy, after *pagination.Cursor, query *pagination.Query) ([]*models.Post, error) {
+	result := make([]*models.Post, 0, query.GetSize()+2)
+
+	pipeline := ranking.Pipeline(strategy, visible(filter), after, query, time.Now())
+
+	err := r.collection.Aggregate(ctx, pipeline, &result)
+	if err != nil {
+		r.logger.For(ctx).Error("FindRanked.Aggregate", zap.String("err", err.Error()), zap.String("sort", strategy.Name()))
+		return nil, errors.Wrap(err, "FindRanked.Aggregate")
+	}
+
+	return result, nil
+}
+
 func (r *repo) Aggregate(ctx context.Context, stages ...bson.D) ([]*models.Post, error) {
 
 	var result []*models.Post
 
-	err := r.collection.Aggregate(ctx, stages, &result)
+	err := r.collection.Aggregate(ctx, visibleStages(stages), &result)
 
 	if err != nil {
-		r.logger.Default().Error("Aggreegate.Collections.GetPosts", zap.String("err", err.Error()))
+		r.logger.For(ctx).Error("Aggreegate.Collections.GetPosts", zap.String("err", err.Error()))
 		return nil, errors.Wrap(err, "Aggreegate.GetPosts")
 	}
 
 	return result, nil
 }
+
+func (r *repo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	result := &models.Post{}
+	if err := r.collection.FindOne(ctx, bson.D{{"_id", id}}, result); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		}
+		r.logger.For(ctx).Error("FindByID.FindOne", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "FindByID.FindOne")
+	}
+
+	return result, nil
+}
+
+// Update - replaces the editable fields of the post, the author and the score are kept as they are.
+func (r *repo) Update(ctx context.Context, id primitive.ObjectID, m *models.Post) (*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	set := bson.D{
+		{"title", m.Title},
+		{"subreddit", m.Subreddit},
+		{"promoted", m.Promoted},
+		{"nsfw", m.NSFW},
+	}
+	unset := bson.D{}
+
+	// link and content are mutually exclusive, so the empty one must be removed from the document
+	if m.Link != "" {
+		set = append(set, bson.E{"link", m.Link})
+		unset = append(unset, bson.E{"content", ""})
+	} else {
+		set = append(set, bson.E{"content", m.Content})
+		unset = append(unset, bson.E{"link", ""})
+	}
+
+	update := bson.D{{"$set", set}, {"$unset", unset}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	result := &models.Post{}
+	if err := r.collection.FindOneAndUpdate(ctx, bson.D{{"_id", id}}, update, result, opts); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		}
+		r.logger.For(ctx).Error("Update.FindOneAndUpdate", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Update.FindOneAndUpdate")
+	}
+
+	return result, nil
+}
+
+func (r *repo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	res, err := r.collection.DeleteOne(ctx, bson.D{{"_id", id}})
+	if err != nil {
+		r.logger.For(ctx).Error("Delete.DeleteOne", zap.String("err", err.Error()))
+		return errors.Wrap(err, "Delete.DeleteOne")
+	}
+
+	if res.DeletedCount == 0 {
+		return customErrors.New(http.StatusNotFound, customErrors.NotFound)
+	}
+
+	return nil
+}
+
+// IncrementScore - atomically adds the deltas to the score and vote counters of the post.
+func (r *repo) IncrementScore(ctx context.Context, id primitive.ObjectID, score, ups, downs int) (*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	update := bson.D{{"$inc", bson.D{{"score", score}, {"ups", ups}, {"downs", downs}}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	result := &models.Post{}
+	if err := r.collection.FindOneAndUpdate(ctx, bson.D{{"_id", id}}, update, result, opts); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		}
+		r.logger.For(ctx).Error("IncrementScore.FindOneAndUpdate", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "IncrementScore.FindOneAndUpdate")
+	}
+
+	return result, nil
+}
+
+func (r *repo) IncrementCommentCount(ctx context.Context, id primitive.ObjectID, delta int) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	res, err := r.collection.UpdateOne(ctx, bson.D{{"_id", id}}, bson.D{{"$inc", bson.D{{"comment_count", delta}}}})
+	if err != nil {
+		r.logger.For(ctx).Error("IncrementCommentCount.UpdateOne", zap.String("err", err.Error()))
+		return errors.Wrap(err, "IncrementCommentCount.UpdateOne")
+	}
+
+	if res.MatchedCount == 0 {
+		return customErrors.New(http.StatusNotFound, customErrors.NotFound)
+	}
+
+	return nil
+}
+
+func (r *repo) SetStatus(ctx context.Context, id primitive.ObjectID, status string) (*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	update := bson.D{{"$set", bson.D{{"status", status}}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	result := &models.Post{}
+	if err := r.collection.FindOneAndUpdate(ctx, bson.D{{"_id", id}}, update, result, opts); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		}
+		r.logger.For(ctx).Error("SetStatus.FindOneAndUpdate", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "SetStatus.FindOneAndUpdate")
+	}
+
+	return result, nil
+}
+
+// visible - the filter restricted to the visible posts, posts without a status are active.
+// The filter of the caller is copied, it may be reused for further queries.
+func visible(filter bson.D) bson.D {
+	result := make(bson.D, 0, len(filter)+1)
+	result = append(result, filter...)
+	return append(result, bson.E{"status", bson.D{{"$nin", models.HiddenStatuses}}})
+}
+
+// visibleStages - restricts the pipeline to the visible posts, in its leading $match when there is one,
+// since a $text query has to stay in the first stage.
+func visibleStages(stages []bson.D) []bson.D {
+	result := make([]bson.D, 0, len(stages)+1)
+
+	if len(stages) > 0 && len(stages[0]) == 1 && stages[0][0].Key == "$match" {
+		if filter, ok := stages[0][0].Value.(bson.D); ok {
+			result = append(result, bson.D{{"$match", visible(filter)}})
+			return append(result, stages[1:]...)
+		}
+	}
+
+	result = append(result, bson.D{{"$match", visible(nil)}})
+	return append(result, stages...)
+}
@@ -6,6 +6,8 @@ import (
 	logr "github.com/aliykh/log"
 	"github.com/aliykh/reddit-feed/internal/driver/db/mock"
 	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
 	"github.com/aliykh/reddit-feed/pkg/pagination"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -13,9 +15,14 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"net/http"
 	"testing"
+	"time"
 )
 
+// notHidden - condition the repository adds to every listing query
+var notHidden = bson.E{"status", bson.D{{"$nin", []string{"deleted_by_author", "removed_by_mod"}}}}
+
 // posts - for testing purposes
 var posts = []*models.Post{
 	{
@@ -97,6 +104,64 @@ func TestRepo_Create(t *testing.T) {
 
 }
 
+func TestRepo_CreateMany(t *testing.T) {
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	newBatch := func() []*models.Post {
+		return []*models.Post{{Title: "title1"}, {Title: "title2"}, {Title: "title3"}}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		batch := newBatch()
+		ids := []interface{}{primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()}
+
+		coll.EXPECT().InsertMany(gomock.Any(), []interface{}{batch[0], batch[1], batch[2]}, options.InsertMany().SetOrdered(false)).
+			Return(&mongo.InsertManyResult{InsertedIDs: ids}, nil)
+
+		failed, err := repo.CreateMany(context.Background(), batch)
+
+		require.NoError(t, err)
+		require.Empty(t, failed)
+		for i, m := range batch {
+			require.Equal(t, ids[i].(primitive.ObjectID).Hex(), m.Id)
+		}
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		batch := newBatch()
+		ids := []interface{}{primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()}
+
+		writeErr := mongo.WriteError{Index: 1, Code: 11000, Message: "duplicate key"}
+		coll.EXPECT().InsertMany(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&mongo.InsertManyResult{InsertedIDs: ids}, mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{{WriteError: writeErr}}})
+
+		failed, err := repo.CreateMany(context.Background(), batch)
+
+		require.NoError(t, err)
+		require.Equal(t, map[int]error{1: writeErr}, failed)
+		require.Equal(t, ids[0].(primitive.ObjectID).Hex(), batch[0].Id)
+		require.Empty(t, batch[1].Id)
+		require.Equal(t, ids[2].(primitive.ObjectID).Hex(), batch[2].Id)
+	})
+
+	t.Run("fails", func(t *testing.T) {
+		coll.EXPECT().InsertMany(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, mongo.ErrClientDisconnected)
+
+		_, err := repo.CreateMany(context.Background(), newBatch())
+
+		require.True(t, errors.Is(err, mongo.ErrClientDisconnected))
+	})
+}
+
 func TestRepo_CountDocuments(t *testing.T) {
 
 	var logger = logr.NewFactory(logr.Mock, "test")
@@ -114,7 +179,7 @@ func TestRepo_CountDocuments(t *testing.T) {
 
 		filter := bson.D{{"promoted", false}}
 
-		coll.EXPECT().CountDocuments(gomock.Any(), gomock.Eq(filter)).Return(int64(20), nil)
+		coll.EXPECT().CountDocuments(gomock.Any(), gomock.Eq(bson.D{{"promoted", false}, notHidden})).Return(int64(20), nil)
 
 		c, err := repo.CountDocuments(context.Background(), filter)
 
@@ -127,7 +192,7 @@ func TestRepo_CountDocuments(t *testing.T) {
 
 		filter := bson.D{{"promoted", false}}
 
-		coll.EXPECT().CountDocuments(gomock.Any(), gomock.Eq(filter)).Return(int64(0), mongo.ErrNilDocument)
+		coll.EXPECT().CountDocuments(gomock.Any(), gomock.Eq(bson.D{{"promoted", false}, notHidden})).Return(int64(0), mongo.ErrNilDocument)
 
 		c, err := repo.CountDocuments(context.Background(), filter)
 
@@ -155,7 +220,9 @@ func TestRepo_Aggregate(t *testing.T) {
 		matchStage := bson.D{{"$match", bson.D{{"promoted", true}}}}
 		sampleStage := bson.D{{"$sample", bson.D{{"size", 2}}}}
 
-		coll.EXPECT().Aggregate(gomock.Any(), gomock.Eq(mongo.Pipeline([]bson.D{matchStage, sampleStage})), gomock.Any()).Return(nil).SetArg(2, posts)
+		visibleMatch := bson.D{{"$match", bson.D{{"promoted", true}, notHidden}}}
+
+		coll.EXPECT().Aggregate(gomock.Any(), gomock.Eq(mongo.Pipeline([]bson.D{visibleMatch, sampleStage})), gomock.Any()).Return(nil).SetArg(2, posts)
 
 		result, err := repo.Aggregate(context.Background(), matchStage, sampleStage)
 
@@ -168,7 +235,7 @@ func TestRepo_Aggregate(t *testing.T) {
 		matchStage := bson.D{{"$match", bson.D{{"promoted", true}}}}
 		sampleStage := bson.D{{"$sample", bson.D{{"size", 2}}}}
 
-		coll.EXPECT().Aggregate(gomock.Any(), gomock.Eq(mongo.Pipeline([]bson.D{matchStage, sampleStage})), gomock.Any()).Return(mongo.CommandError{})
+		coll.EXPECT().Aggregate(gomock.Any(), gomock.Any(), gomock.Any()).Return(mongo.CommandError{})
 		result, err := repo.Aggregate(context.Background(), matchStage, sampleStage)
 
 		require.Empty(t, result)
@@ -178,6 +245,41 @@ func TestRepo_Aggregate(t *testing.T) {
 
 }
 
+func TestRepo_Aggregate_Visible(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	t.Run("without a leading match", func(t *testing.T) {
+
+		sampleStage := bson.D{{"$sample", bson.D{{"size", 2}}}}
+		pipeline := mongo.Pipeline{{{"$match", bson.D{notHidden}}}, sampleStage}
+
+		coll.EXPECT().Aggregate(gomock.Any(), gomock.Eq(pipeline), gomock.Any()).Return(nil)
+
+		_, err := repo.Aggregate(context.Background(), sampleStage)
+		require.NoError(t, err)
+	})
+
+	t.Run("filter of the caller is kept", func(t *testing.T) {
+
+		filter := bson.D{{"promoted", true}}
+
+		coll.EXPECT().Aggregate(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		_, err := repo.Aggregate(context.Background(), bson.D{{"$match", filter}})
+		require.NoError(t, err)
+		require.Equal(t, bson.D{{"promoted", true}}, filter)
+	})
+
+}
+
 func TestRepo_FindAll(t *testing.T) {
 
 	var logger = logr.NewFactory(logr.Mock, "test")
@@ -199,9 +301,9 @@ func TestRepo_FindAll(t *testing.T) {
 		}
 
 		filter := bson.D{{"promoted", false}}
-		opts := options.Find().SetSort(bson.D{{"score", -1}}).SetSkip(int64(query.GetOffset())).SetLimit(int64(query.GetSize()))
+		opts := options.Find().SetSort(bson.D{{"score", -1}, {"_id", -1}}).SetSkip(int64(query.GetOffset())).SetLimit(int64(query.GetSize()))
 
-		coll.EXPECT().Find(gomock.Any(), gomock.Eq(filter), gomock.Eq(&[]*models.Post{}), opts).Return(nil).SetArg(2, posts)
+		coll.EXPECT().Find(gomock.Any(), gomock.Eq(bson.D{{"promoted", false}, notHidden}), gomock.Eq(&[]*models.Post{}), opts).Return(nil).SetArg(2, posts)
 
 		result, err := repo.FindAll(context.Background(), filter, query)
 
@@ -220,3 +322,288 @@ func TestRepo_FindAll(t *testing.T) {
 	})
 
 }
+
+func TestRepo_FindByID(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	id := primitive.NewObjectID()
+
+	t.Run("ok", func(t *testing.T) {
+
+		coll.EXPECT().FindOne(gomock.Any(), bson.D{{"_id", id}}, gomock.Eq(&models.Post{})).Return(nil).SetArg(2, *posts[0])
+
+		result, err := repo.FindByID(context.Background(), id)
+
+		require.NoError(t, err)
+		require.Equal(t, posts[0], result)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+
+		coll.EXPECT().FindOne(gomock.Any(), bson.D{{"_id", id}}, gomock.Any()).Return(mongo.ErrNoDocuments)
+
+		_, err := repo.FindByID(context.Background(), id)
+
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+	})
+
+}
+
+func TestRepo_Update(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	id := primitive.NewObjectID()
+
+	t.Run("ok", func(t *testing.T) {
+
+		p := &models.Post{
+			Title:     "title",
+			Content:   "content",
+			Subreddit: "/r/subreddit",
+			Score:     new(int),
+			Promoted:  new(bool),
+			NSFW:      new(bool),
+		}
+
+		update := bson.D{
+			{"$set", bson.D{
+				{"title", p.Title},
+				{"subreddit", p.Subreddit},
+				{"promoted", p.Promoted},
+				{"nsfw", p.NSFW},
+				{"content", p.Content},
+			}},
+			{"$unset", bson.D{{"link", ""}}},
+		}
+
+		coll.EXPECT().FindOneAndUpdate(gomock.Any(), bson.D{{"_id", id}}, gomock.Eq(update), gomock.Eq(&models.Post{}), gomock.Any()).Return(nil).SetArg(3, *p)
+
+		result, err := repo.Update(context.Background(), id, p)
+
+		require.NoError(t, err)
+		require.Equal(t, p, result)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+
+		coll.EXPECT().FindOneAndUpdate(gomock.Any(), bson.D{{"_id", id}}, gomock.Any(), gomock.Any(), gomock.Any()).Return(mongo.ErrNoDocuments)
+
+		_, err := repo.Update(context.Background(), id, posts[0])
+
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+	})
+
+}
+
+func TestRepo_Delete(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	id := primitive.NewObjectID()
+
+	t.Run("ok", func(t *testing.T) {
+
+		coll.EXPECT().DeleteOne(gomock.Any(), bson.D{{"_id", id}}).Return(&mongo.DeleteResult{DeletedCount: 1}, nil)
+
+		require.NoError(t, repo.Delete(context.Background(), id))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+
+		coll.EXPECT().DeleteOne(gomock.Any(), bson.D{{"_id", id}}).Return(&mongo.DeleteResult{DeletedCount: 0}, nil)
+
+		err := repo.Delete(context.Background(), id)
+
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+
+		coll.EXPECT().DeleteOne(gomock.Any(), bson.D{{"_id", id}}).Return(nil, mongo.CommandError{})
+
+		err := repo.Delete(context.Background(), id)
+
+		require.True(t, errors.As(err, &mongo.CommandError{}))
+	})
+
+}
+
+func TestRepo_FindRanked(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	strategy, _ := ranking.Get(ranking.Top, "")
+	filter := bson.D{{"promoted", false}}
+
+	t.Run("ok", func(t *testing.T) {
+
+		query := &pagination.Query{Size: 25}
+		pipeline := ranking.Pipeline(strategy, bson.D{{"promoted", false}, notHidden}, nil, query, time.Now())
+
+		coll.EXPECT().Aggregate(gomock.Any(), gomock.Eq(pipeline), gomock.Eq(&[]*models.Post{})).Return(nil).SetArg(2, posts)
+
+		result, err := repo.FindRanked(context.Background(), filter, strategy, nil, query)
+
+		require.NoError(t, err)
+		require.Equal(t, posts, result)
+	})
+
+	t.Run("error", func(t *testing.T) {
+
+		coll.EXPECT().Aggregate(gomock.Any(), gomock.Any(), gomock.Any()).Return(mongo.CommandError{})
+
+		result, err := repo.FindRanked(context.Background(), filter, strategy, nil, &pagination.Query{})
+
+		require.Empty(t, result)
+		require.True(t, errors.As(err, &mongo.CommandError{}))
+	})
+
+}
+
+func TestRepo_IncrementScore(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	id := primitive.NewObjectID()
+
+	t.Run("ok", func(t *testing.T) {
+
+		update := bson.D{{"$inc", bson.D{{"score", -2}, {"ups", -1}, {"downs", 1}}}}
+
+		coll.EXPECT().FindOneAndUpdate(gomock.Any(), bson.D{{"_id", id}}, gomock.Eq(update), gomock.Eq(&models.Post{}), gomock.Any()).Return(nil).SetArg(3, *posts[0])
+
+		result, err := repo.IncrementScore(context.Background(), id, -2, -1, 1)
+
+		require.NoError(t, err)
+		require.Equal(t, posts[0], result)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+
+		coll.EXPECT().FindOneAndUpdate(gomock.Any(), bson.D{{"_id", id}}, gomock.Any(), gomock.Any(), gomock.Any()).Return(mongo.ErrNoDocuments)
+
+		_, err := repo.IncrementScore(context.Background(), id, 1, 1, 0)
+
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+	})
+
+}
+
+func TestRepo_IncrementCommentCount(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	id := primitive.NewObjectID()
+	update := bson.D{{"$inc", bson.D{{"comment_count", 1}}}}
+
+	t.Run("ok", func(t *testing.T) {
+
+		coll.EXPECT().UpdateOne(gomock.Any(), bson.D{{"_id", id}}, gomock.Eq(update)).Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+
+		require.NoError(t, repo.IncrementCommentCount(context.Background(), id, 1))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+
+		coll.EXPECT().UpdateOne(gomock.Any(), bson.D{{"_id", id}}, gomock.Eq(update)).Return(&mongo.UpdateResult{}, nil)
+
+		err := repo.IncrementCommentCount(context.Background(), id, 1)
+
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+	})
+
+}
+
+func TestRepo_SetStatus(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	id := primitive.NewObjectID()
+
+	t.Run("ok", func(t *testing.T) {
+
+		update := bson.D{{"$set", bson.D{{"status", models.StatusLocked}}}}
+
+		coll.EXPECT().FindOneAndUpdate(gomock.Any(), bson.D{{"_id", id}}, gomock.Eq(update), gomock.Eq(&models.Post{}), gomock.Any()).Return(nil).SetArg(3, *posts[0])
+
+		result, err := repo.SetStatus(context.Background(), id, models.StatusLocked)
+
+		require.NoError(t, err)
+		require.Equal(t, posts[0], result)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+
+		coll.EXPECT().FindOneAndUpdate(gomock.Any(), bson.D{{"_id", id}}, gomock.Any(), gomock.Any(), gomock.Any()).Return(mongo.ErrNoDocuments)
+
+		_, err := repo.SetStatus(context.Background(), id, models.StatusLocked)
+
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+	})
+
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CountersObserver - notified of the score and comment count changes of the posts.
+type CountersObserver interface {
+	ScoreChanged(post string, score, ups, downs int)
+	CommentsChanged(post string, delta int)
+}
+
+// observedRepo - reports the counter changes made through the wrapped repository to the observer.
+type observedRepo struct {
+	Repository
+	observer CountersObserver
+}
+
+func NewObserved(repo Repository, observer CountersObserver) *observedRepo {
+	return &observedRepo{
+		Repository: repo,
+		observer:   observer,
+	}
+}
+
+func (r *observedRepo) IncrementScore(ctx context.Context, id primitive.ObjectID, score, ups, downs int) (*models.Post, error) {
+	result, err := r.Repository.IncrementScore(ctx, id, score, ups, downs)
+	if err != nil {
+		return nil, err
+	}
+
+	r.observer.ScoreChanged(id.Hex(), score, ups, downs)
+	return result, nil
+}
+
+func (r *observedRepo) IncrementCommentCount(ctx context.Context, id primitive.ObjectID, delta int) error {
+	if err := r.Repository.IncrementCommentCount(ctx, id, delta); err != nil {
+		return err
+	}
+
+	r.observer.CommentsChanged(id.Hex(), delta)
+	return nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	postsMock "github.com/aliykh/reddit-feed/internal/posts/repository/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type counters struct {
+	scores   []string
+	comments []string
+}
+
+func (c *counters) ScoreChanged(post string, score, ups, downs int) {
+	c.scores = append(c.scores, post)
+}
+
+func (c *counters) CommentsChanged(post string, delta int) {
+	c.comments = append(c.comments, post)
+}
+
+func TestObservedRepo(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inner := postsMock.NewMockRepository(ctrl)
+	observer := &counters{}
+
+	repo := NewObserved(inner, observer)
+
+	ctx := context.Background()
+	id := primitive.NewObjectID()
+
+	t.Run("score", func(t *testing.T) {
+		inner.EXPECT().IncrementScore(ctx, id, 1, 1, 0).Return(&models.Post{Id: id.Hex()}, nil)
+		inner.EXPECT().IncrementScore(ctx, id, -1, -1, 0).Return(nil, errors.New("failed"))
+
+		_, err := repo.IncrementScore(ctx, id, 1, 1, 0)
+		require.NoError(t, err)
+		_, err = repo.IncrementScore(ctx, id, -1, -1, 0)
+		require.Error(t, err)
+
+		require.Equal(t, []string{id.Hex()}, observer.scores)
+	})
+
+	t.Run("comments", func(t *testing.T) {
+		inner.EXPECT().IncrementCommentCount(ctx, id, 1).Return(nil)
+
+		require.NoError(t, repo.IncrementCommentCount(ctx, id, 1))
+		require.Equal(t, []string{id.Hex()}, observer.comments)
+	})
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"time"
+)
+
+// postgresAuditRepo - status changes kept in the post_audit table of postgres.
+type postgresAuditRepo struct {
+	logger *log.Factory
+	pool   *pgxpool.Pool
+}
+
+func NewPostgresAuditRepository(logger *log.Factory, pool *pgxpool.Pool) *postgresAuditRepo {
+	return &postgresAuditRepo{
+		logger: logger,
+		pool:   pool,
+	}
+}
+
+func (r *postgresAuditRepo) Create(ctx context.Context, entry *models.AuditEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	_, err := r.pool.Exec(ctx, `INSERT INTO post_audit (id, post, actor, from_status, to_status, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`, primitive.NewObjectID().Hex(), entry.Post.Hex(), entry.Actor, entry.From,
+		entry.To, nullString(entry.Reason), entry.CreatedAt)
+	if err != nil {
+		r.logger.For(ctx).Error("Audit.Create.Exec", zap.String("err", err.Error()))
+		return errors.Wrap(err, "Audit.Create.Exec")
+	}
+
+	return nil
+}
+
+func (r *postgresAuditRepo) FindByPost(ctx context.Context, post primitive.ObjectID) ([]*models.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, `SELECT id, actor, from_status, to_status, reason, created_at FROM post_audit
+		WHERE post = $1 ORDER BY created_at, id`, post.Hex())
+	if err != nil {
+		r.logger.For(ctx).Error("Audit.FindByPost.Query", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Audit.FindByPost.Query")
+	}
+	defer rows.Close()
+
+	result := make([]*models.AuditEntry, 0)
+	for rows.Next() {
+		entry := &models.AuditEntry{Post: post}
+
+		var reason *string
+		if err = rows.Scan(&entry.Id, &entry.Actor, &entry.From, &entry.To, &reason, &entry.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "Audit.FindByPost.Scan")
+		}
+		if reason != nil {
+			entry.Reason = *reason
+		}
+		entry.CreatedAt = entry.CreatedAt.UTC()
+
+		result = append(result, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.For(ctx).Error("Audit.FindByPost.Query", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Audit.FindByPost.Query")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+// postgresIdempotencyRepo - keys kept in the post_idempotency_keys table of postgres.
+type postgresIdempotencyRepo struct {
+	logger *log.Factory
+	pool   *pgxpool.Pool
+	ttl    time.Duration
+}
+
+// NewPostgresIdempotencyRepository - keys are kept ttl after their post is created. Postgres has no ttl index,
+// the expired keys are removed by the reservations.
+func NewPostgresIdempotencyRepository(logger *log.Factory, pool *pgxpool.Pool, ttl time.Duration) *postgresIdempotencyRepo {
+	return &postgresIdempotencyRepo{
+		logger: logger,
+		pool:   pool,
+		ttl:    ttl,
+	}
+}
+
+func (r *postgresIdempotencyRepo) Reserve(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	now := time.Now().UTC()
+	key.PostId = ""
+	key.ExpiresAt = now.Add(reservationTTL)
+
+	if _, err := r.pool.Exec(ctx, `DELETE FROM post_idempotency_keys WHERE expires_at <= $1`, now); err != nil {
+		r.logger.For(ctx).Error("Idempotency.Reserve.Delete", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Idempotency.Reserve.Delete")
+	}
+
+	var id string
+	err := r.pool.QueryRow(ctx, `INSERT INTO post_idempotency_keys (id, body_hash, post_id, expires_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO NOTHING RETURNING id`, key.Id, key.BodyHash, key.PostId, key.ExpiresAt).Scan(&id)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		r.logger.For(ctx).Error("Idempotency.Reserve.Insert", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Idempotency.Reserve.Insert")
+	}
+
+	result := &models.IdempotencyKey{}
+	err = r.pool.QueryRow(ctx, `SELECT id, body_hash, post_id, expires_at FROM post_idempotency_keys WHERE id = $1`,
+		key.Id).Scan(&result.Id, &result.BodyHash, &result.PostId, &result.ExpiresAt)
+	if err != nil {
+		r.logger.For(ctx).Error("Idempotency.Reserve.QueryRow", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Idempotency.Reserve.QueryRow")
+	}
+	result.ExpiresAt = result.ExpiresAt.UTC()
+
+	return result, nil
+}
+
+func (r *postgresIdempotencyRepo) Complete(ctx context.Context, id string, post string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	_, err := r.pool.Exec(ctx, `UPDATE post_idempotency_keys SET post_id = $2, expires_at = $3 WHERE id = $1`,
+		id, post, time.Now().UTC().Add(r.ttl))
+	if err != nil {
+		r.logger.For(ctx).Error("Idempotency.Complete.Exec", zap.String("err", err.Error()))
+		return errors.Wrap(err, "Idempotency.Complete.Exec")
+	}
+
+	return nil
+}
+
+func (r *postgresIdempotencyRepo) Release(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	if _, err := r.pool.Exec(ctx, `DELETE FROM post_idempotency_keys WHERE id = $1`, id); err != nil {
+		r.logger.For(ctx).Error("Idempotency.Release.Exec", zap.String("err", err.Error()))
+		return errors.Wrap(err, "Idempotency.Release.Exec")
+	}
+
+	return nil
+}
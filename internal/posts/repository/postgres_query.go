@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrUnsupportedQuery - a filter or stage the postgres repository cannot translate to sql
+var ErrUnsupportedQuery = errors.New("unsupported by the postgres storage")
+
+// postColumns - columns of the fields of a post, the filters may only refer to these fields
+var postColumns = map[string]string{
+	"_id":           "id",
+	"title":         "title",
+	"author":        "author",
+	"link":          "link",
+	"subreddit":     "subreddit",
+	"content":       "content",
+	"score":         "score",
+	"promoted":      "promoted",
+	"nsfw":          "nsfw",
+	"ups":           "ups",
+	"downs":         "downs",
+	"comment_count": "comment_count",
+	"created_at":    "created_at",
+	"status":        "status",
+}
+
+// sqlQuery - conditions translated from mongo filters and the arguments they refer to by position.
+type sqlQuery struct {
+	args []interface{}
+	// tsquery - the query of the $text condition, the relevance ranking scores the posts by it
+	tsquery string
+}
+
+// arg - adds the argument and returns its placeholder.
+func (q *sqlQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+// where - the conditions of the filter joined by AND, like the fields of a mongo filter.
+func (q *sqlQuery) where(filter bson.D) (string, error) {
+	if len(filter) == 0 {
+		return "TRUE", nil
+	}
+
+	conditions := make([]string, 0, len(filter))
+	for _, e := range filter {
+		condition, err := q.condition(e.Key, e.Value)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return strings.Join(conditions, " AND "), nil
+}
+
+func (q *sqlQuery) condition(key string, value interface{}) (string, error) {
+	switch key {
+	case "$and", "$or", "$nor":
+		return q.logical(key, value)
+	case "$text":
+		return q.text(value)
+	}
+
+	column, ok := postColumns[key]
+	if !ok {
+		return "", errors.Wrapf(ErrUnsupportedQuery, "field %s", key)
+	}
+
+	ops, ok := value.(bson.D)
+	if !ok || len(ops) == 0 || !strings.HasPrefix(ops[0].Key, "$") {
+		return q.compare(column, "$eq", value)
+	}
+
+	conditions := make([]string, 0, len(ops))
+	for _, op := range ops {
+		condition, err := q.compare(column, op.Key, op.Value)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return strings.Join(conditions, " AND "), nil
+}
+
+// compare - condition of a single operator. Null stands for a missing field as well, so unlike in sql
+// $ne and $nin match the posts without a value.
+func (q *sqlQuery) compare(column, op string, value interface{}) (string, error) {
+	switch op {
+	case "$eq":
+		if value == nil {
+			return column + " IS NULL", nil
+		}
+		return column + " = " + q.arg(sqlValue(value)), nil
+	case "$ne":
+		if value == nil {
+			return column + " IS NOT NULL", nil
+		}
+		return column + " IS DISTINCT FROM " + q.arg(sqlValue(value)), nil
+	case "$gt", "$gte", "$lt", "$lte":
+		operators := map[string]string{"$gt": ">", "$gte": ">=", "$lt": "<", "$lte": "<="}
+		return fmt.Sprintf("%s %s %s", column, operators[op], q.arg(sqlValue(value))), nil
+	case "$in", "$nin":
+		values, err := sqlArray(value)
+		if err != nil {
+			return "", errors.Wrapf(err, "%s of %s", op, column)
+		}
+		if op == "$in" {
+			return fmt.Sprintf("%s = ANY(%s)", column, q.arg(values)), nil
+		}
+		return fmt.Sprintf("(%s IS NULL OR NOT %s = ANY(%s))", column, column, q.arg(values)), nil
+	case "$exists":
+		if exists, _ := value.(bool); exists {
+			return column + " IS NOT NULL", nil
+		}
+		return column + " IS NULL", nil
+	default:
+		return "", errors.Wrapf(ErrUnsupportedQuery, "%s operator", op)
+	}
+}
+
+func (q *sqlQuery) logical(op string, value interface{}) (string, error) {
+	filters, ok := value.(bson.A)
+	if !ok || len(filters) == 0 {
+		return "", errors.Errorf("%s must be a nonempty array", op)
+	}
+
+	conditions := make([]string, 0, len(filters))
+	for _, item := range filters {
+		filter, ok := item.(bson.D)
+		if !ok {
+			return "", errors.Errorf("%s entries must be documents", op)
+		}
+
+		condition, err := q.where(filter)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, "("+condition+")")
+	}
+
+	switch op {
+	case "$and":
+		return "(" + strings.Join(conditions, " AND ") + ")", nil
+	case "$or":
+		return "(" + strings.Join(conditions, " OR ") + ")", nil
+	default:
+		return "NOT (" + strings.Join(conditions, " OR ") + ")", nil
+	}
+}
+
+// text - full-text condition on the search column. Like a mongo $text query it matches the posts containing
+// any of the words, they are stemmed by the english configuration of both the column and the query.
+func (q *sqlQuery) text(value interface{}) (string, error) {
+	spec, ok := value.(bson.D)
+	if !ok {
+		return "", errors.New("$text needs a document")
+	}
+
+	search, ok := spec.Map()["$search"].(string)
+	if !ok {
+		return "", errors.New("$text needs a $search string")
+	}
+
+	terms := strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(terms) == 0 {
+		return "FALSE", nil
+	}
+
+	// the terms are letters and digits only, so they cannot break the syntax of to_tsquery
+	q.tsquery = fmt.Sprintf("to_tsquery('english', %s)", q.arg(strings.Join(terms, " | ")))
+
+	return "search @@ " + q.tsquery, nil
+}
+
+// rank - sql expression of the Field of the ranking strategy, the stored fields are used as they are.
+func (q *sqlQuery) rank(strategy ranking.Strategy, now time.Time) (string, error) {
+	if column, ok := postColumns[strategy.Field()]; ok {
+		return column, nil
+	}
+
+	switch strategy.Name() {
+	case ranking.Hot:
+		// see the hot ranking for the constants, the first 4 bytes of an id are the seconds it was created at
+		return `(sign(coalesce(score, 0)::float8) * log(greatest(abs(coalesce(score, 0)), 1)::float8) +
+			(extract(epoch FROM coalesce(created_at, to_timestamp(('x' || left(id, 8))::bit(32)::bigint)))::float8 - 1134028003) / 45000)`, nil
+	case ranking.Rising:
+		return fmt.Sprintf(`(coalesce(score, 0) / power(extract(epoch FROM (%s::timestamptz - created_at))::float8 / 3600 + 2, 1.5))`,
+			q.arg(now)), nil
+	case ranking.Controversial:
+		return `(CASE WHEN ups <= 0 OR downs <= 0 THEN 0 ELSE power((ups + downs)::float8,
+			CASE WHEN ups > downs THEN downs::float8 / ups ELSE ups::float8 / downs END) END)`, nil
+	case ranking.Relevance:
+		if q.tsquery == "" {
+			return "", errors.New("the relevance ranking needs a $text query")
+		}
+		return fmt.Sprintf(`(ts_rank(search, %s) * (1 + log(greatest(coalesce(score, 0), 1)::float8)))`, q.tsquery), nil
+	default:
+		return "", errors.Wrapf(ErrUnsupportedQuery, "%s ranking", strategy.Name())
+	}
+}
+
+// sqlValue - the value as stored in the posts table, object ids are kept as their hex.
+func sqlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case primitive.ObjectID:
+		return v.Hex()
+	case primitive.DateTime:
+		return v.Time().UTC()
+	}
+	return value
+}
+
+// sqlArray - the values of $in and $nin as a text array, the only arrays the filters compare with.
+func sqlArray(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []string:
+		return v, nil
+	case bson.A:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := sqlValue(item).(string)
+			if !ok {
+				return nil, errors.Wrap(ErrUnsupportedQuery, "arrays of values other than strings")
+			}
+			result = append(result, s)
+		}
+		return result, nil
+	default:
+		return nil, errors.New("needs an array")
+	}
+}
@@ -0,0 +1,500 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// selectColumns - columns scanned by scanPost, in its order
+const selectColumns = "id, title, author, link, subreddit, content, score, promoted, nsfw, ups, downs, comment_count, created_at, status"
+
+// postgresRepo - posts kept in the posts table of postgres, see the postgres migrations. The filters of the use
+// cases are mongo filters, they are translated to sql conditions. Ids are generated like mongo object ids,
+// so the cursors and the other modules referring to posts are the same for both storages.
+type postgresRepo struct {
+	logger *log.Factory
+	pool   *pgxpool.Pool
+}
+
+func NewPostgres(logger *log.Factory, pool *pgxpool.Pool) *postgresRepo {
+	return &postgresRepo{
+		logger: logger,
+		pool:   pool,
+	}
+}
+
+func (r *postgresRepo) Create(ctx context.Context, m *models.Post) (*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	query := `INSERT INTO posts (` + selectColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING ` + selectColumns
+
+	result, err := scanPost(r.pool.QueryRow(ctx, query, insertArgs(m)...))
+	if err != nil {
+		return nil, errors.Wrap(err, "PostPostgresRepo.Create.Insert")
+	}
+
+	return result, nil
+}
+
+// CreateMany - the inserts are sent in a single batch, a post whose id exists already is reported as failed.
+func (r *postgresRepo) CreateMany(ctx context.Context, posts []*models.Post) (map[int]error, error) {
+	query := `INSERT INTO posts (` + selectColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) ON CONFLICT (id) DO NOTHING RETURNING id`
+
+	batch := &pgx.Batch{}
+	for _, m := range posts {
+		batch.Queue(query, insertArgs(m)...)
+	}
+
+	results := r.pool.SendBatch(ctx, batch)
+	defer results.Close()
+
+	failed := make(map[int]error)
+	ids := make([]string, len(posts))
+
+	for i := range posts {
+		err := results.QueryRow().Scan(&ids[i])
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			failed[i] = errors.New("a post with the same id exists")
+		case err != nil:
+			// the batch runs in a single implicit transaction, none of the posts is inserted
+			return nil, errors.Wrap(err, "PostPostgresRepo.CreateMany.SendBatch")
+		}
+	}
+
+	if err := results.Close(); err != nil {
+		return nil, errors.Wrap(err, "PostPostgresRepo.CreateMany.SendBatch")
+	}
+
+	for i, id := range ids {
+		if _, ok := failed[i]; !ok {
+			posts[i].Id = id
+		}
+	}
+
+	return failed, nil
+}
+
+func (r *postgresRepo) CountDocuments(ctx context.Context, filter bson.D) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	q := &sqlQuery{}
+	where, err := q.where(visible(filter))
+	if err != nil {
+		return 0, errors.Wrap(err, "GenerateFeeds.CountDocuments")
+	}
+
+	var totalCount int64
+	if err = r.pool.QueryRow(ctx, `SELECT count(*) FROM posts WHERE `+where, q.args...).Scan(&totalCount); err != nil {
+		r.logger.For(ctx).Error("Generate feeds count docs", zap.String("err", err.Error()))
+		return 0, errors.Wrap(err, "GenerateFeeds.CountDocuments")
+	}
+
+	return totalCount, nil
+}
+
+func (r *postgresRepo) FindAll(ctx context.Context, filter bson.D, query *pagination.Query) ([]*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	q := &sqlQuery{}
+	where, err := q.where(visible(filter))
+	if err != nil {
+		return nil, errors.Wrap(err, "FindAll.Find")
+	}
+
+	// id breaks ties between equal scores, so the order is stable across pages
+	sql := fmt.Sprintf(`SELECT %s FROM posts WHERE %s ORDER BY score DESC NULLS LAST, id DESC OFFSET %s LIMIT %s`,
+		selectColumns, where, q.arg(query.GetOffset()), q.arg(query.GetSize()))
+
+	result, err := r.query(ctx, sql, q.args, false)
+	if err != nil {
+		r.logger.For(ctx).Error("FindAll.Find", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "FindAll.Find")
+	}
+
+	return result, nil
+}
+
+// FindRanked - the rank of the strategy is computed by sql, pages after a cursor continue from its position.
+func (r *postgresRepo) FindRanked(ctx context.Context, filter bson.D, strategy ranking.Strategy, after *pagination.Cursor, query *pagination.Query) ([]*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	sql, args, err := rankedQuery(visible(filter), strategy, after, query, time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "FindRanked.Query")
+	}
+
+	result, err := r.query(ctx, sql, args, !storedRank(strategy))
+	if err != nil {
+		r.logger.For(ctx).Error("FindRanked.Query", zap.String("err", err.Error()), zap.String("sort", strategy.Name()))
+		return nil, errors.Wrap(err, "FindRanked.Query")
+	}
+
+	return result, nil
+}
+
+// Aggregate - runs the stages the use cases build: a leading $match followed by $sort, $skip, $limit and $sample.
+// A sample is ordered by random(), it reads all the matching posts which is fine for the few promoted ones.
+func (r *postgresRepo) Aggregate(ctx context.Context, stages ...bson.D) ([]*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	sql, args, err := aggregateQuery(visibleStages(stages))
+	if err != nil {
+		return nil, errors.Wrap(err, "Aggreegate.GetPosts")
+	}
+
+	result, err := r.query(ctx, sql, args, false)
+	if err != nil {
+		r.logger.For(ctx).Error("Aggreegate.Collections.GetPosts", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Aggreegate.GetPosts")
+	}
+
+	return result, nil
+}
+
+func (r *postgresRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	result, err := scanPost(r.pool.QueryRow(ctx, `SELECT `+selectColumns+` FROM posts WHERE id = $1`, id.Hex()))
+	if err != nil {
+		return nil, r.rowError(ctx, err, "FindByID.QueryRow")
+	}
+
+	return result, nil
+}
+
+// Update - replaces the editable fields of the post, the author and the score are kept as they are.
+func (r *postgresRepo) Update(ctx context.Context, id primitive.ObjectID, m *models.Post) (*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	// link and content are mutually exclusive, so the empty one is cleared
+	var link, content *string
+	if m.Link != "" {
+		link = &m.Link
+	} else {
+		content = &m.Content
+	}
+
+	query := `UPDATE posts SET title = $2, subreddit = $3, promoted = $4, nsfw = $5, link = $6, content = $7
+		WHERE id = $1 RETURNING ` + selectColumns
+
+	result, err := scanPost(r.pool.QueryRow(ctx, query, id.Hex(), m.Title, m.Subreddit, m.Promoted, m.NSFW, link, content))
+	if err != nil {
+		return nil, r.rowError(ctx, err, "Update.QueryRow")
+	}
+
+	return result, nil
+}
+
+func (r *postgresRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM posts WHERE id = $1`, id.Hex())
+	if err != nil {
+		r.logger.For(ctx).Error("Delete.Exec", zap.String("err", err.Error()))
+		return errors.Wrap(err, "Delete.Exec")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return customErrors.New(http.StatusNotFound, customErrors.NotFound)
+	}
+
+	return nil
+}
+
+// IncrementScore - atomically adds the deltas to the score and vote counters of the post.
+func (r *postgresRepo) IncrementScore(ctx context.Context, id primitive.ObjectID, score, ups, downs int) (*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	query := `UPDATE posts SET score = coalesce(score, 0) + $2, ups = ups + $3, downs = downs + $4
+		WHERE id = $1 RETURNING ` + selectColumns
+
+	result, err := scanPost(r.pool.QueryRow(ctx, query, id.Hex(), score, ups, downs))
+	if err != nil {
+		return nil, r.rowError(ctx, err, "IncrementScore.QueryRow")
+	}
+
+	return result, nil
+}
+
+func (r *postgresRepo) IncrementCommentCount(ctx context.Context, id primitive.ObjectID, delta int) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	tag, err := r.pool.Exec(ctx, `UPDATE posts SET comment_count = comment_count + $2 WHERE id = $1`, id.Hex(), delta)
+	if err != nil {
+		r.logger.For(ctx).Error("IncrementCommentCount.Exec", zap.String("err", err.Error()))
+		return errors.Wrap(err, "IncrementCommentCount.Exec")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return customErrors.New(http.StatusNotFound, customErrors.NotFound)
+	}
+
+	return nil
+}
+
+func (r *postgresRepo) SetStatus(ctx context.Context, id primitive.ObjectID, status string) (*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	query := `UPDATE posts SET status = $2 WHERE id = $1 RETURNING ` + selectColumns
+
+	result, err := scanPost(r.pool.QueryRow(ctx, query, id.Hex(), status))
+	if err != nil {
+		return nil, r.rowError(ctx, err, "SetStatus.QueryRow")
+	}
+
+	return result, nil
+}
+
+// query - scans the posts of the rows, the rank column follows the post columns when withRank is set.
+func (r *postgresRepo) query(ctx context.Context, sql string, args []interface{}, withRank bool) ([]*models.Post, error) {
+	rows, err := r.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*models.Post, 0)
+	for rows.Next() {
+		var rank float64
+		var extra []interface{}
+		if withRank {
+			extra = append(extra, &rank)
+		}
+
+		p, err := scanPost(rows, extra...)
+		if err != nil {
+			return nil, err
+		}
+		p.Rank = rank
+
+		result = append(result, p)
+	}
+
+	return result, rows.Err()
+}
+
+// rowError - a missing post is not found, other errors are logged.
+func (r *postgresRepo) rowError(ctx context.Context, err error, op string) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return customErrors.New(http.StatusNotFound, customErrors.NotFound)
+	}
+	r.logger.For(ctx).Error(op, zap.String("err", err.Error()))
+	return errors.Wrap(err, op)
+}
+
+// rankedQuery - one page of the posts matching the filter in the order of the strategy, see ranking.Pipeline.
+// The rank is computed in a subquery, so the cursor conditions can refer to it.
+func rankedQuery(filter bson.D, strategy ranking.Strategy, after *pagination.Cursor, query *pagination.Query, now time.Time) (string, []interface{}, error) {
+	q := &sqlQuery{}
+
+	// the $text condition comes first, the relevance rank refers to its query
+	where, err := q.where(filter)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rank, err := q.rank(strategy, now)
+	if err != nil {
+		return "", nil, err
+	}
+
+	columns := selectColumns
+	if !storedRank(strategy) {
+		columns += ", rank"
+	}
+
+	var sql strings.Builder
+	fmt.Fprintf(&sql, `SELECT %s FROM (SELECT %s, %s AS rank FROM posts WHERE %s) ranked`, columns, selectColumns, rank, where)
+
+	if after != nil {
+		key := q.arg(strategy.Value(after.Key))
+		fmt.Fprintf(&sql, ` WHERE (rank < %s OR rank = %s AND id < %s)`, key, key, q.arg(after.ObjectID().Hex()))
+	}
+
+	// nulls sort last like in mongo
+	sql.WriteString(` ORDER BY rank DESC NULLS LAST, id DESC`)
+
+	if after == nil && query.GetOffset() > 0 {
+		fmt.Fprintf(&sql, ` OFFSET %s`, q.arg(query.GetOffset()))
+	}
+
+	fmt.Fprintf(&sql, ` LIMIT %s`, q.arg(query.GetSize()))
+
+	return sql.String(), q.args, nil
+}
+
+// storedRank - whether the strategy sorts by a stored field, which is not a rank of the post.
+func storedRank(strategy ranking.Strategy) bool {
+	_, ok := postColumns[strategy.Field()]
+	return ok
+}
+
+// aggregateQuery - the sql of the stages, the stages following the $match apply in the order of sql.
+func aggregateQuery(stages []bson.D) (string, []interface{}, error) {
+	q := &sqlQuery{}
+
+	where := "TRUE"
+	var orderBy, offset, limit string
+
+	for i, stage := range stages {
+		if len(stage) != 1 {
+			return "", nil, errors.New("a stage must have a single key")
+		}
+
+		var err error
+
+		switch name, spec := stage[0].Key, stage[0].Value; {
+		case name == "$match" && i == 0:
+			filter, _ := spec.(bson.D)
+			where, err = q.where(filter)
+		case name == "$sort":
+			orderBy, err = sortColumns(spec)
+		case name == "$skip":
+			offset = " OFFSET " + q.arg(spec)
+		case name == "$limit":
+			limit = " LIMIT " + q.arg(spec)
+		case name == "$sample":
+			sample, ok := spec.(bson.D)
+			if !ok {
+				return "", nil, errors.New("$sample needs a document")
+			}
+			orderBy = "random()"
+			limit = " LIMIT " + q.arg(sample.Map()["size"])
+		default:
+			err = errors.Wrapf(ErrUnsupportedQuery, "%s stage", name)
+		}
+
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	sql := fmt.Sprintf(`SELECT %s FROM posts WHERE %s`, selectColumns, where)
+	if orderBy != "" {
+		sql += " ORDER BY " + orderBy
+	}
+
+	return sql + offset + limit, q.args, nil
+}
+
+// sortColumns - ORDER BY of a $sort specification.
+func sortColumns(spec interface{}) (string, error) {
+	keys, ok := spec.(bson.D)
+	if !ok || len(keys) == 0 {
+		return "", errors.New("$sort needs a nonempty document")
+	}
+
+	columns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		column, ok := postColumns[key.Key]
+		if !ok {
+			return "", errors.Wrapf(ErrUnsupportedQuery, "sort by %s", key.Key)
+		}
+
+		if direction(key.Value) < 0 {
+			columns = append(columns, column+" DESC NULLS LAST")
+		} else {
+			columns = append(columns, column+" ASC NULLS FIRST")
+		}
+	}
+
+	return strings.Join(columns, ", "), nil
+}
+
+func direction(value interface{}) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	}
+	return 1
+}
+
+// insertArgs - values of the columns of the post, a new id is generated unless it has one.
+// Mongo keeps times in milliseconds, so do the posts here, the cursors of the newest posts rely on it.
+func insertArgs(m *models.Post) []interface{} {
+	id := m.Id
+	if id == "" {
+		id = primitive.NewObjectID().Hex()
+	}
+
+	var createdAt *time.Time
+	if !m.CreatedAt.IsZero() {
+		t := m.CreatedAt.Truncate(time.Millisecond)
+		createdAt = &t
+	}
+
+	return []interface{}{
+		id, m.Title, m.Author, nullString(m.Link), m.Subreddit, nullString(m.Content), m.Score, m.Promoted, m.NSFW,
+		m.Ups, m.Downs, m.CommentCount, createdAt, nullString(m.Status),
+	}
+}
+
+// scanPost - scans the selectColumns of the row, followed by the extra destinations.
+func scanPost(row pgx.Row, extra ...interface{}) (*models.Post, error) {
+	p := &models.Post{}
+
+	var link, content, status *string
+	var createdAt *time.Time
+
+	dest := []interface{}{&p.Id, &p.Title, &p.Author, &link, &p.Subreddit, &content, &p.Score, &p.Promoted, &p.NSFW,
+		&p.Ups, &p.Downs, &p.CommentCount, &createdAt, &status}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if link != nil {
+		p.Link = *link
+	}
+	if content != nil {
+		p.Content = *content
+	}
+	if status != nil {
+		p.Status = *status
+	}
+	if createdAt != nil {
+		p.CreatedAt = createdAt.UTC()
+	}
+
+	return p, nil
+}
+
+// nullString - an empty string is stored as null, like the omitted fields of mongo.
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSQLQuery_Where(t *testing.T) {
+
+	since := time.Date(2022, 4, 10, 0, 0, 0, 0, time.UTC)
+	id := primitive.NewObjectID()
+
+	cases := map[string]struct {
+		filter bson.D
+		where  string
+		args   []interface{}
+	}{
+		"empty": {nil, "TRUE", nil},
+		"equal": {
+			bson.D{{"promoted", false}, {"subreddit", "/r/golang"}},
+			"promoted = $1 AND subreddit = $2",
+			[]interface{}{false, "/r/golang"},
+		},
+		"null":      {bson.D{{"link", nil}}, "link IS NULL", nil},
+		"object id": {bson.D{{"_id", id}}, "id = $1", []interface{}{id.Hex()}},
+		"operators": {
+			bson.D{{"created_at", bson.D{{"$gte", since}}}, {"subreddit", bson.D{{"$eq", "/r/go"}, {"$in", []string{"/r/go"}}}}},
+			"created_at >= $1 AND subreddit = $2 AND subreddit = ANY($3)",
+			[]interface{}{since, "/r/go", []string{"/r/go"}},
+		},
+		// like mongo, a post without a status is not one of the hidden ones
+		"visible": {
+			visible(nil),
+			"(status IS NULL OR NOT status = ANY($1))",
+			[]interface{}{models.HiddenStatuses},
+		},
+		"or": {
+			bson.D{{"$or", bson.A{bson.D{{"score", bson.D{{"$lt", 42}}}}, bson.D{{"score", 42}, {"_id", bson.D{{"$lt", id}}}}}}},
+			"((score < $1) OR (score = $2 AND id < $3))",
+			[]interface{}{42, 42, id.Hex()},
+		},
+		"text": {
+			bson.D{{"$text", bson.D{{"$search", "Golang, generics!"}}}},
+			"search @@ to_tsquery('english', $1)",
+			[]interface{}{"golang | generics"},
+		},
+		"text without words": {bson.D{{"$text", bson.D{{"$search", "?!"}}}}, "FALSE", nil},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			q := &sqlQuery{}
+
+			where, err := q.where(tc.filter)
+			require.NoError(t, err)
+			require.Equal(t, tc.where, where)
+			require.Equal(t, tc.args, q.args)
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		filters := []bson.D{
+			{{"votes.user", "u1"}},
+			{{"score", bson.D{{"$mod", bson.A{2, 0}}}}},
+			{{"score", bson.D{{"$in", bson.A{1, 2}}}}},
+		}
+
+		for _, filter := range filters {
+			_, err := (&sqlQuery{}).where(filter)
+			require.ErrorIs(t, err, ErrUnsupportedQuery)
+		}
+	})
+}
+
+func TestRankedQuery(t *testing.T) {
+
+	now := time.Now()
+	filter := bson.D{{"promoted", false}}
+
+	t.Run("first page", func(t *testing.T) {
+		s, _ := ranking.Get(ranking.Top, "")
+
+		sql, args, err := rankedQuery(filter, s, nil, &pagination.Query{Size: 10}, now)
+		require.NoError(t, err)
+
+		require.Equal(t, `SELECT `+selectColumns+` FROM (SELECT `+selectColumns+`, score AS rank FROM posts WHERE promoted = $1) ranked`+
+			` ORDER BY rank DESC NULLS LAST, id DESC LIMIT $2`, sql)
+		require.Equal(t, []interface{}{false, 10}, args)
+	})
+
+	t.Run("page", func(t *testing.T) {
+		s, _ := ranking.Get(ranking.Hot, "")
+
+		sql, args, err := rankedQuery(filter, s, nil, &pagination.Query{Size: 10, Page: 3}, now)
+		require.NoError(t, err)
+
+		// the computed rank is selected for the posts
+		require.Contains(t, sql, `SELECT `+selectColumns+`, rank FROM`)
+		require.Contains(t, sql, `ORDER BY rank DESC NULLS LAST, id DESC OFFSET $2 LIMIT $3`)
+		require.Equal(t, []interface{}{false, 20, 10}, args)
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		s, _ := ranking.Get(ranking.New, "")
+		id := primitive.NewObjectID()
+		createdAt := time.Date(2022, 4, 10, 12, 30, 15, 0, time.UTC)
+
+		after := &pagination.Cursor{Key: s.Key(&models.Post{CreatedAt: createdAt}), ID: id.Hex()}
+
+		sql, args, err := rankedQuery(filter, s, after, &pagination.Query{Size: 10, Page: 3}, now)
+		require.NoError(t, err)
+
+		require.Contains(t, sql, `created_at AS rank`)
+		require.Contains(t, sql, `ranked WHERE (rank < $2 OR rank = $2 AND id < $3) ORDER BY rank DESC NULLS LAST, id DESC LIMIT $4`)
+		require.Equal(t, []interface{}{false, createdAt, id.Hex(), 10}, args)
+	})
+
+	t.Run("rising", func(t *testing.T) {
+		s, _ := ranking.Get(ranking.Rising, "")
+
+		sql, args, err := rankedQuery(filter, s, nil, &pagination.Query{Size: 10}, now)
+		require.NoError(t, err)
+
+		require.Contains(t, sql, `$2::timestamptz - created_at`)
+		require.Equal(t, []interface{}{false, now, 10}, args)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		text := bson.D{{"$text", bson.D{{"$search", "golang"}}}}
+
+		sql, _, err := rankedQuery(text, ranking.Search(), nil, &pagination.Query{Size: 10}, now)
+		require.NoError(t, err)
+		require.Contains(t, sql, `ts_rank(search, to_tsquery('english', $1))`)
+
+		// relevance is only known for a $text query
+		_, _, err = rankedQuery(filter, ranking.Search(), nil, &pagination.Query{Size: 10}, now)
+		require.Error(t, err)
+	})
+}
+
+func TestAggregateQuery(t *testing.T) {
+
+	t.Run("sample", func(t *testing.T) {
+		stages := visibleStages([]bson.D{
+			{{"$match", bson.D{{"promoted", true}}}},
+			{{"$sample", bson.D{{"size", 2}}}},
+		})
+
+		sql, args, err := aggregateQuery(stages)
+		require.NoError(t, err)
+
+		require.Equal(t, `SELECT `+selectColumns+` FROM posts WHERE promoted = $1 AND (status IS NULL OR NOT status = ANY($2))`+
+			` ORDER BY random() LIMIT $3`, sql)
+		require.Equal(t, []interface{}{true, models.HiddenStatuses, 2}, args)
+	})
+
+	t.Run("sort", func(t *testing.T) {
+		sql, _, err := aggregateQuery(visibleStages([]bson.D{
+			{{"$sort", bson.D{{"score", -1}, {"_id", 1}}}},
+			{{"$skip", 20}},
+			{{"$limit", 10}},
+		}))
+		require.NoError(t, err)
+		require.Contains(t, sql, ` ORDER BY score DESC NULLS LAST, id ASC NULLS FIRST OFFSET $2 LIMIT $3`)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, _, err := aggregateQuery([]bson.D{{{"$group", bson.D{{"_id", "$subreddit"}}}}})
+		require.ErrorIs(t, err, ErrUnsupportedQuery)
+	})
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"time"
+)
+
+// postgresVoteRepo - votes kept in the votes table of postgres, next to the posts whose scores they count.
+type postgresVoteRepo struct {
+	logger *log.Factory
+	pool   *pgxpool.Pool
+}
+
+func NewPostgresVoteRepository(logger *log.Factory, pool *pgxpool.Pool) *postgresVoteRepo {
+	return &postgresVoteRepo{
+		logger: logger,
+		pool:   pool,
+	}
+}
+
+// Upsert - a missing vote is first stored as a cleared one, so two concurrent first votes of the same user
+// wait for each other on the row and the second one reads the direction of the first.
+func (r *postgresVoteRepo) Upsert(ctx context.Context, user string, post primitive.ObjectID, direction int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	var previous int
+
+	err := r.pool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		now := time.Now().UTC()
+
+		_, err := tx.Exec(ctx, `INSERT INTO votes (user_id, post, direction, updated_at) VALUES ($1, $2, 0, $3)
+			ON CONFLICT (user_id, post) DO NOTHING`, user, post.Hex(), now)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(ctx, `SELECT direction FROM votes WHERE user_id = $1 AND post = $2 FOR UPDATE`,
+			user, post.Hex()).Scan(&previous)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE votes SET direction = $3, updated_at = $4 WHERE user_id = $1 AND post = $2`,
+			user, post.Hex(), direction, now)
+		return err
+	})
+
+	if err != nil {
+		r.logger.For(ctx).Error("Vote.Upsert.BeginFunc", zap.String("err", err.Error()))
+		return 0, errors.Wrap(err, "Vote.Upsert.BeginFunc")
+	}
+
+	return previous, nil
+}
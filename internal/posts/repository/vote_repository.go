@@ -0,0 +1,67 @@
+//go:generate mockgen -source vote_repository.go -destination mock/vote_repository_mock.go -package mock
+package repository
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/driver/db"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	votesCollectionName = "votes"
+)
+
+type VoteRepository interface {
+	// Upsert - stores the direction of the user's vote on the post and returns the previous direction, 0 when there was none.
+	Upsert(ctx context.Context, user string, post primitive.ObjectID, direction int) (int, error)
+}
+
+type voteRepo struct {
+	logger     *log.Factory
+	collection db.Collection
+}
+
+func NewVoteRepository(logger *log.Factory, collection db.Collection) *voteRepo {
+	return &voteRepo{
+		logger:     logger,
+		collection: collection,
+	}
+}
+
+func (r *voteRepo) Upsert(ctx context.Context, user string, post primitive.ObjectID, direction int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	filter := bson.D{{"user", user}, {"post", post}}
+	update := bson.D{{"$set", bson.D{{"direction", direction}, {"updated_at", time.Now().UTC()}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+
+	previous := &models.Vote{}
+
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, previous, opts)
+
+	// two concurrent first votes of the same user race on the unique (user, post) index, the loser retries as an update
+	if mongo.IsDuplicateKeyError(err) {
+		previous = &models.Vote{}
+		err = r.collection.FindOneAndUpdate(ctx, filter, update, previous, opts)
+	}
+
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// first vote of the user on the post
+			return 0, nil
+		}
+		r.logger.For(ctx).Error("Vote.Upsert.FindOneAndUpdate", zap.String("err", err.Error()))
+		return 0, errors.Wrap(err, "Vote.Upsert.FindOneAndUpdate")
+	}
+
+	return previous.Direction, nil
+}
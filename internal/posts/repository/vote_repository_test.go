@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/driver/db/mock"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestVoteRepo_Upsert(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := NewVoteRepository(logger, coll)
+
+	post := primitive.NewObjectID()
+	filter := bson.D{{"user", "t2_user"}, {"post", post}}
+
+	t.Run("first vote", func(t *testing.T) {
+
+		coll.EXPECT().FindOneAndUpdate(gomock.Any(), filter, gomock.Any(), gomock.Any(), gomock.Any()).Return(mongo.ErrNoDocuments)
+
+		previous, err := repo.Upsert(context.Background(), "t2_user", post, 1)
+
+		require.NoError(t, err)
+		require.Equal(t, 0, previous)
+	})
+
+	t.Run("changed vote", func(t *testing.T) {
+
+		coll.EXPECT().FindOneAndUpdate(gomock.Any(), filter, gomock.Any(), gomock.Eq(&models.Vote{}), gomock.Any()).Return(nil).SetArg(3, models.Vote{Direction: 1})
+
+		previous, err := repo.Upsert(context.Background(), "t2_user", post, -1)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, previous)
+	})
+
+	t.Run("concurrent first vote", func(t *testing.T) {
+
+		duplicate := mongo.CommandError{Code: 11000}
+
+		gomock.InOrder(
+			coll.EXPECT().FindOneAndUpdate(gomock.Any(), filter, gomock.Any(), gomock.Any(), gomock.Any()).Return(duplicate),
+			coll.EXPECT().FindOneAndUpdate(gomock.Any(), filter, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).SetArg(3, models.Vote{Direction: 1}),
+		)
+
+		previous, err := repo.Upsert(context.Background(), "t2_user", post, 1)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, previous)
+	})
+
+	t.Run("error", func(t *testing.T) {
+
+		coll.EXPECT().FindOneAndUpdate(gomock.Any(), filter, gomock.Any(), gomock.Any(), gomock.Any()).Return(mongo.CommandError{})
+
+		_, err := repo.Upsert(context.Background(), "t2_user", post, 1)
+
+		require.Error(t, err)
+	})
+
+}
@@ -0,0 +1,109 @@
+package snapshot
+
+import (
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"sync"
+	"time"
+)
+
+// Global - feed of the posts of every subreddit, the other feeds are named by their subreddit
+const Global = ""
+
+const (
+	// ReadHit - the page was read from the snapshot
+	ReadHit = "hit"
+	// ReadMissing - the feed has no snapshot yet
+	ReadMissing = "missing"
+	// ReadStale - the snapshot of the feed is older than the max age
+	ReadStale = "stale"
+	// ReadBeyond - the page reaches past the posts of the snapshot
+	ReadBeyond = "beyond"
+)
+
+// Entry - a post of the snapshot with its hot rank at the time of the snapshot.
+type Entry struct {
+	Id  string
+	Key float64
+}
+
+// Snapshot - the first posts of a feed in the hot order, as of ComputedAt.
+type Snapshot struct {
+	Entries []Entry
+	// Total - organic posts of the feed when the snapshot was computed
+	Total int64
+	// Complete - the entries are all the posts of the feed, so pages past them are empty rather than unknown
+	Complete   bool
+	ComputedAt time.Time
+}
+
+// Page - entries of the page, after the position of the cursor when it is set, otherwise at the offset of the query.
+// Reports false when the page reaches past the entries of an incomplete snapshot.
+func (s *Snapshot) Page(after *pagination.Cursor, query *pagination.Query) ([]Entry, bool) {
+	start := query.GetOffset()
+
+	if after != nil {
+		// ids are hex of the same length, their strings sort like the ids
+		start = len(s.Entries)
+		for i, e := range s.Entries {
+			if e.Key < after.Key || e.Key == after.Key && e.Id < after.ID {
+				start = i
+				break
+			}
+		}
+	}
+
+	end := start + query.GetSize()
+	if end > len(s.Entries) {
+		if !s.Complete {
+			return nil, false
+		}
+		end = len(s.Entries)
+	}
+	if start > end {
+		start = end
+	}
+
+	return s.Entries[start:end], true
+}
+
+// Store - the latest snapshot of every feed, kept in memory by each instance.
+type Store struct {
+	maxAge time.Duration
+	now    func() time.Time
+
+	mu    sync.RWMutex
+	feeds map[string]*Snapshot
+}
+
+// NewStore - snapshots older than maxAge are not read, the feeds fall back to the live query.
+func NewStore(maxAge time.Duration) *Store {
+	return &Store{
+		maxAge: maxAge,
+		now:    time.Now,
+		feeds:  make(map[string]*Snapshot),
+	}
+}
+
+// Get - the snapshot of the feed along with the outcome of the read, the snapshot is only set for a hit.
+func (s *Store) Get(feed string) (*Snapshot, string) {
+	s.mu.RLock()
+	snapshot, ok := s.feeds[feed]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, ReadMissing
+	}
+	if s.now().Sub(snapshot.ComputedAt) > s.maxAge {
+		return nil, ReadStale
+	}
+
+	return snapshot, ReadHit
+}
+
+// Set - replaces the snapshot of the feed, readers holding the previous one keep it.
+func (s *Store) Set(feed string, snapshot *Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.feeds[feed] = snapshot
+}
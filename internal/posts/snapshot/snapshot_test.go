@@ -0,0 +1,82 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot_Page(t *testing.T) {
+
+	entries := []Entry{{"c", 3}, {"b", 2}, {"a", 2}, {"d", 1}}
+
+	ids := func(entries []Entry) []string {
+		result := make([]string, 0, len(entries))
+		for _, e := range entries {
+			result = append(result, e.Id)
+		}
+		return result
+	}
+
+	t.Run("page", func(t *testing.T) {
+		s := &Snapshot{Entries: entries}
+
+		page, ok := s.Page(nil, &pagination.Query{Size: 2, Page: 2})
+		require.True(t, ok)
+		require.Equal(t, []string{"a", "d"}, ids(page))
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		s := &Snapshot{Entries: entries}
+
+		// a tie is broken by the id, like the live query does
+		page, ok := s.Page(&pagination.Cursor{Key: 2, ID: "b"}, &pagination.Query{Size: 2})
+		require.True(t, ok)
+		require.Equal(t, []string{"a", "d"}, ids(page))
+	})
+
+	t.Run("past an incomplete snapshot", func(t *testing.T) {
+		s := &Snapshot{Entries: entries}
+
+		_, ok := s.Page(nil, &pagination.Query{Size: 3, Page: 2})
+		require.False(t, ok)
+
+		_, ok = s.Page(&pagination.Cursor{Key: 1, ID: "d"}, &pagination.Query{Size: 2})
+		require.False(t, ok)
+	})
+
+	t.Run("past a complete snapshot", func(t *testing.T) {
+		s := &Snapshot{Entries: entries, Complete: true}
+
+		page, ok := s.Page(nil, &pagination.Query{Size: 3, Page: 2})
+		require.True(t, ok)
+		require.Equal(t, []string{"d"}, ids(page))
+
+		page, ok = s.Page(nil, &pagination.Query{Size: 3, Page: 5})
+		require.True(t, ok)
+		require.Empty(t, page)
+	})
+}
+
+func TestStore_Get(t *testing.T) {
+
+	now := time.Now()
+
+	store := NewStore(time.Minute)
+	store.now = func() time.Time { return now }
+
+	_, result := store.Get(Global)
+	require.Equal(t, ReadMissing, result)
+
+	store.Set(Global, &Snapshot{ComputedAt: now.Add(-time.Second)})
+	s, result := store.Get(Global)
+	require.Equal(t, ReadHit, result)
+	require.NotNil(t, s)
+
+	store.Set("/r/golang", &Snapshot{ComputedAt: now.Add(-time.Minute * 2)})
+	s, result = store.Get("/r/golang")
+	require.Equal(t, ReadStale, result)
+	require.Nil(t, s)
+}
@@ -0,0 +1,146 @@
+package snapshot
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/aliykh/reddit-feed/internal/metrics"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
+	"github.com/aliykh/reddit-feed/internal/posts/repository"
+	subredditsRepository "github.com/aliykh/reddit-feed/internal/subreddits/repository"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"time"
+)
+
+// batchSize - posts and subreddits read by a single query, the repositories cap the size of a page
+const batchSize = 25
+
+// Worker - refreshes the snapshots of the hot feeds of every subreddit and of the global one.
+type Worker struct {
+	logger     *log.Factory
+	cfg        config.FeedSnapshotConfig
+	posts      repository.Repository
+	subreddits subredditsRepository.Repository
+	store      *Store
+}
+
+func NewWorker(logger *log.Factory, cfg config.FeedSnapshotConfig, posts repository.Repository, subreddits subredditsRepository.Repository, store *Store) *Worker {
+	return &Worker{
+		logger:     logger,
+		cfg:        cfg,
+		posts:      posts,
+		subreddits: subreddits,
+		store:      store,
+	}
+}
+
+// Run - refreshes the snapshots right away and then every interval until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Refresh(ctx); err != nil && ctx.Err() == nil {
+			w.logger.Default().Error("feed snapshots refresh", zap.String("err", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh - computes the snapshot of every feed. A feed failing to refresh keeps its previous snapshot,
+// which is read until it gets stale.
+func (w *Worker) Refresh(ctx context.Context) error {
+	feeds := []string{Global}
+
+	for page := 1; ; page++ {
+		subreddits, err := w.subreddits.FindAll(ctx, bson.D{}, &pagination.Query{Size: batchSize, Page: page})
+		if err != nil {
+			return errors.Wrap(err, "snapshot.Refresh")
+		}
+		for _, s := range subreddits {
+			feeds = append(feeds, s.Name)
+		}
+		if len(subreddits) < batchSize {
+			break
+		}
+	}
+
+	var failed int
+	for _, feed := range feeds {
+		snapshot, err := w.compute(ctx, feed)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			metrics.FeedSnapshotRefreshErrors.Inc()
+			w.logger.Default().Error("feed snapshot", zap.String("feed", feed), zap.String("err", err.Error()))
+			continue
+		}
+		w.store.Set(feed, snapshot)
+	}
+
+	if failed > 0 {
+		return errors.Errorf("snapshot.Refresh: %d of %d feeds failed", failed, len(feeds))
+	}
+
+	metrics.FeedSnapshotRefreshed.SetToCurrentTime()
+	return nil
+}
+
+// compute - the snapshot of a feed, the organic posts of the subreddit or of all of them for the global feed.
+func (w *Worker) compute(ctx context.Context, feed string) (*Snapshot, error) {
+	strategy, err := ranking.Get(ranking.Hot, "")
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{{"promoted", false}}
+	if feed != Global {
+		filter = append(filter, bson.E{"subreddit", feed})
+	}
+
+	computedAt := time.Now()
+
+	total, err := w.posts.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{Entries: make([]Entry, 0, w.cfg.Size), Total: total, ComputedAt: computedAt}
+
+	var after *pagination.Cursor
+	for len(snapshot.Entries) < w.cfg.Size {
+		size := batchSize
+		if left := w.cfg.Size - len(snapshot.Entries); left < size {
+			size = left
+		}
+
+		posts, err := w.posts.FindRanked(ctx, filter, strategy, after, &pagination.Query{Size: size})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range posts {
+			snapshot.Entries = append(snapshot.Entries, Entry{Id: p.Id, Key: strategy.Key(p)})
+		}
+
+		if len(posts) < size {
+			snapshot.Complete = true
+			break
+		}
+
+		last := snapshot.Entries[len(snapshot.Entries)-1]
+		after = &pagination.Cursor{Sort: strategy.Name(), Key: last.Key, ID: last.Id}
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,88 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
+	"github.com/aliykh/reddit-feed/internal/posts/repository/mock"
+	subredditsModels "github.com/aliykh/reddit-feed/internal/subreddits/models"
+	subredditsMock "github.com/aliykh/reddit-feed/internal/subreddits/repository/mock"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestWorker_Refresh(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	posts := mock.NewMockRepository(ctrl)
+	subreddits := subredditsMock.NewMockRepository(ctrl)
+	store := NewStore(time.Minute)
+
+	worker := NewWorker(logger, config.FeedSnapshotConfig{Interval: time.Second, Size: 3, MaxAge: time.Minute}, posts, subreddits, store)
+
+	ranked := func(ids ...string) []*models.Post {
+		result := make([]*models.Post, 0, len(ids))
+		for i, id := range ids {
+			result = append(result, &models.Post{Id: id, Rank: float64(10 - i)})
+		}
+		return result
+	}
+
+	global := bson.D{{"promoted", false}}
+	golang := bson.D{{"promoted", false}, {"subreddit", "/r/golang"}}
+
+	hot := func(_ context.Context, _ bson.D, s ranking.Strategy, after *pagination.Cursor, query *pagination.Query) {
+		require.Equal(t, ranking.Hot, s.Name())
+		require.Nil(t, after)
+		require.Equal(t, 3, query.Size)
+	}
+
+	subreddits.EXPECT().FindAll(gomock.Any(), bson.D{}, &pagination.Query{Size: batchSize, Page: 1}).
+		Return([]*subredditsModels.Subreddit{{Name: "/r/golang"}}, nil).Times(2)
+
+	t.Run("refresh", func(t *testing.T) {
+		posts.EXPECT().CountDocuments(gomock.Any(), global).Return(int64(7), nil)
+		posts.EXPECT().FindRanked(gomock.Any(), global, gomock.Any(), gomock.Any(), gomock.Any()).Do(hot).Return(ranked("c", "b", "a"), nil)
+		posts.EXPECT().CountDocuments(gomock.Any(), golang).Return(int64(1), nil)
+		posts.EXPECT().FindRanked(gomock.Any(), golang, gomock.Any(), gomock.Any(), gomock.Any()).Do(hot).Return(ranked("b"), nil)
+
+		require.NoError(t, worker.Refresh(context.Background()))
+
+		s, result := store.Get(Global)
+		require.Equal(t, ReadHit, result)
+		require.Equal(t, []Entry{{"c", 10}, {"b", 9}, {"a", 8}}, s.Entries)
+		require.Equal(t, int64(7), s.Total)
+		require.False(t, s.Complete)
+
+		s, result = store.Get("/r/golang")
+		require.Equal(t, ReadHit, result)
+		require.Equal(t, []Entry{{"b", 10}}, s.Entries)
+		require.True(t, s.Complete)
+	})
+
+	t.Run("failed feed keeps its snapshot", func(t *testing.T) {
+		posts.EXPECT().CountDocuments(gomock.Any(), global).Return(int64(8), nil)
+		posts.EXPECT().FindRanked(gomock.Any(), global, gomock.Any(), gomock.Any(), gomock.Any()).Return(ranked("d", "c", "b"), nil)
+		posts.EXPECT().CountDocuments(gomock.Any(), golang).Return(int64(0), errors.New("timeout"))
+
+		require.Error(t, worker.Refresh(context.Background()))
+
+		s, _ := store.Get(Global)
+		require.Equal(t, "d", s.Entries[0].Id)
+
+		s, _ = store.Get("/r/golang")
+		require.Equal(t, []Entry{{"b", 10}}, s.Entries)
+	})
+}
@@ -0,0 +1,234 @@
+package stream
+
+import (
+	"errors"
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/aliykh/reddit-feed/internal/metrics"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"sync"
+)
+
+const (
+	// SlowClientDrop - a client with a full buffer misses the events
+	SlowClientDrop = "drop"
+	// SlowClientDisconnect - a client with a full buffer is disconnected, it resumes from its last event
+	SlowClientDisconnect = "disconnect"
+)
+
+var ErrClosed = errors.New("stream is closed")
+
+// Event - a created post. Its id is the id of the post, so it is the same on every instance.
+type Event struct {
+	ID   string
+	Post *models.Post
+}
+
+// Filter - posts a client is interested in, the zero value matches every post.
+type Filter struct {
+	Subreddit string
+	NSFW      *bool
+}
+
+// Match - whether the post passes the filter, posts without the nsfw flag are not nsfw.
+func (f Filter) Match(post *models.Post) bool {
+	if f.Subreddit != "" && post.Subreddit != f.Subreddit {
+		return false
+	}
+	if f.NSFW != nil && *f.NSFW != (post.NSFW != nil && *post.NSFW) {
+		return false
+	}
+	return true
+}
+
+// Broker - in-process pub/sub of the created posts. Recent events are kept, so reconnecting clients resume
+// where they left off.
+type Broker struct {
+	buffer     int
+	slowClient string
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	closed      bool
+
+	// history - ring of the recent events, next is the slot written next once it is full
+	history []Event
+	next    int
+	size    int
+	// published - ids of the events in the history, a post published twice is delivered once
+	published map[string]struct{}
+}
+
+func NewBroker(cfg config.StreamConfig) *Broker {
+	return &Broker{
+		buffer:      cfg.Buffer,
+		slowClient:  cfg.SlowClient,
+		subscribers: make(map[*Subscription]struct{}),
+		history:     make([]Event, cfg.History),
+		published:   make(map[string]struct{}, cfg.History),
+	}
+}
+
+// Publish - sends the post to the subscribers it matches. A post already in the history is skipped,
+// so the instance creating it and a change stream may both publish it.
+func (b *Broker) Publish(post *models.Post) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed || post.Id == "" {
+		return
+	}
+	if _, ok := b.published[post.Id]; ok {
+		return
+	}
+
+	event := Event{ID: post.Id, Post: post}
+	b.remember(event)
+
+	for sub := range b.subscribers {
+		if sub.filter.Match(post) {
+			b.deliver(sub, event)
+		}
+	}
+}
+
+// Subscribe - events of the posts matching the filter. With the id of the last event seen by the client,
+// the events published after it are delivered first.
+func (b *Broker) Subscribe(filter Filter, lastEventID string) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	var replay []Event
+	missed := false
+
+	if lastEventID != "" {
+		var ok bool
+		replay, ok = b.since(lastEventID, filter)
+		missed = !ok
+	}
+
+	sub := &Subscription{
+		broker: b,
+		filter: filter,
+		// room for the replay, it must not count as a slow client
+		events: make(chan Event, b.buffer+len(replay)),
+		Missed: missed,
+	}
+
+	for _, event := range replay {
+		sub.events <- event
+	}
+
+	b.subscribers[sub] = struct{}{}
+	metrics.StreamSubscribers.Inc()
+
+	return sub, nil
+}
+
+// Close - disconnects every subscriber, later subscriptions fail with ErrClosed.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
+
+func (b *Broker) deliver(sub *Subscription, event Event) {
+	select {
+	case sub.events <- event:
+	default:
+		if b.slowClient == SlowClientDrop {
+			metrics.StreamSlowConsumers.WithLabelValues("dropped").Inc()
+			return
+		}
+
+		metrics.StreamSlowConsumers.WithLabelValues("disconnected").Inc()
+		b.remove(sub)
+	}
+}
+
+// remove - closes the events of the subscriber, it is called with the lock held.
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+
+	delete(b.subscribers, sub)
+	close(sub.events)
+	metrics.StreamSubscribers.Dec()
+}
+
+// remember - adds the event to the history, evicting the oldest one when it is full.
+func (b *Broker) remember(event Event) {
+	if len(b.history) == 0 {
+		return
+	}
+
+	if b.size == len(b.history) {
+		delete(b.published, b.history[b.next].ID)
+	} else {
+		b.size++
+	}
+
+	b.history[b.next] = event
+	b.published[event.ID] = struct{}{}
+	b.next = (b.next + 1) % len(b.history)
+}
+
+// since - the events of the history after the given one that match the filter, oldest first.
+// Reports false when the event is not in the history anymore.
+func (b *Broker) since(id string, filter Filter) ([]Event, bool) {
+	if _, ok := b.published[id]; !ok {
+		return nil, false
+	}
+
+	// the oldest event is at next once the ring is full
+	start := 0
+	if b.size == len(b.history) {
+		start = b.next
+	}
+
+	var result []Event
+	found := false
+
+	for i := 0; i < b.size; i++ {
+		event := b.history[(start+i)%len(b.history)]
+		if found && filter.Match(event.Post) {
+			result = append(result, event)
+		}
+		if event.ID == id {
+			found = true
+		}
+	}
+
+	return result, true
+}
+
+// Subscription - events of a single client.
+type Subscription struct {
+	broker *Broker
+	filter Filter
+	events chan Event
+
+	// Missed - the last event of the client is not in the history anymore, it may have missed posts
+	Missed bool
+}
+
+// Events - closed when the client is disconnected as slow or the broker is closed.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close - stops the events of the client.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.remove(s)
+}
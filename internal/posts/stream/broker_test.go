@@ -0,0 +1,154 @@
+package stream
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aliykh/reddit-feed/internal/config"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/stretchr/testify/require"
+)
+
+func newPost(i int, subreddit string, nsfw bool) *models.Post {
+	return &models.Post{Id: fmt.Sprintf("post%d", i), Subreddit: subreddit, NSFW: &nsfw}
+}
+
+// received - the events queued for the subscription, without waiting for more
+func received(sub *Subscription) []string {
+	var ids []string
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return ids
+			}
+			ids = append(ids, event.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestBroker_Publish(t *testing.T) {
+
+	broker := NewBroker(config.StreamConfig{Buffer: 8, SlowClient: SlowClientDisconnect, History: 8})
+	defer broker.Close()
+
+	nsfw := false
+	all, err := broker.Subscribe(Filter{}, "")
+	require.NoError(t, err)
+	golang, err := broker.Subscribe(Filter{Subreddit: "/r/golang", NSFW: &nsfw}, "")
+	require.NoError(t, err)
+
+	broker.Publish(newPost(1, "/r/golang", false))
+	broker.Publish(newPost(2, "/r/golang", true))
+	broker.Publish(newPost(3, "/r/rust", false))
+	// published by the creating instance and the change stream
+	broker.Publish(newPost(1, "/r/golang", false))
+
+	require.Equal(t, []string{"post1", "post2", "post3"}, received(all))
+	require.Equal(t, []string{"post1"}, received(golang))
+
+	t.Run("closed subscription", func(t *testing.T) {
+		golang.Close()
+		broker.Publish(newPost(4, "/r/golang", false))
+
+		_, ok := <-golang.Events()
+		require.False(t, ok)
+		require.Equal(t, []string{"post4"}, received(all))
+	})
+}
+
+func TestBroker_Resume(t *testing.T) {
+
+	broker := NewBroker(config.StreamConfig{Buffer: 1, SlowClient: SlowClientDisconnect, History: 3})
+	defer broker.Close()
+
+	for i := 1; i <= 4; i++ {
+		broker.Publish(newPost(i, "/r/golang", false))
+	}
+
+	t.Run("replays the events after the last one", func(t *testing.T) {
+		sub, err := broker.Subscribe(Filter{}, "post2")
+		require.NoError(t, err)
+		defer sub.Close()
+
+		require.False(t, sub.Missed)
+		require.Equal(t, []string{"post3", "post4"}, received(sub))
+	})
+
+	t.Run("filtered", func(t *testing.T) {
+		sub, err := broker.Subscribe(Filter{Subreddit: "/r/rust"}, "post2")
+		require.NoError(t, err)
+		defer sub.Close()
+
+		require.Empty(t, received(sub))
+	})
+
+	t.Run("evicted from the history", func(t *testing.T) {
+		sub, err := broker.Subscribe(Filter{}, "post1")
+		require.NoError(t, err)
+		defer sub.Close()
+
+		require.True(t, sub.Missed)
+		require.Empty(t, received(sub))
+
+		// an evicted post is not a duplicate anymore
+		broker.Publish(newPost(1, "/r/golang", false))
+		require.Equal(t, []string{"post1"}, received(sub))
+	})
+}
+
+func TestBroker_SlowClient(t *testing.T) {
+
+	t.Run("disconnect", func(t *testing.T) {
+		broker := NewBroker(config.StreamConfig{Buffer: 1, SlowClient: SlowClientDisconnect, History: 8})
+		defer broker.Close()
+
+		sub, err := broker.Subscribe(Filter{}, "")
+		require.NoError(t, err)
+
+		broker.Publish(newPost(1, "/r/golang", false))
+		broker.Publish(newPost(2, "/r/golang", false))
+
+		// the queued event is still delivered, then the events are closed
+		require.Equal(t, []string{"post1"}, received(sub))
+		_, ok := <-sub.Events()
+		require.False(t, ok)
+
+		// closing a disconnected subscription is a no-op
+		sub.Close()
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		broker := NewBroker(config.StreamConfig{Buffer: 1, SlowClient: SlowClientDrop, History: 8})
+		defer broker.Close()
+
+		sub, err := broker.Subscribe(Filter{}, "")
+		require.NoError(t, err)
+		defer sub.Close()
+
+		broker.Publish(newPost(1, "/r/golang", false))
+		broker.Publish(newPost(2, "/r/golang", false))
+		require.Equal(t, []string{"post1"}, received(sub))
+
+		broker.Publish(newPost(3, "/r/golang", false))
+		require.Equal(t, []string{"post3"}, received(sub))
+	})
+}
+
+func TestBroker_Close(t *testing.T) {
+
+	broker := NewBroker(config.StreamConfig{Buffer: 1, SlowClient: SlowClientDisconnect})
+
+	sub, err := broker.Subscribe(Filter{}, "")
+	require.NoError(t, err)
+
+	broker.Close()
+
+	_, ok := <-sub.Events()
+	require.False(t, ok)
+
+	_, err = broker.Subscribe(Filter{}, "")
+	require.Equal(t, ErrClosed, err)
+}
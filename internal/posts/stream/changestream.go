@@ -0,0 +1,65 @@
+package stream
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"time"
+)
+
+// watchRetryInterval - pause before a failed change stream is opened again
+const watchRetryInterval = time.Second * 5
+
+// Watch - publishes the posts inserted into the collection by any instance until ctx is done.
+// A failed change stream is opened again after the last change it delivered.
+func Watch(ctx context.Context, logger *log.Factory, collection *mongo.Collection, broker *Broker) {
+	var resumeToken bson.Raw
+
+	for {
+		err := watch(ctx, collection, broker, &resumeToken)
+		if ctx.Err() != nil {
+			return
+		}
+
+		logger.Default().Error("posts change stream", zap.String("err", err.Error()))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchRetryInterval):
+		}
+	}
+}
+
+func watch(ctx context.Context, collection *mongo.Collection, broker *Broker, resumeToken *bson.Raw) error {
+	pipeline := mongo.Pipeline{bson.D{{"$match", bson.D{{"operationType", "insert"}}}}}
+
+	opts := options.ChangeStream()
+	if *resumeToken != nil {
+		opts.SetResumeAfter(*resumeToken)
+	}
+
+	cs, err := collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return err
+	}
+	defer cs.Close(context.Background())
+
+	for cs.Next(ctx) {
+		var change struct {
+			Post models.Post `bson:"fullDocument"`
+		}
+		if err = cs.Decode(&change); err != nil {
+			return err
+		}
+
+		*resumeToken = cs.ResumeToken()
+		broker.Publish(&change.Post)
+	}
+
+	return cs.Err()
+}
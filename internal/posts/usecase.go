@@ -4,10 +4,26 @@ package posts
 import (
 	"context"
 	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/stream"
 	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UseCase interface {
 	Create(context.Context, *models.Post) (*models.Post, error)
-	GenerateFeeds(context.Context, *pagination.Query) (*models.Feed, error)
+	// CreateIdempotent - Create, once per key of the principal, bodyHash tells the retries from other requests
+	CreateIdempotent(ctx context.Context, key, bodyHash string, model *models.Post) (*models.Post, error)
+	Import(context.Context, []*models.Post) ([]*models.ImportResult, error)
+	GenerateFeeds(context.Context, *models.FeedParams, *pagination.Query) (*models.Feed, error)
+	Search(context.Context, *models.SearchParams, *pagination.Query) (*models.Feed, error)
+	ListByAuthor(ctx context.Context, author string, query *pagination.Query) (*models.Feed, error)
+	GetByID(context.Context, primitive.ObjectID) (*models.Post, error)
+	Update(context.Context, primitive.ObjectID, *models.Post) (*models.Post, error)
+	Patch(context.Context, primitive.ObjectID, *models.PostPatch) (*models.Post, error)
+	Delete(context.Context, primitive.ObjectID) error
+	Moderate(context.Context, primitive.ObjectID, *models.Moderation) (*models.Post, error)
+	AuditLog(context.Context, primitive.ObjectID) ([]*models.AuditEntry, error)
+	// Subscribe - events of the created posts, the subscription is closed by the caller
+	Subscribe(ctx context.Context, params *models.StreamParams, lastEventID string) (*stream.Subscription, error)
+	Vote(ctx context.Context, id primitive.ObjectID, user string, direction int) (*models.Post, error)
 }
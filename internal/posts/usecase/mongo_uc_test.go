@@ -1,11 +1,29 @@
 package usecase
 
 import (
-	"strings"
+	"context"
+	"errors"
+	"net/http"
 	"testing"
+	"time"
 
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/auth"
+	"github.com/aliykh/reddit-feed/internal/config"
 	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/placement"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
+	"github.com/aliykh/reddit-feed/internal/posts/repository/mock"
+	"github.com/aliykh/reddit-feed/internal/posts/snapshot"
+	"github.com/aliykh/reddit-feed/internal/posts/stream"
+	subredditsModels "github.com/aliykh/reddit-feed/internal/subreddits/models"
+	subredditsMock "github.com/aliykh/reddit-feed/internal/subreddits/repository/mock"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // const (
@@ -114,7 +132,7 @@ import (
 
 // 	repo := repository.New(logger, mongoCollection)
 
-// 	uc := New(logger, repo)
+// 	uc := New(logger, repo, nil, nil, nil, nil, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
 
 // 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 // 	defer cancel()
@@ -138,7 +156,7 @@ import (
 
 // 	repo := repository.New(logger, mongoCollection)
 
-// 	uc := New(logger, repo)
+// 	uc := New(logger, repo, nil, nil, nil, nil, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
 
 // 	postsInDb, err := uc.GenerateFeeds(context.Background(), &pagination.Query{
 // 		Size: 25,
@@ -162,7 +180,7 @@ import (
 // 	// ----------------------------
 
 // 	repo := repository.New(logger, mongoCollection)
-// 	uc := New(logger, repo)
+// 	uc := New(logger, repo, nil, nil, nil, nil, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
 
 // 	postsInDb, err := uc.GenerateFeeds(context.Background(), &pagination.Query{
 // 		Size: 25,
@@ -209,12 +227,952 @@ import (
 // 	return posts
 // }
 
-func TestGenerateAuthorName(t *testing.T) {
+// placer - default promoted posts placement rules
+var placer = placement.New(config.PromotedConfig{
+	Slots:      []int{2, 16},
+	MaxPerPage: 2,
+	NSFWPolicy: placement.NSFWNeighbours,
+	Fallback:   placement.FallbackSkip,
+})
 
-	m := &models.Post{}
-	m.GenerateAuthorName()
+func TestPostsUC_Patch(t *testing.T) {
 
-	require.NotEmpty(t, m.Author)
-	require.True(t, strings.HasPrefix(m.Author, "t2_"))
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	uc := New(logger, repo, nil, nil, nil, nil, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
+
+	id := primitive.NewObjectID()
+	ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: "t2_author"})
+
+	stored := func() *models.Post {
+		return &models.Post{
+			Id:        id.Hex(),
+			Title:     "title",
+			Author:    "t2_author",
+			Link:      "https://www.example.com",
+			Subreddit: "/r/subreddit",
+			Score:     new(int),
+			Promoted:  new(bool),
+			NSFW:      new(bool),
+		}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		link, content := "", "content"
+
+		expected := stored()
+		expected.Link = link
+		expected.Content = content
+
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(stored(), nil)
+		repo.EXPECT().Update(gomock.Any(), id, gomock.Eq(expected)).Return(expected, nil)
+
+		result, err := uc.Patch(ctx, id, &models.PostPatch{Link: &link, Content: &content})
+
+		require.NoError(t, err)
+		require.Equal(t, expected, result)
+	})
+
+	t.Run("link and content", func(t *testing.T) {
+		content := "content"
+
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(stored(), nil)
+
+		_, err := uc.Patch(ctx, id, &models.PostPatch{Content: &content})
+
+		require.Equal(t, http.StatusBadRequest, customErrors.ParseError(err).ErrStatus)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		notFound := customErrors.New(http.StatusNotFound, customErrors.NotFound)
+
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(nil, notFound)
+
+		_, err := uc.Patch(ctx, id, &models.PostPatch{})
+
+		require.Equal(t, notFound, err)
+	})
+
+	t.Run("not the author", func(t *testing.T) {
+		other := auth.NewContext(context.Background(), &auth.Principal{UserID: "t2_other"})
+
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(stored(), nil)
+
+		_, err := uc.Patch(other, id, &models.PostPatch{})
+
+		require.Equal(t, customErrors.New(http.StatusForbidden, customErrors.Forbidden), err)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		_, err := uc.Patch(context.Background(), id, &models.PostPatch{})
+
+		require.Equal(t, customErrors.New(http.StatusUnauthorized, customErrors.Unauthorized), err)
+	})
+
+}
+
+func TestPostsUC_GenerateFeeds_Cursor(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	cursors := pagination.NewCursorCodec("secret", time.Hour)
+	uc := New(logger, repo, nil, nil, nil, nil, cursors, placer, nil, nil)
+
+	organic := func(n int) []*models.Post {
+		result := make([]*models.Post, 0, n)
+		for i := 0; i < n; i++ {
+			score := 100 - i
+			result = append(result, &models.Post{
+				Id:       primitive.NewObjectID().Hex(),
+				Score:    &score,
+				Promoted: new(bool),
+				NSFW:     new(bool),
+			})
+		}
+		return result
+	}
+
+	filter := bson.D{{"promoted", false}}
+
+	t.Run("next page", func(t *testing.T) {
+		last := primitive.NewObjectID()
+		query := &pagination.Query{Size: 2, Cursor: cursors.Encode("top:all", 50, last.Hex())}
+
+		page := organic(2)
+
+		repo.EXPECT().CountDocuments(gomock.Any(), filter).Return(int64(10), nil)
+		repo.EXPECT().FindRanked(gomock.Any(), filter, gomock.Any(), gomock.Any(), query).
+			DoAndReturn(func(_ context.Context, _ bson.D, s ranking.Strategy, after *pagination.Cursor, _ *pagination.Query) ([]*models.Post, error) {
+				require.Equal(t, "top:all", s.Name())
+				require.Equal(t, float64(50), after.Key)
+				require.Equal(t, last, after.ObjectID())
+				return page, nil
+			})
+		repo.EXPECT().Aggregate(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+		feed, err := uc.GenerateFeeds(context.Background(), &models.FeedParams{}, query)
+
+		require.NoError(t, err)
+		require.True(t, feed.HasMore)
+
+		next, err := cursors.Decode(feed.NextCursor)
+		require.NoError(t, err)
+		require.Equal(t, float64(*page[1].Score), next.Key)
+		require.Equal(t, page[1].Id, next.ID)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		query := &pagination.Query{Size: 2, Cursor: cursors.Encode("top:all", 50, primitive.NewObjectID().Hex())}
+
+		repo.EXPECT().CountDocuments(gomock.Any(), filter).Return(int64(10), nil)
+		repo.EXPECT().FindRanked(gomock.Any(), filter, gomock.Any(), gomock.Any(), query).Return(organic(1), nil)
+		repo.EXPECT().Aggregate(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+		feed, err := uc.GenerateFeeds(context.Background(), &models.FeedParams{}, query)
+
+		require.NoError(t, err)
+		require.False(t, feed.HasMore)
+		require.Empty(t, feed.NextCursor)
+	})
+
+	t.Run("tampered cursor", func(t *testing.T) {
+		_, err := uc.GenerateFeeds(context.Background(), &models.FeedParams{}, &pagination.Query{Size: 2, Cursor: "tampered"})
+
+		require.Equal(t, customErrors.New(http.StatusBadRequest, pagination.ErrInvalidCursor), err)
+	})
+
+	t.Run("cursor of another sort", func(t *testing.T) {
+		query := &pagination.Query{Size: 2, Cursor: cursors.Encode("top:all", 50, primitive.NewObjectID().Hex())}
+
+		_, err := uc.GenerateFeeds(context.Background(), &models.FeedParams{Sort: ranking.New}, query)
+
+		require.Equal(t, customErrors.New(http.StatusBadRequest, pagination.ErrInvalidCursor), err)
+	})
+
+	t.Run("rising", func(t *testing.T) {
+		// the rising rank moves with the time of the request, its pages are only paginated by page
+		query := &pagination.Query{Size: 2, Cursor: cursors.Encode(ranking.Rising, 5, primitive.NewObjectID().Hex())}
+
+		_, err := uc.GenerateFeeds(context.Background(), &models.FeedParams{Sort: ranking.Rising}, query)
+
+		require.Equal(t, customErrors.New(http.StatusBadRequest, ranking.ErrNoCursor), err)
+
+		repo.EXPECT().CountDocuments(gomock.Any(), gomock.Any()).Return(int64(10), nil)
+		repo.EXPECT().FindRanked(gomock.Any(), gomock.Any(), gomock.Any(), nil, gomock.Any()).Return(organic(2), nil)
+		repo.EXPECT().Aggregate(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+		feed, err := uc.GenerateFeeds(context.Background(), &models.FeedParams{Sort: ranking.Rising}, &pagination.Query{Size: 2, Page: 1})
+
+		require.NoError(t, err)
+		require.True(t, feed.HasMore)
+		require.Empty(t, feed.NextCursor)
+	})
+
+}
+
+func TestPostsUC_GenerateFeeds_Sort(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	uc := New(logger, repo, nil, nil, nil, nil, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
+
+	t.Run("top within a window", func(t *testing.T) {
+		repo.EXPECT().CountDocuments(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, filter bson.D) (int64, error) {
+				require.Len(t, filter, 2)
+				require.Equal(t, "created_at", filter[1].Key)
+				return 0, nil
+			})
+		repo.EXPECT().FindRanked(gomock.Any(), gomock.Any(), gomock.Any(), nil, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ bson.D, s ranking.Strategy, _ *pagination.Cursor, _ *pagination.Query) ([]*models.Post, error) {
+				require.Equal(t, "top:day", s.Name())
+				return nil, nil
+			})
+
+		_, err := uc.GenerateFeeds(context.Background(), &models.FeedParams{Sort: ranking.Top, Window: "day"}, &pagination.Query{})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("unknown sort", func(t *testing.T) {
+		_, err := uc.GenerateFeeds(context.Background(), &models.FeedParams{Sort: "best"}, &pagination.Query{})
+
+		require.Equal(t, customErrors.New(http.StatusBadRequest, ranking.ErrUnknownSort), err)
+	})
+
+}
+
+func TestPostsUC_GenerateFeeds_ContentFilter(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	subreddits := subredditsMock.NewMockRepository(ctrl)
+	uc := New(logger, repo, nil, nil, nil, subreddits, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
+
+	sfw := false
+	posts := []*models.Post{{Id: primitive.NewObjectID().Hex(), Score: new(int), Promoted: new(bool), NSFW: new(bool)}}
+
+	t.Run("organic and promoted posts", func(t *testing.T) {
+		filter := bson.D{{"promoted", false}, {"subreddit", bson.D{{"$nin", []string{"/r/a", "/r/b"}}}}, {"nsfw", false}}
+		promoted := bson.D{{"promoted", true}, {"subreddit", bson.D{{"$nin", []string{"/r/a", "/r/b"}}}}, {"nsfw", false}}
+
+		repo.EXPECT().CountDocuments(gomock.Any(), filter).Return(int64(1), nil)
+		repo.EXPECT().FindRanked(gomock.Any(), filter, gomock.Any(), nil, gomock.Any()).Return(posts, nil)
+		repo.EXPECT().Aggregate(gomock.Any(), bson.D{{"$match", promoted}}, gomock.Any()).Return(nil, nil)
+
+		params := &models.FeedParams{ContentFilter: models.ContentFilter{IncludeNSFW: &sfw, ExcludeSubreddits: []string{"/r/a", "/r/b"}}}
+		feed, err := uc.GenerateFeeds(context.Background(), params, &pagination.Query{})
+
+		require.NoError(t, err)
+		require.Equal(t, int64(1), feed.TotalCount)
+	})
+
+	t.Run("within a subreddit feed", func(t *testing.T) {
+		filter := bson.D{{"promoted", false}, {"subreddit", bson.D{{"$eq", "/r/golang"}, {"$in", []string{"/r/golang", "/r/rust"}}}}}
+
+		subreddits.EXPECT().FindByName(gomock.Any(), "/r/golang").Return(&subredditsModels.Subreddit{Name: "/r/golang"}, nil)
+		repo.EXPECT().CountDocuments(gomock.Any(), filter).Return(int64(0), nil)
+		repo.EXPECT().FindRanked(gomock.Any(), filter, gomock.Any(), nil, gomock.Any()).Return(nil, nil)
+
+		params := &models.FeedParams{Subreddit: "/r/golang", ContentFilter: models.ContentFilter{OnlySubreddits: []string{"/r/golang", "/r/rust"}}}
+		_, err := uc.GenerateFeeds(context.Background(), params, &pagination.Query{})
+
+		require.NoError(t, err)
+	})
+
+}
+
+func TestPostsUC_GenerateFeeds_Snapshot(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	cursors := pagination.NewCursorCodec("secret", time.Hour)
+	snapshots := snapshot.NewStore(time.Minute)
+	uc := New(logger, repo, nil, nil, nil, nil, cursors, placer, nil, snapshots)
+
+	ids := []primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()}
+	computedAt := time.Now().Add(-time.Second)
+	hot := &models.FeedParams{Sort: ranking.Hot}
+
+	snapshots.Set(snapshot.Global, &snapshot.Snapshot{
+		Entries:    []snapshot.Entry{{ids[0].Hex(), 3}, {ids[1].Hex(), 2}, {ids[2].Hex(), 1}},
+		Total:      10,
+		ComputedAt: computedAt,
+	})
+
+	t.Run("page of the snapshot", func(t *testing.T) {
+		found := []*models.Post{{Id: ids[1].Hex()}, {Id: ids[0].Hex()}}
+
+		repo.EXPECT().FindAll(gomock.Any(), bson.D{{"_id", bson.D{{"$in", bson.A{ids[0], ids[1]}}}}}, gomock.Any()).Return(found, nil)
+		repo.EXPECT().Aggregate(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+		feed, err := uc.GenerateFeeds(context.Background(), hot, &pagination.Query{Size: 2, Page: 1})
+		require.NoError(t, err)
+
+		require.Equal(t, []string{ids[0].Hex(), ids[1].Hex()}, []string{feed.Posts[0].Id, feed.Posts[1].Id})
+		require.Equal(t, float64(3), feed.Posts[0].Rank)
+		require.Equal(t, int64(10), feed.TotalCount)
+		require.True(t, feed.HasMore)
+		require.WithinDuration(t, computedAt, *feed.SnapshotAt, 0)
+
+		next, err := cursors.Decode(feed.NextCursor)
+		require.NoError(t, err)
+		require.Equal(t, ranking.Hot, next.Sort)
+		require.Equal(t, float64(2), next.Key)
+		require.Equal(t, ids[1].Hex(), next.ID)
+	})
+
+	t.Run("page past the snapshot", func(t *testing.T) {
+		query := &pagination.Query{Size: 2, Cursor: cursors.Encode(ranking.Hot, 2, ids[1].Hex())}
+
+		repo.EXPECT().CountDocuments(gomock.Any(), bson.D{{"promoted", false}}).Return(int64(10), nil)
+		repo.EXPECT().FindRanked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), query).Return(nil, nil)
+
+		feed, err := uc.GenerateFeeds(context.Background(), hot, query)
+		require.NoError(t, err)
+		require.Nil(t, feed.SnapshotAt)
+	})
+
+	t.Run("filtered by the reader", func(t *testing.T) {
+		sfw := false
+
+		repo.EXPECT().CountDocuments(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+		repo.EXPECT().FindRanked(gomock.Any(), gomock.Any(), gomock.Any(), nil, gomock.Any()).Return(nil, nil)
+
+		params := &models.FeedParams{Sort: ranking.Hot, ContentFilter: models.ContentFilter{IncludeNSFW: &sfw}}
+		_, err := uc.GenerateFeeds(context.Background(), params, &pagination.Query{Size: 2})
+		require.NoError(t, err)
+	})
+
+	t.Run("stale snapshot", func(t *testing.T) {
+		snapshots.Set(snapshot.Global, &snapshot.Snapshot{Total: 10, ComputedAt: time.Now().Add(-time.Hour)})
+
+		repo.EXPECT().CountDocuments(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+		repo.EXPECT().FindRanked(gomock.Any(), gomock.Any(), gomock.Any(), nil, gomock.Any()).Return(nil, nil)
+
+		feed, err := uc.GenerateFeeds(context.Background(), hot, &pagination.Query{Size: 2})
+		require.NoError(t, err)
+		require.Nil(t, feed.SnapshotAt)
+	})
+
+}
+
+func TestPostsUC_Delete(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	audit := mock.NewMockAuditRepository(ctrl)
+	uc := New(logger, repo, nil, audit, nil, nil, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
+
+	id := primitive.NewObjectID()
+
+	t.Run("author", func(t *testing.T) {
+		ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: "t2_author"})
+
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(&models.Post{Author: "t2_author"}, nil)
+		// the post is kept, only hidden
+		repo.EXPECT().SetStatus(gomock.Any(), id, models.StatusDeletedByAuthor).Return(&models.Post{Author: "t2_author", Status: models.StatusDeletedByAuthor}, nil)
+		audit.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, entry *models.AuditEntry) error {
+			require.Equal(t, id, entry.Post)
+			require.Equal(t, "t2_author", entry.Actor)
+			require.Equal(t, models.StatusActive, entry.From)
+			require.Equal(t, models.StatusDeletedByAuthor, entry.To)
+			return nil
+		})
+
+		require.NoError(t, uc.Delete(ctx, id))
+	})
+
+	t.Run("not the author", func(t *testing.T) {
+		ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: "t2_other"})
+
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(&models.Post{Author: "t2_author"}, nil)
+
+		require.Equal(t, customErrors.New(http.StatusForbidden, customErrors.Forbidden), uc.Delete(ctx, id))
+	})
+
+	t.Run("already deleted", func(t *testing.T) {
+		ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: "t2_author"})
+
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(&models.Post{Author: "t2_author", Status: models.StatusDeletedByAuthor}, nil)
+
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), uc.Delete(ctx, id))
+	})
+
+}
+
+func TestPostsUC_Moderate(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	audit := mock.NewMockAuditRepository(ctrl)
+	uc := New(logger, repo, nil, audit, nil, nil, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
+
+	id := primitive.NewObjectID()
+	ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: "t2_mod"})
+
+	t.Run("remove", func(t *testing.T) {
+		removed := &models.Post{Status: models.StatusRemovedByMod}
+
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(&models.Post{Status: models.StatusLocked}, nil)
+		repo.EXPECT().SetStatus(gomock.Any(), id, models.StatusRemovedByMod).Return(removed, nil)
+		audit.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, entry *models.AuditEntry) error {
+			require.Equal(t, "t2_mod", entry.Actor)
+			require.Equal(t, models.StatusLocked, entry.From)
+			require.Equal(t, models.StatusRemovedByMod, entry.To)
+			require.Equal(t, "spam", entry.Reason)
+			return nil
+		})
+
+		result, err := uc.Moderate(ctx, id, &models.Moderation{Status: models.StatusRemovedByMod, Reason: "spam"})
+
+		require.NoError(t, err)
+		require.Equal(t, removed, result)
+	})
+
+	t.Run("audit failure is not fatal", func(t *testing.T) {
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(&models.Post{}, nil)
+		repo.EXPECT().SetStatus(gomock.Any(), id, models.StatusLocked).Return(&models.Post{Status: models.StatusLocked}, nil)
+		audit.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("timeout"))
+
+		_, err := uc.Moderate(ctx, id, &models.Moderation{Status: models.StatusLocked})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("same status", func(t *testing.T) {
+		// posts without a status are active
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(&models.Post{}, nil)
+
+		_, err := uc.Moderate(ctx, id, &models.Moderation{Status: models.StatusActive})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("deleted by the author", func(t *testing.T) {
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(&models.Post{Status: models.StatusDeletedByAuthor}, nil)
+
+		_, err := uc.Moderate(ctx, id, &models.Moderation{Status: models.StatusActive})
+
+		require.Equal(t, customErrors.New(http.StatusConflict, models.ErrDeletedByAuthor), err)
+	})
+
+}
+
+func TestPostsUC_Create(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	subreddits := subredditsMock.NewMockRepository(ctrl)
+	uc := New(logger, repo, nil, nil, nil, subreddits, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
+
+	forged := 1000
+	post := &models.Post{
+		Title:     "title",
+		Author:    "t2_forged",
+		Link:      "https://www.example.com",
+		Subreddit: "/r/golang",
+		Score:     &forged,
+		Promoted:  new(bool),
+		NSFW:      new(bool),
+	}
+
+	t.Run("author from principal", func(t *testing.T) {
+		ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: "t2_author"})
+
+		subreddits.EXPECT().FindByName(gomock.Any(), "/r/golang").Return(&subredditsModels.Subreddit{Name: "/r/golang"}, nil)
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m *models.Post) (*models.Post, error) {
+			require.Equal(t, "t2_author", m.Author)
+			// only the votes change the score, new posts start at 0
+			require.Equal(t, 0, *m.Score)
+			return m, nil
+		})
+
+		_, err := uc.Create(ctx, post)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("no principal", func(t *testing.T) {
+		_, err := uc.Create(context.Background(), post)
+
+		require.Equal(t, customErrors.New(http.StatusUnauthorized, customErrors.Unauthorized), err)
+	})
+
+	t.Run("published to the stream", func(t *testing.T) {
+		broker := stream.NewBroker(config.StreamConfig{Buffer: 1, SlowClient: stream.SlowClientDisconnect, History: 1})
+		defer broker.Close()
+
+		sub, err := broker.Subscribe(stream.Filter{}, "")
+		require.NoError(t, err)
+
+		uc := New(logger, repo, nil, nil, nil, subreddits, pagination.NewCursorCodec("secret", time.Hour), placer, broker, nil)
+		ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: "t2_author"})
+
+		created := &models.Post{Id: primitive.NewObjectID().Hex(), Subreddit: "/r/golang"}
+		subreddits.EXPECT().FindByName(gomock.Any(), "/r/golang").Return(&subredditsModels.Subreddit{Name: "/r/golang"}, nil)
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(created, nil)
+
+		_, err = uc.Create(ctx, post)
+		require.NoError(t, err)
+
+		event := <-sub.Events()
+		require.Equal(t, stream.Event{ID: created.Id, Post: created}, event)
+	})
+
+}
+
+func TestPostsUC_CreateIdempotent(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	keys := mock.NewMockIdempotencyRepository(ctrl)
+	subreddits := subredditsMock.NewMockRepository(ctrl)
+	uc := New(logger, repo, nil, nil, keys, subreddits, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
+
+	ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: "t2_author"})
+
+	id := primitive.NewObjectID()
+	post := &models.Post{Id: id.Hex(), Title: "title", Subreddit: "/r/golang"}
+
+	t.Run("first request", func(t *testing.T) {
+		keys.EXPECT().Reserve(gomock.Any(), &models.IdempotencyKey{Id: "t2_author:key", BodyHash: "hash"}).Return(nil, nil)
+		subreddits.EXPECT().FindByName(gomock.Any(), "/r/golang").Return(&subredditsModels.Subreddit{Name: "/r/golang"}, nil)
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(post, nil)
+		keys.EXPECT().Complete(gomock.Any(), "t2_author:key", id.Hex()).Return(nil)
+
+		result, err := uc.CreateIdempotent(ctx, "key", "hash", &models.Post{Subreddit: "/r/golang"})
+
+		require.NoError(t, err)
+		require.Equal(t, post, result)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		keys.EXPECT().Reserve(gomock.Any(), gomock.Any()).Return(&models.IdempotencyKey{Id: "t2_author:key", BodyHash: "hash", PostId: id.Hex()}, nil)
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(post, nil)
+
+		result, err := uc.CreateIdempotent(ctx, "key", "hash", &models.Post{Subreddit: "/r/golang"})
+
+		require.NoError(t, err)
+		require.Equal(t, post, result)
+	})
+
+	t.Run("different body", func(t *testing.T) {
+		keys.EXPECT().Reserve(gomock.Any(), gomock.Any()).Return(&models.IdempotencyKey{Id: "t2_author:key", BodyHash: "hash", PostId: id.Hex()}, nil)
+
+		_, err := uc.CreateIdempotent(ctx, "key", "other", &models.Post{Subreddit: "/r/golang"})
+
+		require.Equal(t, customErrors.New(http.StatusUnprocessableEntity, models.ErrIdempotencyKeyReused), err)
+	})
+
+	t.Run("in progress", func(t *testing.T) {
+		keys.EXPECT().Reserve(gomock.Any(), gomock.Any()).Return(&models.IdempotencyKey{Id: "t2_author:key", BodyHash: "hash"}, nil)
+
+		_, err := uc.CreateIdempotent(ctx, "key", "hash", &models.Post{Subreddit: "/r/golang"})
+
+		require.Equal(t, customErrors.New(http.StatusConflict, models.ErrIdempotencyKeyInProgress), err)
+	})
+
+	t.Run("failed create releases the key", func(t *testing.T) {
+		keys.EXPECT().Reserve(gomock.Any(), gomock.Any()).Return(nil, nil)
+		subreddits.EXPECT().FindByName(gomock.Any(), "/r/unknown").Return(nil, customErrors.New(http.StatusNotFound, customErrors.NotFound))
+		keys.EXPECT().Release(gomock.Any(), "t2_author:key").Return(nil)
+
+		_, err := uc.CreateIdempotent(ctx, "key", "hash", &models.Post{Subreddit: "/r/unknown"})
+
+		require.Equal(t, customErrors.New(http.StatusBadRequest, models.ErrUnknownSubreddit), err)
+	})
+
+	t.Run("no principal", func(t *testing.T) {
+		_, err := uc.CreateIdempotent(context.Background(), "key", "hash", post)
+
+		require.Equal(t, customErrors.New(http.StatusUnauthorized, customErrors.Unauthorized), err)
+	})
+
+}
+
+func TestPostsUC_Import(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	subreddits := subredditsMock.NewMockRepository(ctrl)
+	uc := New(logger, repo, nil, nil, nil, subreddits, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
+
+	ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: "t2_author"})
+
+	newPosts := func() []*models.Post {
+		return []*models.Post{
+			{Title: "title1", Author: "t2_forged", Subreddit: "/r/golang"},
+			{Title: "title2", Subreddit: "/r/unknown"},
+			{Title: "title3", Subreddit: "/r/golang"},
+			{Title: "title4", Subreddit: "/r/golang"},
+		}
+	}
+
+	t.Run("partial failure", func(t *testing.T) {
+		// looked up once per import
+		subreddits.EXPECT().FindByName(gomock.Any(), "/r/golang").Return(&subredditsModels.Subreddit{Name: "/r/golang"}, nil)
+		subreddits.EXPECT().FindByName(gomock.Any(), "/r/unknown").Return(nil, customErrors.New(http.StatusNotFound, customErrors.NotFound))
+
+		repo.EXPECT().CreateMany(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, batch []*models.Post) (map[int]error, error) {
+			require.Len(t, batch, 3)
+			for i, m := range batch {
+				require.Equal(t, "t2_author", m.Author)
+				require.Equal(t, models.StatusActive, m.Status)
+				m.Id = primitive.NewObjectID().Hex()
+				if i == 1 {
+					m.Id = ""
+				}
+			}
+			return map[int]error{1: errors.New("write error")}, nil
+		})
+
+		posts := newPosts()
+		results, err := uc.Import(ctx, posts)
+
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+
+		require.Equal(t, &models.ImportResult{Index: 0, Id: posts[0].Id}, results[0])
+		require.Equal(t, []customErrors.ErrorValidation{{Message: models.ErrUnknownSubreddit.Error()}}, results[1].Errors)
+		require.Equal(t, []customErrors.ErrorValidation{{Message: customErrors.InternalServerError.Error()}}, results[2].Errors)
+		require.Equal(t, &models.ImportResult{Index: 3, Id: posts[3].Id}, results[3])
+	})
+
+	t.Run("failed batch", func(t *testing.T) {
+		subreddits.EXPECT().FindByName(gomock.Any(), gomock.Any()).Return(&subredditsModels.Subreddit{}, nil).Times(2)
+		repo.EXPECT().CreateMany(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+
+		results, err := uc.Import(ctx, newPosts())
+
+		require.NoError(t, err)
+		for _, result := range results {
+			require.Empty(t, result.Id)
+			require.NotEmpty(t, result.Errors)
+		}
+	})
+
+	t.Run("batches", func(t *testing.T) {
+		posts := make([]*models.Post, importBatchSize+1)
+		for i := range posts {
+			posts[i] = &models.Post{Subreddit: "/r/golang"}
+		}
+
+		subreddits.EXPECT().FindByName(gomock.Any(), "/r/golang").Return(&subredditsModels.Subreddit{Name: "/r/golang"}, nil)
+		repo.EXPECT().CreateMany(gomock.Any(), gomock.Len(importBatchSize)).Return(nil, nil)
+		repo.EXPECT().CreateMany(gomock.Any(), gomock.Len(1)).Return(nil, nil)
+
+		results, err := uc.Import(ctx, posts)
+
+		require.NoError(t, err)
+		require.Len(t, results, importBatchSize+1)
+		require.Equal(t, importBatchSize, results[importBatchSize].Index)
+	})
+
+	t.Run("no principal", func(t *testing.T) {
+		_, err := uc.Import(context.Background(), newPosts())
+
+		require.Equal(t, customErrors.New(http.StatusUnauthorized, customErrors.Unauthorized), err)
+	})
+
+}
+
+func TestPostsUC_ListByAuthor(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	uc := New(logger, repo, nil, nil, nil, nil, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
+
+	filter := bson.D{{"author", "t2_author"}}
+	query := &pagination.Query{Page: 1, Size: 1}
+	posts := []*models.Post{{Author: "t2_author"}}
+
+	repo.EXPECT().CountDocuments(gomock.Any(), filter).Return(int64(3), nil)
+	repo.EXPECT().FindRanked(gomock.Any(), filter, gomock.Any(), nil, query).
+		DoAndReturn(func(_ context.Context, _ bson.D, s ranking.Strategy, _ *pagination.Cursor, _ *pagination.Query) ([]*models.Post, error) {
+			require.Equal(t, ranking.New, s.Name())
+			return posts, nil
+		})
+
+	res, err := uc.ListByAuthor(context.Background(), "t2_author", query)
+
+	require.NoError(t, err)
+	require.Equal(t, &models.Feed{TotalCount: 3, TotalPages: 3, Page: 1, Size: 1, HasMore: true, Posts: posts}, res)
+
+}
+
+func TestPostsUC_Search(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	subreddits := subredditsMock.NewMockRepository(ctrl)
+	cursors := pagination.NewCursorCodec("secret", time.Hour)
+	uc := New(logger, repo, nil, nil, nil, subreddits, cursors, placer, nil, nil)
+
+	text := bson.E{"$text", bson.D{{"$search", "golang generics"}}}
+
+	t.Run("no promoted posts", func(t *testing.T) {
+		filter := bson.D{text, {"promoted", false}}
+		query := &pagination.Query{Page: 1, Size: 1}
+		posts := []*models.Post{{Id: primitive.NewObjectID().Hex(), Title: "golang generics", Rank: 1.5}}
+
+		repo.EXPECT().CountDocuments(gomock.Any(), filter).Return(int64(2), nil)
+		repo.EXPECT().FindRanked(gomock.Any(), filter, gomock.Any(), nil, query).
+			DoAndReturn(func(_ context.Context, _ bson.D, s ranking.Strategy, _ *pagination.Cursor, _ *pagination.Query) ([]*models.Post, error) {
+				require.Equal(t, ranking.Relevance, s.Name())
+				return posts, nil
+			})
+		// the placer samples promoted posts for the feeds, never for the search
+		repo.EXPECT().Aggregate(gomock.Any(), gomock.Any()).Times(0)
+
+		res, err := uc.Search(context.Background(), &models.SearchParams{Query: "golang generics"}, query)
+
+		require.NoError(t, err)
+		require.Equal(t, posts, res.Posts)
+		require.True(t, res.HasMore)
+
+		next, err := cursors.Decode(res.NextCursor)
+		require.NoError(t, err)
+		require.Equal(t, ranking.Relevance, next.Sort)
+		require.Equal(t, 1.5, next.Key)
+	})
+
+	t.Run("subreddit and nsfw", func(t *testing.T) {
+		nsfw := false
+		filter := bson.D{text, {"promoted", false}, {"subreddit", "/r/golang"}, {"nsfw", false}}
+
+		subreddits.EXPECT().FindByName(gomock.Any(), "/r/golang").Return(&subredditsModels.Subreddit{Name: "/r/golang"}, nil)
+		repo.EXPECT().CountDocuments(gomock.Any(), filter).Return(int64(0), nil)
+		repo.EXPECT().FindRanked(gomock.Any(), filter, gomock.Any(), nil, gomock.Any()).Return(nil, nil)
+
+		res, err := uc.Search(context.Background(), &models.SearchParams{Query: "golang generics", Subreddit: "/r/golang", NSFW: &nsfw}, &pagination.Query{})
+
+		require.NoError(t, err)
+		require.False(t, res.HasMore)
+	})
+
+	t.Run("cursor of a feed", func(t *testing.T) {
+		query := &pagination.Query{Size: 2, Cursor: cursors.Encode("top:all", 50, primitive.NewObjectID().Hex())}
+
+		_, err := uc.Search(context.Background(), &models.SearchParams{Query: "golang"}, query)
+
+		require.Equal(t, customErrors.New(http.StatusBadRequest, pagination.ErrInvalidCursor), err)
+	})
+
+}
+
+func TestPostsUC_Subreddit(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	subreddits := subredditsMock.NewMockRepository(ctrl)
+	uc := New(logger, repo, nil, nil, nil, subreddits, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
+
+	notFound := customErrors.New(http.StatusNotFound, customErrors.NotFound)
+
+	post := func() *models.Post {
+		return &models.Post{
+			Title:     "title",
+			Author:    "t2_author",
+			Link:      "https://www.example.com",
+			Subreddit: "/r/golang",
+			Score:     new(int),
+			Promoted:  new(bool),
+			NSFW:      new(bool),
+		}
+	}
+
+	ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: "t2_author"})
+
+	t.Run("create in known subreddit", func(t *testing.T) {
+		subreddits.EXPECT().FindByName(gomock.Any(), "/r/golang").Return(&subredditsModels.Subreddit{Name: "/r/golang"}, nil)
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(post(), nil)
+
+		_, err := uc.Create(ctx, post())
+
+		require.NoError(t, err)
+	})
+
+	t.Run("create in unknown subreddit", func(t *testing.T) {
+		subreddits.EXPECT().FindByName(gomock.Any(), "/r/golang").Return(nil, notFound)
+
+		_, err := uc.Create(ctx, post())
+
+		require.Equal(t, customErrors.New(http.StatusBadRequest, models.ErrUnknownSubreddit), err)
+	})
+
+	t.Run("patch into unknown subreddit", func(t *testing.T) {
+		id := primitive.NewObjectID()
+		name := "/r/missing"
+
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(post(), nil)
+		subreddits.EXPECT().FindByName(gomock.Any(), name).Return(nil, notFound)
+
+		_, err := uc.Patch(ctx, id, &models.PostPatch{Subreddit: &name})
+
+		require.Equal(t, customErrors.New(http.StatusBadRequest, models.ErrUnknownSubreddit), err)
+	})
+
+	t.Run("feed scoped to subreddit", func(t *testing.T) {
+		subreddits.EXPECT().FindByName(gomock.Any(), "/r/golang").Return(&subredditsModels.Subreddit{Name: "/r/golang"}, nil)
+		repo.EXPECT().CountDocuments(gomock.Any(), bson.D{{"promoted", false}, {"subreddit", "/r/golang"}}).Return(int64(1), nil)
+		repo.EXPECT().FindRanked(gomock.Any(), bson.D{{"promoted", false}, {"subreddit", "/r/golang"}}, gomock.Any(), nil, gomock.Any()).Return([]*models.Post{post()}, nil)
+		// the ads are scoped to the subreddit as well
+		repo.EXPECT().Aggregate(gomock.Any(), bson.D{{"$match", bson.D{{"promoted", true}, {"subreddit", "/r/golang"}}}}, gomock.Any()).Return(nil, nil)
+
+		_, err := uc.GenerateFeeds(context.Background(), &models.FeedParams{Subreddit: "/r/golang"}, &pagination.Query{})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("feed of unknown subreddit", func(t *testing.T) {
+		subreddits.EXPECT().FindByName(gomock.Any(), "/r/missing").Return(nil, notFound)
+
+		_, err := uc.GenerateFeeds(context.Background(), &models.FeedParams{Subreddit: "/r/missing"}, &pagination.Query{})
+
+		require.Equal(t, notFound, err)
+	})
+
+}
+
+func TestPostsUC_Vote(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	votes := mock.NewMockVoteRepository(ctrl)
+	uc := New(logger, repo, votes, nil, nil, nil, pagination.NewCursorCodec("secret", time.Hour), placer, nil, nil)
+
+	id := primitive.NewObjectID()
+	post := &models.Post{Id: id.Hex(), Score: new(int)}
+
+	cases := []struct {
+		name                string
+		previous, direction int
+		score, ups, downs   int
+	}{
+		{name: "first up vote", previous: 0, direction: 1, score: 1, ups: 1},
+		{name: "first down vote", previous: 0, direction: -1, score: -1, downs: 1},
+		{name: "up to down", previous: 1, direction: -1, score: -2, ups: -1, downs: 1},
+		{name: "down to up", previous: -1, direction: 1, score: 2, ups: 1, downs: -1},
+		{name: "clear up vote", previous: 1, direction: 0, score: -1, ups: -1},
+		{name: "clear down vote", previous: -1, direction: 0, score: 1, downs: -1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo.EXPECT().FindByID(gomock.Any(), id).Return(post, nil)
+			votes.EXPECT().Upsert(gomock.Any(), "t2_user", id, c.direction).Return(c.previous, nil)
+			repo.EXPECT().IncrementScore(gomock.Any(), id, c.score, c.ups, c.downs).Return(post, nil)
+
+			_, err := uc.Vote(context.Background(), id, "t2_user", c.direction)
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("repeated vote", func(t *testing.T) {
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(post, nil)
+		votes.EXPECT().Upsert(gomock.Any(), "t2_user", id, 1).Return(1, nil)
+
+		result, err := uc.Vote(context.Background(), id, "t2_user", 1)
+
+		require.NoError(t, err)
+		require.Equal(t, post, result)
+	})
+
+	t.Run("failed score update", func(t *testing.T) {
+		failure := errors.New("timeout")
+
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(post, nil)
+		votes.EXPECT().Upsert(gomock.Any(), "t2_user", id, 1).Return(-1, nil)
+		repo.EXPECT().IncrementScore(gomock.Any(), id, 2, 1, -1).Return(nil, failure)
+		// the vote is set back, so a retry counts it
+		votes.EXPECT().Upsert(gomock.Any(), "t2_user", id, -1).Return(1, nil)
+
+		_, err := uc.Vote(context.Background(), id, "t2_user", 1)
+
+		require.Equal(t, failure, err)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		notFound := customErrors.New(http.StatusNotFound, customErrors.NotFound)
+
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(nil, notFound)
+
+		_, err := uc.Vote(context.Background(), id, "t2_user", 1)
+
+		require.Equal(t, notFound, err)
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(&models.Post{Id: id.Hex(), Status: models.StatusLocked}, nil)
+
+		_, err := uc.Vote(context.Background(), id, "t2_user", 1)
+
+		require.Equal(t, customErrors.New(http.StatusForbidden, models.ErrLocked), err)
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		repo.EXPECT().FindByID(gomock.Any(), id).Return(&models.Post{Id: id.Hex(), Status: models.StatusRemovedByMod}, nil)
+
+		_, err := uc.Vote(context.Background(), id, "t2_user", 1)
+
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+	})
 
 }
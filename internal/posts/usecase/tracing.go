@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"github.com/aliykh/reddit-feed/internal/posts"
+	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/stream"
+	"github.com/aliykh/reddit-feed/internal/tracing"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedUC - starts a span around every call of the wrapped use case,
+// the repository calls and mongo commands of the call become its children.
+type tracedUC struct {
+	next posts.UseCase
+}
+
+func NewTraced(next posts.UseCase) *tracedUC {
+	return &tracedUC{next: next}
+}
+
+func (t *tracedUC) Create(ctx context.Context, model *models.Post) (result *models.Post, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.Create", trace.WithAttributes(attribute.String("subreddit", model.Subreddit)))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.Create(ctx, model)
+}
+
+func (t *tracedUC) CreateIdempotent(ctx context.Context, key, bodyHash string, model *models.Post) (result *models.Post, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.CreateIdempotent", trace.WithAttributes(attribute.String("subreddit", model.Subreddit)))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.CreateIdempotent(ctx, key, bodyHash, model)
+}
+
+func (t *tracedUC) Import(ctx context.Context, posts []*models.Post) (result []*models.ImportResult, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.Import", trace.WithAttributes(attribute.Int("posts", len(posts))))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.Import(ctx, posts)
+}
+
+func (t *tracedUC) GenerateFeeds(ctx context.Context, params *models.FeedParams, query *pagination.Query) (result *models.Feed, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.GenerateFeeds", trace.WithAttributes(
+		attribute.String("sort", params.Sort),
+		attribute.String("subreddit", params.Subreddit),
+		attribute.Int("page", query.GetPage()),
+		attribute.Bool("cursor", query.IsCursor()),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.GenerateFeeds(ctx, params, query)
+}
+
+func (t *tracedUC) Search(ctx context.Context, params *models.SearchParams, query *pagination.Query) (result *models.Feed, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.Search", trace.WithAttributes(
+		attribute.String("subreddit", params.Subreddit),
+		attribute.Int("page", query.GetPage()),
+		attribute.Bool("cursor", query.IsCursor()),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.Search(ctx, params, query)
+}
+
+func (t *tracedUC) ListByAuthor(ctx context.Context, author string, query *pagination.Query) (result *models.Feed, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.ListByAuthor", trace.WithAttributes(attribute.String("author", author)))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.ListByAuthor(ctx, author, query)
+}
+
+func (t *tracedUC) Subscribe(ctx context.Context, params *models.StreamParams, lastEventID string) (result *stream.Subscription, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.Subscribe", trace.WithAttributes(
+		attribute.String("subreddit", params.Subreddit),
+		attribute.Bool("resume", lastEventID != ""),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.Subscribe(ctx, params, lastEventID)
+}
+
+func (t *tracedUC) GetByID(ctx context.Context, id primitive.ObjectID) (result *models.Post, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.GetByID", trace.WithAttributes(attribute.String("post", id.Hex())))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.GetByID(ctx, id)
+}
+
+func (t *tracedUC) Update(ctx context.Context, id primitive.ObjectID, model *models.Post) (result *models.Post, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.Update", trace.WithAttributes(attribute.String("post", id.Hex())))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.Update(ctx, id, model)
+}
+
+func (t *tracedUC) Patch(ctx context.Context, id primitive.ObjectID, patch *models.PostPatch) (result *models.Post, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.Patch", trace.WithAttributes(attribute.String("post", id.Hex())))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.Patch(ctx, id, patch)
+}
+
+func (t *tracedUC) Delete(ctx context.Context, id primitive.ObjectID) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.Delete", trace.WithAttributes(attribute.String("post", id.Hex())))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.Delete(ctx, id)
+}
+
+func (t *tracedUC) Vote(ctx context.Context, id primitive.ObjectID, user string, direction int) (result *models.Post, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.Vote", trace.WithAttributes(attribute.String("post", id.Hex()), attribute.Int("direction", direction)))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.Vote(ctx, id, user, direction)
+}
+
+func (t *tracedUC) Moderate(ctx context.Context, id primitive.ObjectID, moderation *models.Moderation) (result *models.Post, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.Moderate", trace.WithAttributes(
+		attribute.String("post", id.Hex()),
+		attribute.String("status", moderation.Status),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.Moderate(ctx, id, moderation)
+}
+
+func (t *tracedUC) AuditLog(ctx context.Context, id primitive.ObjectID) (result []*models.AuditEntry, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "postsUC.AuditLog", trace.WithAttributes(attribute.String("post", id.Hex())))
+	defer func() { tracing.End(span, err) }()
+
+	return t.next.AuditLog(ctx, id)
+}
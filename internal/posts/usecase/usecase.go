@@ -3,62 +3,659 @@ package usecase
 import (
 	"context"
 	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/auth"
+	"github.com/aliykh/reddit-feed/internal/metrics"
 	"github.com/aliykh/reddit-feed/internal/posts/models"
+	"github.com/aliykh/reddit-feed/internal/posts/placement"
+	"github.com/aliykh/reddit-feed/internal/posts/ranking"
 	"github.com/aliykh/reddit-feed/internal/posts/repository"
+	"github.com/aliykh/reddit-feed/internal/posts/snapshot"
+	"github.com/aliykh/reddit-feed/internal/posts/stream"
+	subredditsRepository "github.com/aliykh/reddit-feed/internal/subreddits/repository"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
 	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
 )
 
+// importBatchSize - posts inserted by a single write of an import
+const importBatchSize = 500
+
 type postsUC struct {
-	logger *log.Factory
-	repo   repository.Repository
+	logger     *log.Factory
+	repo       repository.Repository
+	votes      repository.VoteRepository
+	audit      repository.AuditRepository
+	keys       repository.IdempotencyRepository
+	subreddits subredditsRepository.Repository
+	cursors    *pagination.CursorCodec
+	placer     *placement.Placer
+	// events - created posts are published to the event stream
+	events *stream.Broker
+	// snapshots - precomputed hot feeds, nil reads every feed live
+	snapshots *snapshot.Store
 }
 
-func New(logger *log.Factory, repo repository.Repository) *postsUC {
+func New(logger *log.Factory, repo repository.Repository, votes repository.VoteRepository, audit repository.AuditRepository, keys repository.IdempotencyRepository, subreddits subredditsRepository.Repository, cursors *pagination.CursorCodec, placer *placement.Placer, events *stream.Broker, snapshots *snapshot.Store) *postsUC {
 	return &postsUC{
-		logger: logger,
-		repo:   repo,
+		logger:     logger,
+		repo:       repo,
+		votes:      votes,
+		audit:      audit,
+		keys:       keys,
+		subreddits: subreddits,
+		cursors:    cursors,
+		placer:     placer,
+		events:     events,
+		snapshots:  snapshots,
 	}
 }
 
+// Create - creates a post authored by the principal of the request.
 func (p *postsUC) Create(ctx context.Context, model *models.Post) (*models.Post, error) {
-	model.GenerateAuthorName()
-	return p.repo.Create(ctx, model)
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, customErrors.New(http.StatusUnauthorized, customErrors.Unauthorized)
+	}
+
+	if err := p.checkSubreddit(ctx, model.Subreddit); err != nil {
+		return nil, err
+	}
+
+	model.Author = principal.UserID
+	model.CreatedAt = time.Now().UTC()
+	model.Score, model.Ups, model.Downs, model.CommentCount = new(int), 0, 0, 0
+	model.Status = models.StatusActive
+
+	result, err := p.repo.Create(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(result)
+	return result, nil
+}
+
+// CreateIdempotent - creates the post once per idempotency key of the principal. A retry with the same body gets
+// the post created first, a different body or a retry while the first request is in progress is rejected.
+func (p *postsUC) CreateIdempotent(ctx context.Context, key, bodyHash string, model *models.Post) (*models.Post, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, customErrors.New(http.StatusUnauthorized, customErrors.Unauthorized)
+	}
+
+	record := &models.IdempotencyKey{Id: principal.UserID + ":" + key, BodyHash: bodyHash}
+
+	existing, err := p.keys.Reserve(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return p.replay(ctx, existing, bodyHash)
+	}
+
+	result, err := p.Create(ctx, model)
+	if err != nil {
+		// nothing was created, the request may be retried with the same key
+		if releaseErr := p.keys.Release(ctx, record.Id); releaseErr != nil {
+			p.logger.For(ctx).Error("idempotency key release", zap.String("key", record.Id), zap.String("err", releaseErr.Error()))
+		}
+		return nil, err
+	}
+
+	// the post is created either way, a retry after the reservation expires would create it again
+	if err = p.keys.Complete(ctx, record.Id, result.Id); err != nil {
+		p.logger.For(ctx).Error("idempotency key complete", zap.String("key", record.Id), zap.String("post", result.Id), zap.String("err", err.Error()))
+	}
+
+	return result, nil
+}
+
+// replay - the post created by the first request of the key.
+func (p *postsUC) replay(ctx context.Context, key *models.IdempotencyKey, bodyHash string) (*models.Post, error) {
+	if key.BodyHash != bodyHash {
+		return nil, customErrors.New(http.StatusUnprocessableEntity, models.ErrIdempotencyKeyReused)
+	}
+	if key.PostId == "" {
+		return nil, customErrors.New(http.StatusConflict, models.ErrIdempotencyKeyInProgress)
+	}
+
+	id, err := primitive.ObjectIDFromHex(key.PostId)
+	if err != nil {
+		return nil, errors.Wrap(err, "postsUC.replay")
+	}
+
+	return p.repo.FindByID(ctx, id)
+}
+
+// Import - creates the posts authored by the principal of the request, a failed post does not stop the others.
+// The results follow the order of the posts, their indexes are positions in the given slice.
+func (p *postsUC) Import(ctx context.Context, posts []*models.Post) ([]*models.ImportResult, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, customErrors.New(http.StatusUnauthorized, customErrors.Unauthorized)
+	}
+
+	results := make([]*models.ImportResult, len(posts))
+	pending := make([]int, 0, len(posts))
+
+	// an import usually targets a handful of subreddits, each of them is looked up once
+	subreddits := make(map[string]error)
+	now := time.Now().UTC()
+
+	for i, model := range posts {
+		results[i] = &models.ImportResult{Index: i}
+
+		err, ok := subreddits[model.Subreddit]
+		if !ok {
+			err = p.checkSubreddit(ctx, model.Subreddit)
+			subreddits[model.Subreddit] = err
+		}
+		if err != nil {
+			results[i].Errors = customErrors.Validations(err)
+			continue
+		}
+
+		model.Author = principal.UserID
+		model.CreatedAt = now
+		model.Score, model.Ups, model.Downs, model.CommentCount = new(int), 0, 0, 0
+		model.Status = models.StatusActive
+		pending = append(pending, i)
+	}
+
+	for start := 0; start < len(pending); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+
+		indexes := pending[start:end]
+		batch := make([]*models.Post, len(indexes))
+		for j, i := range indexes {
+			batch[j] = posts[i]
+		}
+
+		failed, err := p.repo.CreateMany(ctx, batch)
+		if err != nil {
+			// the rest of the import is still attempted, the posts of this batch are reported as failed
+			p.logger.For(ctx).Error("post import batch", zap.Int("posts", len(batch)), zap.String("err", err.Error()))
+
+			failed = make(map[int]error, len(batch))
+			for j := range batch {
+				failed[j] = err
+			}
+		}
+
+		for j, i := range indexes {
+			if err, ok := failed[j]; ok {
+				results[i].Errors = customErrors.Validations(err)
+				continue
+			}
+			results[i].Id = posts[i].Id
+			p.publish(posts[i])
+		}
+	}
+
+	return results, nil
+}
+
+// Subscribe - events of the created posts matching the params, after the lastEventID ones when it is set.
+func (p *postsUC) Subscribe(ctx context.Context, params *models.StreamParams, lastEventID string) (*stream.Subscription, error) {
+	sub, err := p.events.Subscribe(stream.Filter{Subreddit: params.Subreddit, NSFW: params.NSFW}, lastEventID)
+	if errors.Is(err, stream.ErrClosed) {
+		return nil, customErrors.New(http.StatusServiceUnavailable, err)
+	}
+
+	return sub, err
+}
+
+// publish - sends the created post to the clients of the event stream.
+func (p *postsUC) publish(post *models.Post) {
+	if p.events != nil {
+		p.events.Publish(post)
+	}
+}
+
+// GetByID - returns the post unless it is deleted or removed.
+func (p *postsUC) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Post, error) {
+	return p.findVisible(ctx, id)
+}
+
+// Update - replaces the post, only its author may do so.
+func (p *postsUC) Update(ctx context.Context, id primitive.ObjectID, model *models.Post) (*models.Post, error) {
+	if err := model.CheckValidity(); err != nil {
+		return nil, err
+	}
+	if _, err := p.findOwned(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := p.checkSubreddit(ctx, model.Subreddit); err != nil {
+		return nil, err
+	}
+	return p.repo.Update(ctx, id, model)
+}
+
+// Patch - applies a partial update on top of the stored post, the merged post has to stay valid.
+func (p *postsUC) Patch(ctx context.Context, id primitive.ObjectID, patch *models.PostPatch) (*models.Post, error) {
+	model, err := p.findOwned(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	patch.Apply(model)
+
+	if err = model.CheckValidity(); err != nil {
+		return nil, err
+	}
+
+	if patch.Subreddit != nil {
+		if err = p.checkSubreddit(ctx, model.Subreddit); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.repo.Update(ctx, id, model)
+}
+
+// Delete - hides the post as deleted by its author, only its author may do so. The post is kept for the audit.
+func (p *postsUC) Delete(ctx context.Context, id primitive.ObjectID) error {
+	post, err := p.findOwned(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.changeStatus(ctx, id, post, post.Author, models.StatusDeletedByAuthor, "")
+	return err
+}
+
+// Moderate - sets the status of the post on behalf of a moderator, the caller is expected to be one.
+// Setting the current status again is a no-op, posts deleted by their authors stay deleted.
+func (p *postsUC) Moderate(ctx context.Context, id primitive.ObjectID, moderation *models.Moderation) (*models.Post, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, customErrors.New(http.StatusUnauthorized, customErrors.Unauthorized)
+	}
+
+	post, err := p.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch statusOf(post) {
+	case models.StatusDeletedByAuthor:
+		return nil, customErrors.New(http.StatusConflict, models.ErrDeletedByAuthor)
+	case moderation.Status:
+		return post, nil
+	}
+
+	return p.changeStatus(ctx, id, post, principal.UserID, moderation.Status, moderation.Reason)
+}
+
+// AuditLog - status changes of the post, hidden posts included.
+func (p *postsUC) AuditLog(ctx context.Context, id primitive.ObjectID) ([]*models.AuditEntry, error) {
+	if _, err := p.repo.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return p.audit.FindByPost(ctx, id)
+}
+
+// changeStatus - sets the status of the post and records the change in the audit log.
+func (p *postsUC) changeStatus(ctx context.Context, id primitive.ObjectID, post *models.Post, actor, status, reason string) (*models.Post, error) {
+	result, err := p.repo.SetStatus(ctx, id, status)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.AuditEntry{
+		Post:      id,
+		Actor:     actor,
+		From:      statusOf(post),
+		To:        status,
+		Reason:    reason,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	// the status is already changed, a failed audit write must not fail the request
+	if err = p.audit.Create(ctx, entry); err != nil {
+		p.logger.For(ctx).Error("post audit", zap.String("post", id.Hex()), zap.String("actor", actor), zap.String("to", status), zap.String("err", err.Error()))
+	}
+
+	return result, nil
+}
+
+// findVisible - returns the post, deleted and removed posts are not found.
+func (p *postsUC) findVisible(ctx context.Context, id primitive.ObjectID) (*models.Post, error) {
+	post, err := p.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !post.Visible() {
+		return nil, customErrors.New(http.StatusNotFound, customErrors.NotFound)
+	}
+
+	return post, nil
+}
+
+// findOwned - returns the post if the principal of the request is its author.
+func (p *postsUC) findOwned(ctx context.Context, id primitive.ObjectID) (*models.Post, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, customErrors.New(http.StatusUnauthorized, customErrors.Unauthorized)
+	}
+
+	post, err := p.findVisible(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if post.Author != principal.UserID {
+		return nil, customErrors.New(http.StatusForbidden, customErrors.Forbidden)
+	}
+
+	return post, nil
+}
+
+// Vote - sets the user's vote on the post, repeating the same vote is a no-op.
+// direction is +1, -1 or 0 to clear the vote. Locked posts take no votes.
+func (p *postsUC) Vote(ctx context.Context, id primitive.ObjectID, user string, direction int) (*models.Post, error) {
+	post, err := p.findVisible(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if post.Status == models.StatusLocked {
+		return nil, customErrors.New(http.StatusForbidden, models.ErrLocked)
+	}
+
+	previous, err := p.votes.Upsert(ctx, user, id, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous == direction {
+		return post, nil
+	}
+
+	ups := boolToInt(direction > 0) - boolToInt(previous > 0)
+	downs := boolToInt(direction < 0) - boolToInt(previous < 0)
+
+	result, err := p.repo.IncrementScore(ctx, id, direction-previous, ups, downs)
+	if err != nil {
+		// the vote is stored but not counted, it is set back so that a retry counts it rather than being a no-op.
+		// A standalone mongo, like the one of docker-compose, has no transactions to write both at once
+		if _, undoErr := p.votes.Upsert(ctx, user, id, previous); undoErr != nil {
+			p.logger.For(ctx).Error("vote rollback", zap.String("post", id.Hex()), zap.String("user", user), zap.String("err", undoErr.Error()))
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (p *postsUC) GenerateFeeds(ctx context.Context, params *models.FeedParams, query *pagination.Query) (*models.Feed, error) {
+
+	strategy, err := ranking.Get(params.Sort, params.Window)
+	if err != nil {
+		return nil, customErrors.New(http.StatusBadRequest, err)
+	}
+
+	after, err := p.decodeCursor(strategy, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Subreddit != "" {
+		// an unknown subreddit is a missing resource rather than an empty feed
+		if _, err = p.subreddits.FindByName(ctx, params.Subreddit); err != nil {
+			return nil, err
+		}
+	}
+
+	filter := bson.D{{"promoted", false}}
+	filter = append(filter, contentMatch(params.ContentFilter, params.Subreddit)...)
+	filter = append(filter, strategy.Match(time.Now())...)
+
+	feed, err := p.snapshotPage(ctx, params, strategy, after, query)
+	if err == nil && feed == nil {
+		feed, err = p.rankedPage(ctx, filter, strategy, after, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if sample := p.placer.Sample(); sample > 0 && len(feed.Posts) > 0 {
+		// the ads follow the content filter of the reader and the subreddit of the feed
+		matchStage := bson.D{{"$match", append(bson.D{{"promoted", true}}, contentMatch(params.ContentFilter, params.Subreddit)...)}}
+		sampleStage := bson.D{{"$sample", bson.D{{"size", sample}}}}
+
+		promotedPosts, err := p.repo.Aggregate(ctx, matchStage, sampleStage)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var res placement.Result
+		feed.Posts, res = p.placer.Place(feed.Posts, promotedPosts)
+		feed.Size = len(feed.Posts)
+		metrics.ObservePlacement(res)
+	}
+
+	return feed, nil
+}
+
+// Search - full-text search over the titles and contents of the organic posts, ranked by relevance.
+// Unlike the feeds no promoted posts are placed into the results.
+func (p *postsUC) Search(ctx context.Context, params *models.SearchParams, query *pagination.Query) (*models.Feed, error) {
+	strategy := ranking.Search()
+
+	after, err := p.decodeCursor(strategy, query)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{{"$text", bson.D{{"$search", params.Query}}}, {"promoted", false}}
+
+	if params.Subreddit != "" {
+		if _, err = p.subreddits.FindByName(ctx, params.Subreddit); err != nil {
+			return nil, err
+		}
+		filter = append(filter, bson.E{"subreddit", params.Subreddit})
+	}
+
+	if params.NSFW != nil {
+		filter = append(filter, bson.E{"nsfw", *params.NSFW})
+	}
+
+	return p.rankedPage(ctx, filter, strategy, after, query)
+}
+
+// contentMatch - conditions hiding the posts filtered out by the reader, scoped to a single subreddit when it is set.
+// The subreddit conditions share a single key, a filter must not repeat a field.
+func contentMatch(f models.ContentFilter, subreddit string) bson.D {
+	var match bson.D
+
+	var in bson.D
+	if len(f.OnlySubreddits) > 0 {
+		in = append(in, bson.E{"$in", f.OnlySubreddits})
+	}
+	if len(f.ExcludeSubreddits) > 0 {
+		in = append(in, bson.E{"$nin", f.ExcludeSubreddits})
+	}
+
+	switch {
+	case subreddit != "" && len(in) > 0:
+		match = append(match, bson.E{"subreddit", append(bson.D{{"$eq", subreddit}}, in...)})
+	case subreddit != "":
+		match = append(match, bson.E{"subreddit", subreddit})
+	case len(in) > 0:
+		match = append(match, bson.E{"subreddit", in})
+	}
+
+	if f.IncludeNSFW != nil && !*f.IncludeNSFW {
+		match = append(match, bson.E{"nsfw", false})
+	}
+
+	return match
 }
 
-func (p *postsUC) GenerateFeeds(ctx context.Context, query *pagination.Query) (*models.Feed, error) {
+// decodeCursor - returns the cursor of the query, nil for page based queries.
+func (p *postsUC) decodeCursor(strategy ranking.Strategy, query *pagination.Query) (*pagination.Cursor, error) {
+	if !query.IsCursor() {
+		return nil, nil
+	}
+
+	if !ranking.Keyset(strategy) {
+		return nil, customErrors.New(http.StatusBadRequest, ranking.ErrNoCursor)
+	}
+
+	after, err := p.cursors.Decode(query.Cursor)
+	if err != nil {
+		return nil, customErrors.New(http.StatusBadRequest, err)
+	}
+
+	// a cursor only makes sense for the ordering it was issued for
+	if after.Sort != strategy.Name() {
+		return nil, customErrors.New(http.StatusBadRequest, pagination.ErrInvalidCursor)
+	}
+
+	return after, nil
+}
 
-	totalCount, err := p.repo.CountDocuments(ctx, bson.D{{"promoted", false}})
+// rankedPage - one page of the posts matching the filter in the order of the strategy, with the cursor of the next page.
+func (p *postsUC) rankedPage(ctx context.Context, filter bson.D, strategy ranking.Strategy, after *pagination.Cursor, query *pagination.Query) (*models.Feed, error) {
+	totalCount, err := p.repo.CountDocuments(ctx, filter)
 
 	if err != nil {
 		return nil, err
 	}
 
-	posts, err := p.repo.FindAll(ctx, bson.D{{"promoted", false}}, query)
+	posts, err := p.repo.FindRanked(ctx, filter, strategy, after, query)
 	if err != nil {
 		return nil, err
 	}
 
-	matchStage := bson.D{{"$match", bson.D{{"promoted", true}}}}
-	sampleStage := bson.D{{"$sample", bson.D{{"size", 2}}}}
+	hasMore := pagination.GetHasMore(query.GetPage(), int(totalCount), query.GetSize())
+	if query.IsCursor() {
+		hasMore = len(posts) == query.GetSize()
+	}
+
+	var nextCursor string
+	if hasMore && len(posts) > 0 && ranking.Keyset(strategy) {
+		last := posts[len(posts)-1]
+		nextCursor = p.cursors.Encode(strategy.Name(), strategy.Key(last), last.Id)
+	}
+
+	return &models.Feed{
+		TotalCount: totalCount,
+		TotalPages: pagination.GetTotalPages(totalCount, query.GetSize()),
+		Page:       query.GetPage(),
+		Size:       len(posts),
+		HasMore:    hasMore,
+		NextCursor: nextCursor,
+		Posts:      posts,
+	}, nil
+}
+
+// snapshotPage - the page of the hot feed read from its snapshot, nil when the feed is read live: other sorts,
+// feeds filtered by the reader, stale or missing snapshots and pages past the posts of the snapshot.
+// The posts are read by their ids, those removed since the snapshot are left out of the page.
+func (p *postsUC) snapshotPage(ctx context.Context, params *models.FeedParams, strategy ranking.Strategy, after *pagination.Cursor, query *pagination.Query) (*models.Feed, error) {
+	if p.snapshots == nil || strategy.Name() != ranking.Hot || len(contentMatch(params.ContentFilter, "")) > 0 {
+		return nil, nil
+	}
+
+	snap, result := p.snapshots.Get(params.Subreddit)
+
+	var entries []snapshot.Entry
+	if snap != nil {
+		var ok bool
+		if entries, ok = snap.Page(after, query); !ok {
+			result = snapshot.ReadBeyond
+		}
+	}
+
+	metrics.FeedSnapshotReads.WithLabelValues(result).Inc()
+	if result != snapshot.ReadHit {
+		return nil, nil
+	}
+
+	posts := make([]*models.Post, 0, len(entries))
+	if len(entries) > 0 {
+		ids := make(bson.A, 0, len(entries))
+		for _, e := range entries {
+			id, err := primitive.ObjectIDFromHex(e.Id)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
 
-	promotedPosts, err := p.repo.Aggregate(ctx, matchStage, sampleStage)
+		found, err := p.repo.FindAll(ctx, bson.D{{"_id", bson.D{{"$in", ids}}}}, &pagination.Query{Size: len(ids)})
+		if err != nil {
+			return nil, err
+		}
 
+		byID := make(map[string]*models.Post, len(found))
+		for _, post := range found {
+			byID[post.Id] = post
+		}
+
+		// the order and the ranks are those of the snapshot, so its cursors continue where the page ends
+		for _, e := range entries {
+			if post, ok := byID[e.Id]; ok {
+				post.Rank = e.Key
+				posts = append(posts, post)
+			}
+		}
+	}
+
+	hasMore := pagination.GetHasMore(query.GetPage(), int(snap.Total), query.GetSize())
+	if query.IsCursor() {
+		hasMore = len(entries) == query.GetSize()
+	}
+
+	var nextCursor string
+	if hasMore && len(entries) > 0 {
+		last := entries[len(entries)-1]
+		nextCursor = p.cursors.Encode(strategy.Name(), last.Key, last.Id)
+	}
+
+	computedAt := snap.ComputedAt.UTC()
+
+	return &models.Feed{
+		TotalCount: snap.Total,
+		TotalPages: pagination.GetTotalPages(snap.Total, query.GetSize()),
+		Page:       query.GetPage(),
+		Size:       len(posts),
+		HasMore:    hasMore,
+		NextCursor: nextCursor,
+		SnapshotAt: &computedAt,
+		Posts:      posts,
+	}, nil
+}
+
+// ListByAuthor - returns the posts of the user, newest first.
+func (p *postsUC) ListByAuthor(ctx context.Context, author string, query *pagination.Query) (*models.Feed, error) {
+	strategy, err := ranking.Get(ranking.New, "")
 	if err != nil {
 		return nil, err
 	}
 
-	if len(promotedPosts) > 0 && len(posts) >= 3 && !*posts[0].NSFW && !*posts[1].NSFW {
-		posts = append(posts, &models.Post{})
-		copy(posts[2:], posts[1:])
-		posts[1] = promotedPosts[:1][0]
-		promotedPosts = promotedPosts[1:]
+	filter := bson.D{{"author", author}}
+
+	totalCount, err := p.repo.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(promotedPosts) > 0 && len(posts) > 16 && !*posts[14].NSFW && !*posts[15].NSFW {
-		posts = append(posts, &models.Post{})
-		copy(posts[15:], posts[14:])
-		posts[15] = promotedPosts[:1][0]
+	posts, err := p.repo.FindRanked(ctx, filter, strategy, nil, query)
+	if err != nil {
+		return nil, err
 	}
 
 	return &models.Feed{
@@ -70,3 +667,30 @@ func (p *postsUC) GenerateFeeds(ctx context.Context, query *pagination.Query) (*
 		Posts:      posts,
 	}, nil
 }
+
+// checkSubreddit - posts may only target existing subreddits.
+func (p *postsUC) checkSubreddit(ctx context.Context, name string) error {
+	_, err := p.subreddits.FindByName(ctx, name)
+
+	var errResp *customErrors.ErrorResponse
+	if errors.As(err, &errResp) && errResp.ErrStatus == http.StatusNotFound {
+		return customErrors.New(http.StatusBadRequest, models.ErrUnknownSubreddit)
+	}
+
+	return err
+}
+
+// statusOf - status of the post, posts stored before statuses were introduced are active.
+func statusOf(post *models.Post) string {
+	if post.Status == "" {
+		return models.StatusActive
+	}
+	return post.Status
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
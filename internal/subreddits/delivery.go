@@ -0,0 +1,9 @@
+package subreddits
+
+import "github.com/gin-gonic/gin"
+
+type Handlers interface {
+	Create(c *gin.Context)
+	GetByName(c *gin.Context)
+	List(c *gin.Context)
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"fmt"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/http/server/helpers"
+	"github.com/aliykh/reddit-feed/internal/subreddits"
+	"github.com/aliykh/reddit-feed/internal/subreddits/models"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type handlers struct {
+	logger *log.Factory
+	uc     subreddits.UseCase
+}
+
+func New(logger *log.Factory, uc subreddits.UseCase) *handlers {
+	return &handlers{
+		logger: logger,
+		uc:     uc,
+	}
+}
+
+// Create godoc
+// @Summary Create - create a new subreddit
+// @Description creates a new subreddit, names are unique
+// @Tags Subreddits
+// @Security BearerAuth
+// @Param params body models.Subreddit true "body"
+// @Accept json
+// @Produce json
+// @Success 201 {object} models.Subreddit
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 401 {object} customErrors.ErrorResponse
+// @Failure 409 {object} customErrors.ErrorResponse
+// @Router /r [POST]
+func (h *handlers) Create(c *gin.Context) {
+
+	model := &models.Subreddit{}
+
+	// go-validator validations
+	if err := c.ShouldBindJSON(model); err != nil {
+		h.logger.For(c.Request.Context()).Error(fmt.Sprintf("error while binding json body: %v\n", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	result, err := h.uc.Create(c.Request.Context(), model)
+	if err != nil {
+		h.logger.For(c.Request.Context()).Error("subreddit create", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondCreated(c, result)
+}
+
+// GetByName godoc
+// @Summary GetByName - returns a single subreddit
+// @Description returns a subreddit by its name without the /r/ prefix
+// @Tags Subreddits
+// @Param name path string true "subreddit name"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Subreddit
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Router /r/{name} [GET]
+func (h *handlers) GetByName(c *gin.Context) {
+
+	result, err := h.uc.GetByName(c.Request.Context(), models.Prefix+c.Param("name"))
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, result)
+}
+
+// List godoc
+// @Summary List - lists the subreddits
+// @Description returns the subreddits ordered by name
+// @Tags Subreddits
+// @Param page query int false "page number"
+// @Param size query int false "page size, max 25"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.SubredditList
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Router /r [GET]
+func (h *handlers) List(c *gin.Context) {
+
+	pg := &pagination.Query{
+		Size: 25,
+	}
+
+	if err := c.ShouldBindQuery(pg); err != nil {
+		h.logger.For(c.Request.Context()).Error("pagination query binding err", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	res, err := h.uc.List(c.Request.Context(), pg)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, res)
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/auth"
+	"github.com/aliykh/reddit-feed/internal/http/middleware"
+	"github.com/aliykh/reddit-feed/internal/subreddits/mock"
+	"github.com/aliykh/reddit-feed/internal/subreddits/models"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/helpers"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/aliykh/reddit-feed/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func init() {
+
+	binding.Validator = new(helpers.DefaultValidator)
+
+	engine := binding.Validator.Engine().(*validator.Validate)
+
+	eng := en.New()
+	uni := ut.New(eng, eng)
+	customErrors.Trans, _ = uni.GetTranslator("en")
+	_ = en_translations.RegisterDefaultTranslations(engine, customErrors.Trans)
+
+}
+
+func TestHandlers_Create(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSubredditUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	subredditHandlers := New(logger, mockSubredditUC)
+
+	router := gin.Default()
+	router.Use(middleware.Authenticate(auth.NewVerifier(utils.TestKeys)))
+	RegisterHandlers(router.Group(""), subredditHandlers)
+
+	t.Run("ok", func(t *testing.T) {
+		reqBody := &models.Subreddit{Name: "/r/golang", Description: "gophers", NSFW: new(bool)}
+		model := &models.Subreddit{Id: "62633b1e5f3c5e6a1b2c3d4e", Name: "/r/golang", Description: "gophers", NSFW: new(bool)}
+
+		mockSubredditUC.EXPECT().Create(gomock.Any(), reqBody).Return(model, nil)
+
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/r/", reqBody)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", utils.Bearer(utils.TestKeys, "t2_user"))
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		data := &models.Subreddit{}
+		err = json.Unmarshal(resp.Body, &data)
+		require.NoError(t, err)
+		require.Equal(t, model, data)
+	})
+
+	t.Run("missing nsfw", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/r/", &models.Subreddit{Name: "/r/golang"})
+		require.NoError(t, err)
+		req.Header.Set("Authorization", utils.Bearer(utils.TestKeys, "t2_user"))
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		expectedErr := customErrors.New(http.StatusConflict, customErrors.Conflict)
+		mockSubredditUC.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, expectedErr)
+
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/r/", &models.Subreddit{Name: "/r/golang", NSFW: new(bool)})
+		require.NoError(t, err)
+		req.Header.Set("Authorization", utils.Bearer(utils.TestKeys, "t2_user"))
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/r/", &models.Subreddit{Name: "/r/golang", NSFW: new(bool)})
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestHandlers_GetByName(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSubredditUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	subredditHandlers := New(logger, mockSubredditUC)
+
+	router := gin.Default()
+	RegisterHandlers(router.Group(""), subredditHandlers)
+
+	t.Run("ok", func(t *testing.T) {
+		model := &models.Subreddit{Name: "/r/golang", NSFW: new(bool)}
+		mockSubredditUC.EXPECT().GetByName(context.Background(), "/r/golang").Return(model, nil)
+
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/r/golang", nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		expectedErr := customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		mockSubredditUC.EXPECT().GetByName(context.Background(), "/r/missing").Return(nil, expectedErr)
+
+		req, err := utils.MakeRequest(utils.GET, utils.FORM, "/r/missing", nil)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestHandlers_List(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSubredditUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	subredditHandlers := New(logger, mockSubredditUC)
+
+	router := gin.Default()
+	RegisterHandlers(router.Group(""), subredditHandlers)
+
+	list := &models.SubredditList{TotalCount: 1, TotalPages: 1, Page: 1, Size: 25, Subreddits: []*models.Subreddit{{Name: "/r/golang", NSFW: new(bool)}}}
+	mockSubredditUC.EXPECT().List(context.Background(), &pagination.Query{Page: 1, Size: 25}).Return(list, nil)
+
+	req, err := utils.MakeRequest(utils.GET, utils.FORM, "/r/?page=1", nil)
+	require.NoError(t, err)
+
+	resp, err := utils.InvokeHandler(req, router)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data := &models.SubredditList{}
+	err = json.Unmarshal(resp.Body, &data)
+	require.NoError(t, err)
+	require.Equal(t, list, data)
+}
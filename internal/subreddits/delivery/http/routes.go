@@ -0,0 +1,18 @@
+package http
+
+import (
+	"github.com/aliykh/reddit-feed/internal/http/middleware"
+	"github.com/aliykh/reddit-feed/internal/subreddits"
+	"github.com/gin-gonic/gin"
+)
+
+const path = "/r"
+
+func RegisterHandlers(router *gin.RouterGroup, handlers subreddits.Handlers) {
+
+	r1Group := router.Group(path)
+	r1Group.POST("/", middleware.RequireAuth(), handlers.Create)
+	r1Group.GET("/", handlers.List)
+	r1Group.GET("/:name", handlers.GetByName)
+
+}
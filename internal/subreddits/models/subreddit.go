@@ -0,0 +1,45 @@
+package models
+
+import (
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// Prefix - every subreddit name starts with it
+const Prefix = "/r/"
+
+var nameRegex = regexp.MustCompile(`^/r/[A-Za-z0-9_]{3,21}$`)
+
+type SubredditList struct {
+	TotalCount int64        `json:"total_count"`
+	TotalPages int          `json:"total_pages"`
+	Page       int          `json:"page"`
+	Size       int          `json:"size"`
+	HasMore    bool         `json:"has_more"`
+	Subreddits []*Subreddit `json:"subreddits"`
+}
+
+type Subreddit struct {
+	Id          string    `json:"id" bson:"_id,omitempty"`
+	Name        string    `json:"name" bson:"name" binding:"required,startswith=/r/"`
+	Description string    `json:"description" bson:"description"`
+	NSFW        *bool     `json:"nsfw" bson:"nsfw" binding:"required"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+}
+
+func (s Subreddit) CheckValidity() error {
+	if !nameRegex.MatchString(s.Name) {
+		return &customErrors.ErrorResponse{
+			ErrStatus: http.StatusBadRequest,
+			Errors: []customErrors.ErrorValidation{
+				{
+					Field:   "name",
+					Message: "name must be /r/ followed by 3 to 21 letters, digits or underscores",
+				},
+			},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+//go:generate mockgen -source mongo_repository.go -destination mock/repository_mock.go -package mock
+package repository
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/driver/db"
+	"github.com/aliykh/reddit-feed/internal/subreddits/models"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+const (
+	collectionName = "subreddits"
+)
+
+type Repository interface {
+	Create(context.Context, *models.Subreddit) (*models.Subreddit, error)
+	FindByName(ctx context.Context, name string) (*models.Subreddit, error)
+	CountDocuments(ctx context.Context, filter bson.D) (int64, error)
+	FindAll(ctx context.Context, filter bson.D, query *pagination.Query) ([]*models.Subreddit, error)
+}
+
+type repo struct {
+	logger     *log.Factory
+	collection db.Collection
+}
+
+func New(logger *log.Factory, collection db.Collection) *repo {
+	return &repo{
+		logger:     logger,
+		collection: collection,
+	}
+}
+
+func (r *repo) Create(ctx context.Context, m *models.Subreddit) (*models.Subreddit, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	res, err := r.collection.InsertOne(ctx, m)
+
+	if err != nil {
+		// names are unique, see the subreddits migration
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, customErrors.New(http.StatusConflict, customErrors.Conflict)
+		}
+		return nil, errors.Wrap(err, "SubredditMongoRepo.Create.InsertOne")
+	}
+
+	result := &models.Subreddit{}
+
+	if err = r.collection.FindOne(ctx, bson.D{{"_id", res.InsertedID}}, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *repo) FindByName(ctx context.Context, name string) (*models.Subreddit, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	result := &models.Subreddit{}
+	if err := r.collection.FindOne(ctx, bson.D{{"name", name}}, result); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		}
+		r.logger.For(ctx).Error("Subreddits.FindByName.FindOne", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Subreddits.FindByName.FindOne")
+	}
+
+	return result, nil
+}
+
+func (r *repo) CountDocuments(ctx context.Context, filter bson.D) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	totalCount, err := r.collection.CountDocuments(ctx, filter)
+
+	if err != nil {
+		r.logger.For(ctx).Error("Subreddits.CountDocuments", zap.String("err", err.Error()))
+		return 0, errors.Wrap(err, "Subreddits.CountDocuments")
+	}
+
+	return totalCount, nil
+}
+
+// FindAll - returns one page of the subreddits matching the filter, ordered by name.
+func (r *repo) FindAll(ctx context.Context, filter bson.D, query *pagination.Query) ([]*models.Subreddit, error) {
+
+	result := make([]*models.Subreddit, 0, query.GetSize())
+
+	opts := options.Find().SetSort(bson.D{{"name", 1}}).SetSkip(int64(query.GetOffset())).SetLimit(int64(query.GetSize()))
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	err := r.collection.Find(ctx, filter, &result, opts)
+
+	if err != nil {
+		r.logger.For(ctx).Error("Subreddits.FindAll.Find", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Subreddits.FindAll.Find")
+	}
+
+	return result, nil
+}
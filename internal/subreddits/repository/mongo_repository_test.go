@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/driver/db/mock"
+	"github.com/aliykh/reddit-feed/internal/subreddits/models"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestRepo_Create(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	t.Run("duplicate name", func(t *testing.T) {
+
+		dup := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error"}}}
+		coll.EXPECT().InsertOne(gomock.Any(), gomock.Any()).Return(nil, dup)
+
+		_, err := repo.Create(context.Background(), &models.Subreddit{Name: "/r/golang"})
+
+		require.Equal(t, customErrors.New(http.StatusConflict, customErrors.Conflict), err)
+	})
+
+	t.Run("insert error", func(t *testing.T) {
+
+		coll.EXPECT().InsertOne(gomock.Any(), gomock.Any()).Return(nil, errors.New("timeout"))
+
+		_, err := repo.Create(context.Background(), &models.Subreddit{Name: "/r/golang"})
+
+		require.Error(t, err)
+	})
+
+}
+
+func TestRepo_FindByName(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	t.Run("not found", func(t *testing.T) {
+
+		coll.EXPECT().FindOne(gomock.Any(), bson.D{{"name", "/r/golang"}}, gomock.Any()).Return(mongo.ErrNoDocuments)
+
+		_, err := repo.FindByName(context.Background(), "/r/golang")
+
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+	})
+
+}
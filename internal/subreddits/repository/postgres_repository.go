@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/driver/db"
+	"github.com/aliykh/reddit-feed/internal/subreddits/models"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// selectColumns - columns scanned by scanSubreddit, in its order
+const selectColumns = "id, name, description, nsfw, created_at"
+
+// columns - columns of the fields the use cases filter the subreddits by
+var columns = map[string]string{
+	"_id":  "id",
+	"name": "name",
+	"nsfw": "nsfw",
+}
+
+// postgresRepo - subreddits kept in the subreddits table of postgres, ids are generated like mongo object ids.
+type postgresRepo struct {
+	logger *log.Factory
+	pool   *pgxpool.Pool
+}
+
+func NewPostgres(logger *log.Factory, pool *pgxpool.Pool) *postgresRepo {
+	return &postgresRepo{
+		logger: logger,
+		pool:   pool,
+	}
+}
+
+func (r *postgresRepo) Create(ctx context.Context, m *models.Subreddit) (*models.Subreddit, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	query := `INSERT INTO subreddits (` + selectColumns + `) VALUES ($1, $2, $3, $4, $5) RETURNING ` + selectColumns
+
+	result, err := scanSubreddit(r.pool.QueryRow(ctx, query, primitive.NewObjectID().Hex(), m.Name, m.Description, m.NSFW, m.CreatedAt))
+	if err != nil {
+		// names are unique, see the subreddits migration
+		if db.IsUniqueViolation(err) {
+			return nil, customErrors.New(http.StatusConflict, customErrors.Conflict)
+		}
+		return nil, errors.Wrap(err, "SubredditPostgresRepo.Create.Insert")
+	}
+
+	return result, nil
+}
+
+func (r *postgresRepo) FindByName(ctx context.Context, name string) (*models.Subreddit, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	result, err := scanSubreddit(r.pool.QueryRow(ctx, `SELECT `+selectColumns+` FROM subreddits WHERE name = $1`, name))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		}
+		r.logger.For(ctx).Error("Subreddits.FindByName.QueryRow", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Subreddits.FindByName.QueryRow")
+	}
+
+	return result, nil
+}
+
+func (r *postgresRepo) CountDocuments(ctx context.Context, filter bson.D) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	where, args, err := db.Where(filter, columns)
+	if err != nil {
+		return 0, errors.Wrap(err, "Subreddits.CountDocuments")
+	}
+
+	var totalCount int64
+	if err = r.pool.QueryRow(ctx, `SELECT count(*) FROM subreddits WHERE `+where, args...).Scan(&totalCount); err != nil {
+		r.logger.For(ctx).Error("Subreddits.CountDocuments", zap.String("err", err.Error()))
+		return 0, errors.Wrap(err, "Subreddits.CountDocuments")
+	}
+
+	return totalCount, nil
+}
+
+// FindAll - returns one page of the subreddits matching the filter, ordered by name.
+func (r *postgresRepo) FindAll(ctx context.Context, filter bson.D, query *pagination.Query) ([]*models.Subreddit, error) {
+
+	where, args, err := db.Where(filter, columns)
+	if err != nil {
+		return nil, errors.Wrap(err, "Subreddits.FindAll.Query")
+	}
+
+	sql := fmt.Sprintf(`SELECT %s FROM subreddits WHERE %s ORDER BY name OFFSET $%d LIMIT $%d`,
+		selectColumns, where, len(args)+1, len(args)+2)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, sql, append(args, query.GetOffset(), query.GetSize())...)
+	if err != nil {
+		r.logger.For(ctx).Error("Subreddits.FindAll.Query", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Subreddits.FindAll.Query")
+	}
+	defer rows.Close()
+
+	result := make([]*models.Subreddit, 0, query.GetSize())
+	for rows.Next() {
+		s, err := scanSubreddit(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "Subreddits.FindAll.Scan")
+		}
+		result = append(result, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.For(ctx).Error("Subreddits.FindAll.Query", zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, "Subreddits.FindAll.Query")
+	}
+
+	return result, nil
+}
+
+// scanSubreddit - scans the selectColumns of the row.
+func scanSubreddit(row pgx.Row) (*models.Subreddit, error) {
+	s := &models.Subreddit{}
+
+	if err := row.Scan(&s.Id, &s.Name, &s.Description, &s.NSFW, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	s.CreatedAt = s.CreatedAt.UTC()
+
+	return s, nil
+}
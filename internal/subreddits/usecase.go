@@ -0,0 +1,14 @@
+//go:generate mockgen -source usecase.go -destination mock/usecase_mock.go -package mock
+package subreddits
+
+import (
+	"context"
+	"github.com/aliykh/reddit-feed/internal/subreddits/models"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+)
+
+type UseCase interface {
+	Create(context.Context, *models.Subreddit) (*models.Subreddit, error)
+	GetByName(ctx context.Context, name string) (*models.Subreddit, error)
+	List(ctx context.Context, query *pagination.Query) (*models.SubredditList, error)
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/subreddits/models"
+	"github.com/aliykh/reddit-feed/internal/subreddits/repository/mock"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSubredditsUC_Create(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	uc := New(logger, repo)
+
+	t.Run("ok", func(t *testing.T) {
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m *models.Subreddit) (*models.Subreddit, error) {
+			require.False(t, m.CreatedAt.IsZero())
+			return m, nil
+		})
+
+		_, err := uc.Create(context.Background(), &models.Subreddit{Name: "/r/golang", NSFW: new(bool)})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		for _, name := range []string{"/r/go", "/r/golang/news", "/r/a_name_that_is_far_too_long", "/r/go-lang"} {
+			_, err := uc.Create(context.Background(), &models.Subreddit{Name: name, NSFW: new(bool)})
+
+			require.Equal(t, http.StatusBadRequest, customErrors.ParseError(err).ErrStatus, name)
+		}
+	})
+
+}
+
+func TestSubredditsUC_List(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	uc := New(logger, repo)
+
+	subreddits := []*models.Subreddit{{Name: "/r/golang"}, {Name: "/r/rustlang"}}
+
+	t.Run("page", func(t *testing.T) {
+		query := &pagination.Query{Page: 1, Size: 2}
+
+		repo.EXPECT().CountDocuments(gomock.Any(), bson.D{}).Return(int64(5), nil)
+		repo.EXPECT().FindAll(gomock.Any(), bson.D{}, query).Return(subreddits, nil)
+
+		res, err := uc.List(context.Background(), query)
+		require.NoError(t, err)
+		require.Equal(t, 2, res.Size)
+		require.True(t, res.HasMore)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		last := &pagination.Query{Page: 3, Size: 2}
+
+		repo.EXPECT().CountDocuments(gomock.Any(), bson.D{}).Return(int64(5), nil)
+		repo.EXPECT().FindAll(gomock.Any(), bson.D{}, last).Return(subreddits[:1], nil)
+
+		// the size is the one of the page, like the feeds report it
+		res, err := uc.List(context.Background(), last)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Size)
+		require.False(t, res.HasMore)
+		require.Equal(t, 3, res.TotalPages)
+	})
+
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/subreddits/models"
+	"github.com/aliykh/reddit-feed/internal/subreddits/repository"
+	"github.com/aliykh/reddit-feed/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+)
+
+type subredditsUC struct {
+	logger *log.Factory
+	repo   repository.Repository
+}
+
+func New(logger *log.Factory, repo repository.Repository) *subredditsUC {
+	return &subredditsUC{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+func (u *subredditsUC) Create(ctx context.Context, model *models.Subreddit) (*models.Subreddit, error) {
+	if err := model.CheckValidity(); err != nil {
+		return nil, err
+	}
+	model.CreatedAt = time.Now().UTC()
+	return u.repo.Create(ctx, model)
+}
+
+func (u *subredditsUC) GetByName(ctx context.Context, name string) (*models.Subreddit, error) {
+	return u.repo.FindByName(ctx, name)
+}
+
+func (u *subredditsUC) List(ctx context.Context, query *pagination.Query) (*models.SubredditList, error) {
+	filter := bson.D{}
+
+	totalCount, err := u.repo.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	subreddits, err := u.repo.FindAll(ctx, filter, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SubredditList{
+		TotalCount: totalCount,
+		TotalPages: pagination.GetTotalPages(totalCount, query.GetSize()),
+		Page:       query.GetPage(),
+		Size:       len(subreddits),
+		HasMore:    pagination.GetHasMore(query.GetPage(), int(totalCount), query.GetSize()),
+		Subreddits: subreddits,
+	}, nil
+}
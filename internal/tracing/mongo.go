@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"sync"
+)
+
+// commandKey - request ids are only unique within a connection
+type commandKey struct {
+	connection string
+	request    int64
+}
+
+// CommandMonitor - starts a client span for every mongo command, as a child of the span of the operation context.
+func CommandMonitor() *event.CommandMonitor {
+	var spans sync.Map
+
+	finish := func(evt event.CommandFinishedEvent, err error) {
+		v, ok := spans.LoadAndDelete(commandKey{evt.ConnectionID, evt.RequestID})
+		if !ok {
+			return
+		}
+
+		span := v.(trace.Span)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			attrs := []attribute.KeyValue{
+				semconv.DBSystemMongoDB,
+				semconv.DBNameKey.String(evt.DatabaseName),
+				semconv.DBOperationKey.String(evt.CommandName),
+			}
+
+			// the first element of a command names the collection it runs on
+			if elem, err := evt.Command.IndexErr(0); err == nil {
+				if collection, ok := elem.Value().StringValueOK(); ok {
+					attrs = append(attrs, semconv.DBMongoDBCollectionKey.String(collection))
+				}
+			}
+
+			_, span := Tracer().Start(ctx, "mongo."+evt.CommandName,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+
+			spans.Store(commandKey{evt.ConnectionID, evt.RequestID}, span)
+		},
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			finish(evt.CommandFinishedEvent, nil)
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			finish(evt.CommandFinishedEvent, errors.New(evt.Failure))
+		},
+	}
+}
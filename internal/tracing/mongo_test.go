@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCommandMonitor(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	monitor := CommandMonitor()
+
+	ctx, parent := Tracer().Start(context.Background(), "postsUC.GetByID")
+	defer parent.End()
+
+	command, err := bson.Marshal(bson.D{{"find", "posts"}})
+	require.NoError(t, err)
+
+	started := func(connection string, request int64) {
+		monitor.Started(ctx, &event.CommandStartedEvent{
+			Command:      command,
+			DatabaseName: "reddit-feed",
+			CommandName:  "find",
+			RequestID:    request,
+			ConnectionID: connection,
+		})
+	}
+
+	finished := func(connection string, request int64) event.CommandFinishedEvent {
+		return event.CommandFinishedEvent{CommandName: "find", RequestID: request, ConnectionID: connection}
+	}
+
+	t.Run("succeeded", func(t *testing.T) {
+		started("conn-1", 1)
+		// the same request id on another connection is another command
+		started("conn-2", 1)
+
+		monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: finished("conn-1", 1)})
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+
+		span := spans[0]
+		require.Equal(t, "mongo.find", span.Name())
+		require.Equal(t, trace.SpanKindClient, span.SpanKind())
+		require.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		require.Contains(t, span.Attributes(), semconv.DBMongoDBCollectionKey.String("posts"))
+		require.Equal(t, codes.Unset, span.Status().Code)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: finished("conn-2", 1), Failure: "timeout"})
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 2)
+		require.Equal(t, codes.Error, spans[1].Status().Code)
+		require.Equal(t, "timeout", spans[1].Status().Description)
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: finished("conn-3", 7)})
+		require.Len(t, recorder.Ended(), 2)
+	})
+
+}
@@ -0,0 +1,9 @@
+package users
+
+import "github.com/gin-gonic/gin"
+
+type Handlers interface {
+	Register(c *gin.Context)
+	Login(c *gin.Context)
+	Profile(c *gin.Context)
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"fmt"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/http/server/helpers"
+	"github.com/aliykh/reddit-feed/internal/users"
+	"github.com/aliykh/reddit-feed/internal/users/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type handlers struct {
+	logger *log.Factory
+	uc     users.UseCase
+}
+
+func New(logger *log.Factory, uc users.UseCase) *handlers {
+	return &handlers{
+		logger: logger,
+		uc:     uc,
+	}
+}
+
+// Register godoc
+// @Summary Register - create a new user account
+// @Description creates a user with a unique username, the password is stored as a bcrypt hash
+// @Tags Users
+// @Param params body models.Credentials true "body"
+// @Accept json
+// @Produce json
+// @Success 201 {object} models.User
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 409 {object} customErrors.ErrorResponse
+// @Router /u/register [POST]
+func (h *handlers) Register(c *gin.Context) {
+
+	creds, ok := h.bindCredentials(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.uc.Register(c.Request.Context(), creds)
+	if err != nil {
+		h.logger.For(c.Request.Context()).Error("user register", zap.String("err", err.Error()))
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondCreated(c, result)
+}
+
+// Login godoc
+// @Summary Login - authenticates a user
+// @Description returns an access token for the user
+// @Tags Users
+// @Param params body models.Credentials true "body"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Session
+// @Failure 400 {object} customErrors.ErrorResponse
+// @Failure 401 {object} customErrors.ErrorResponse
+// @Router /u/login [POST]
+func (h *handlers) Login(c *gin.Context) {
+
+	creds, ok := h.bindCredentials(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.uc.Login(c.Request.Context(), creds)
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, result)
+}
+
+// Profile godoc
+// @Summary Profile - returns a user
+// @Description returns the public profile of the user
+// @Tags Users
+// @Param id path string true "user id"
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.User
+// @Failure 404 {object} customErrors.ErrorResponse
+// @Router /u/{id} [GET]
+func (h *handlers) Profile(c *gin.Context) {
+
+	result, err := h.uc.Profile(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		helpers.RespondError(c, err)
+		return
+	}
+
+	helpers.RespondOK(c, result)
+}
+
+// bindCredentials - binds the credentials body, responds on failure.
+func (h *handlers) bindCredentials(c *gin.Context) (*models.Credentials, bool) {
+
+	creds := &models.Credentials{}
+
+	// go-validator validations
+	if err := c.ShouldBindJSON(creds); err != nil {
+		h.logger.For(c.Request.Context()).Error(fmt.Sprintf("error while binding json body: %v\n", err.Error()))
+		helpers.RespondError(c, err)
+		return nil, false
+	}
+
+	return creds, true
+}
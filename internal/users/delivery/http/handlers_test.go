@@ -0,0 +1,158 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/users/mock"
+	"github.com/aliykh/reddit-feed/internal/users/models"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/helpers"
+	"github.com/aliykh/reddit-feed/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func init() {
+
+	binding.Validator = new(helpers.DefaultValidator)
+
+	engine := binding.Validator.Engine().(*validator.Validate)
+
+	eng := en.New()
+	uni := ut.New(eng, eng)
+	customErrors.Trans, _ = uni.GetTranslator("en")
+	_ = en_translations.RegisterDefaultTranslations(engine, customErrors.Trans)
+
+}
+
+func TestHandlers_Register(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	userHandlers := New(logger, mockUserUC)
+
+	router := gin.Default()
+	RegisterHandlers(router.Group(""), userHandlers)
+
+	creds := &models.Credentials{Username: "gopher", Password: "password123"}
+
+	t.Run("ok", func(t *testing.T) {
+		user := &models.User{Id: "t2_abcdefgh", Username: "gopher", Password: "hash"}
+		mockUserUC.EXPECT().Register(context.Background(), creds).Return(user, nil)
+
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/u/register", creds)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.NotContains(t, string(resp.Body), "hash")
+
+		data := &models.User{}
+		err = json.Unmarshal(resp.Body, &data)
+		require.NoError(t, err)
+		require.Equal(t, "t2_abcdefgh", data.Id)
+	})
+
+	t.Run("short password", func(t *testing.T) {
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/u/register", &models.Credentials{Username: "gopher", Password: "short"})
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("taken username", func(t *testing.T) {
+		expectedErr := customErrors.New(http.StatusConflict, customErrors.Conflict)
+		mockUserUC.EXPECT().Register(context.Background(), creds).Return(nil, expectedErr)
+
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/u/register", creds)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+}
+
+func TestHandlers_Login(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	userHandlers := New(logger, mockUserUC)
+
+	router := gin.Default()
+	RegisterHandlers(router.Group(""), userHandlers)
+
+	creds := &models.Credentials{Username: "gopher", Password: "password123"}
+
+	t.Run("ok", func(t *testing.T) {
+		session := &models.Session{Token: "token", User: &models.User{Id: "t2_abcdefgh", Username: "gopher"}}
+		mockUserUC.EXPECT().Login(context.Background(), creds).Return(session, nil)
+
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/u/login", creds)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		data := &models.Session{}
+		err = json.Unmarshal(resp.Body, &data)
+		require.NoError(t, err)
+		require.Equal(t, session, data)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		expectedErr := customErrors.New(http.StatusUnauthorized, models.ErrInvalidCredentials)
+		mockUserUC.EXPECT().Login(context.Background(), creds).Return(nil, expectedErr)
+
+		req, err := utils.MakeRequest(utils.POST, utils.JSON, "/u/login", creds)
+		require.NoError(t, err)
+
+		resp, err := utils.InvokeHandler(req, router)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestHandlers_Profile(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserUC := mock.NewMockUseCase(ctrl)
+
+	logger := log.NewFactory(log.Mock, "test")
+	userHandlers := New(logger, mockUserUC)
+
+	router := gin.Default()
+	RegisterHandlers(router.Group(""), userHandlers)
+
+	mockUserUC.EXPECT().Profile(context.Background(), "t2_missing").Return(nil, customErrors.New(http.StatusNotFound, customErrors.NotFound))
+
+	req, err := utils.MakeRequest(utils.GET, utils.FORM, "/u/t2_missing", nil)
+	require.NoError(t, err)
+
+	resp, err := utils.InvokeHandler(req, router)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,17 @@
+package http
+
+import (
+	"github.com/aliykh/reddit-feed/internal/users"
+	"github.com/gin-gonic/gin"
+)
+
+const path = "/u"
+
+func RegisterHandlers(router *gin.RouterGroup, handlers users.Handlers) {
+
+	r1Group := router.Group(path)
+	r1Group.POST("/register", handlers.Register)
+	r1Group.POST("/login", handlers.Login)
+	r1Group.GET("/:id", handlers.Profile)
+
+}
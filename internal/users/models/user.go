@@ -0,0 +1,44 @@
+package models
+
+import (
+	"errors"
+	"github.com/dchest/uniuri"
+	"time"
+)
+
+// IDPrefix - prefix of the public user ids
+const IDPrefix = "t2_"
+
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+type User struct {
+	// Id - public t2_ prefixed id, used as the author of posts and comments
+	Id        string    `json:"id" bson:"_id"`
+	Username  string    `json:"username" bson:"username"`
+	Password  string    `json:"-" bson:"password"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Credentials - body of the register and login requests.
+type Credentials struct {
+	Username string `json:"username" binding:"required,min=3,max=20,alphanum"`
+	// bcrypt only uses the first 72 bytes of a password
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
+
+// Session - result of a successful login.
+type Session struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      *User     `json:"user"`
+}
+
+var chars = []byte("abcdefghijklmnopqrstuvwxyz0123456789")
+
+// NewID - generates a new public user id.
+func NewID() string {
+	inBytes := make([]byte, 0, 11)
+	inBytes = append(inBytes, []byte(IDPrefix)...)
+	inBytes = append(inBytes, uniuri.NewLenChars(8, chars)...)
+	return string(inBytes)
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewID(t *testing.T) {
+
+	id := NewID()
+
+	require.Len(t, id, 11)
+	require.True(t, strings.HasPrefix(id, "t2_"))
+	require.NotEqual(t, id, NewID())
+
+}
@@ -0,0 +1,78 @@
+//go:generate mockgen -source mongo_repository.go -destination mock/repository_mock.go -package mock
+package repository
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/driver/db"
+	"github.com/aliykh/reddit-feed/internal/users/models"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+const (
+	collectionName = "users"
+)
+
+type Repository interface {
+	Create(context.Context, *models.User) (*models.User, error)
+	FindByID(ctx context.Context, id string) (*models.User, error)
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
+}
+
+type repo struct {
+	logger     *log.Factory
+	collection db.Collection
+}
+
+func New(logger *log.Factory, collection db.Collection) *repo {
+	return &repo{
+		logger:     logger,
+		collection: collection,
+	}
+}
+
+func (r *repo) Create(ctx context.Context, m *models.User) (*models.User, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, m); err != nil {
+		// usernames are unique, see the users migration
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, customErrors.New(http.StatusConflict, customErrors.Conflict)
+		}
+		return nil, errors.Wrap(err, "UserMongoRepo.Create.InsertOne")
+	}
+
+	return m, nil
+}
+
+func (r *repo) FindByID(ctx context.Context, id string) (*models.User, error) {
+	return r.findOne(ctx, bson.D{{"_id", id}}, "Users.FindByID.FindOne")
+}
+
+func (r *repo) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.findOne(ctx, bson.D{{"username", username}}, "Users.FindByUsername.FindOne")
+}
+
+func (r *repo) findOne(ctx context.Context, filter bson.D, op string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	result := &models.User{}
+	if err := r.collection.FindOne(ctx, filter, result); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		}
+		r.logger.For(ctx).Error(op, zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/driver/db/mock"
+	"github.com/aliykh/reddit-feed/internal/users/models"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestRepo_Create(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	t.Run("taken username", func(t *testing.T) {
+
+		dup := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error"}}}
+		coll.EXPECT().InsertOne(gomock.Any(), gomock.Any()).Return(nil, dup)
+
+		_, err := repo.Create(context.Background(), &models.User{Id: "t2_abcdefgh", Username: "gopher"})
+
+		require.Equal(t, customErrors.New(http.StatusConflict, customErrors.Conflict), err)
+	})
+
+}
+
+func TestRepo_FindByUsername(t *testing.T) {
+
+	var logger = logr.NewFactory(logr.Mock, "test")
+
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coll := mock.NewMockCollection(ctrl)
+
+	repo := New(logger, coll)
+
+	t.Run("not found", func(t *testing.T) {
+
+		coll.EXPECT().FindOne(gomock.Any(), bson.D{{"username", "gopher"}}, gomock.Any()).Return(mongo.ErrNoDocuments)
+
+		_, err := repo.FindByUsername(context.Background(), "gopher")
+
+		require.Equal(t, customErrors.New(http.StatusNotFound, customErrors.NotFound), err)
+	})
+
+}
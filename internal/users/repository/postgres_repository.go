@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/driver/db"
+	"github.com/aliykh/reddit-feed/internal/users/models"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// postgresRepo - users kept in the users table of postgres.
+type postgresRepo struct {
+	logger *log.Factory
+	pool   *pgxpool.Pool
+}
+
+func NewPostgres(logger *log.Factory, pool *pgxpool.Pool) *postgresRepo {
+	return &postgresRepo{
+		logger: logger,
+		pool:   pool,
+	}
+}
+
+func (r *postgresRepo) Create(ctx context.Context, m *models.User) (*models.User, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	_, err := r.pool.Exec(ctx, `INSERT INTO users (id, username, password, created_at) VALUES ($1, $2, $3, $4)`,
+		m.Id, m.Username, m.Password, m.CreatedAt)
+	if err != nil {
+		// usernames are unique, see the users migration
+		if db.IsUniqueViolation(err) {
+			return nil, customErrors.New(http.StatusConflict, customErrors.Conflict)
+		}
+		return nil, errors.Wrap(err, "UserPostgresRepo.Create.Exec")
+	}
+
+	return m, nil
+}
+
+func (r *postgresRepo) FindByID(ctx context.Context, id string) (*models.User, error) {
+	return r.findOne(ctx, `id = $1`, id, "Users.FindByID.QueryRow")
+}
+
+func (r *postgresRepo) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.findOne(ctx, `username = $1`, username, "Users.FindByUsername.QueryRow")
+}
+
+func (r *postgresRepo) findOne(ctx context.Context, where string, arg interface{}, op string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+
+	result := &models.User{}
+	err := r.pool.QueryRow(ctx, `SELECT id, username, password, created_at FROM users WHERE `+where, arg).
+		Scan(&result.Id, &result.Username, &result.Password, &result.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, customErrors.New(http.StatusNotFound, customErrors.NotFound)
+		}
+		r.logger.For(ctx).Error(op, zap.String("err", err.Error()))
+		return nil, errors.Wrap(err, op)
+	}
+	result.CreatedAt = result.CreatedAt.UTC()
+
+	return result, nil
+}
@@ -0,0 +1,13 @@
+//go:generate mockgen -source usecase.go -destination mock/usecase_mock.go -package mock
+package users
+
+import (
+	"context"
+	"github.com/aliykh/reddit-feed/internal/users/models"
+)
+
+type UseCase interface {
+	Register(context.Context, *models.Credentials) (*models.User, error)
+	Login(context.Context, *models.Credentials) (*models.Session, error)
+	Profile(ctx context.Context, id string) (*models.User, error)
+}
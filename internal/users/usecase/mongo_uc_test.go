@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	logr "github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/auth"
+	"github.com/aliykh/reddit-feed/internal/users/models"
+	"github.com/aliykh/reddit-feed/internal/users/repository/mock"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/aliykh/reddit-feed/pkg/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUsersUC_Register(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	uc := New(logger, repo, auth.NewIssuer(utils.TestKeys, time.Hour))
+
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m *models.User) (*models.User, error) {
+		require.Len(t, m.Id, 11)
+		require.Equal(t, "gopher", m.Username)
+		require.NotEqual(t, "password123", m.Password)
+		require.NoError(t, bcrypt.CompareHashAndPassword([]byte(m.Password), []byte("password123")))
+		return m, nil
+	})
+
+	_, err := uc.Register(context.Background(), &models.Credentials{Username: "gopher", Password: "password123"})
+
+	require.NoError(t, err)
+
+}
+
+func TestUsersUC_Login(t *testing.T) {
+
+	logger := logr.NewFactory(logr.Mock, "test")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock.NewMockRepository(ctrl)
+	uc := New(logger, repo, auth.NewIssuer(utils.TestKeys, time.Hour))
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	user := &models.User{Id: "t2_abcdefgh", Username: "gopher", Password: string(hash)}
+	invalid := customErrors.New(http.StatusUnauthorized, models.ErrInvalidCredentials)
+
+	t.Run("ok", func(t *testing.T) {
+		repo.EXPECT().FindByUsername(gomock.Any(), "gopher").Return(user, nil)
+
+		session, err := uc.Login(context.Background(), &models.Credentials{Username: "gopher", Password: "password123"})
+
+		require.NoError(t, err)
+		require.NotEmpty(t, session.Token)
+		require.Equal(t, user, session.User)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		repo.EXPECT().FindByUsername(gomock.Any(), "gopher").Return(user, nil)
+
+		_, err := uc.Login(context.Background(), &models.Credentials{Username: "gopher", Password: "password321"})
+
+		require.Equal(t, invalid, err)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		repo.EXPECT().FindByUsername(gomock.Any(), "nobody").Return(nil, customErrors.New(http.StatusNotFound, customErrors.NotFound))
+
+		_, err := uc.Login(context.Background(), &models.Credentials{Username: "nobody", Password: "password123"})
+
+		require.Equal(t, invalid, err)
+	})
+
+}
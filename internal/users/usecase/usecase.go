@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"github.com/aliykh/log"
+	"github.com/aliykh/reddit-feed/internal/auth"
+	"github.com/aliykh/reddit-feed/internal/users/models"
+	"github.com/aliykh/reddit-feed/internal/users/repository"
+	"github.com/aliykh/reddit-feed/pkg/customErrors"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"time"
+)
+
+type usersUC struct {
+	logger *log.Factory
+	repo   repository.Repository
+	issuer *auth.Issuer
+}
+
+func New(logger *log.Factory, repo repository.Repository, issuer *auth.Issuer) *usersUC {
+	return &usersUC{
+		logger: logger,
+		repo:   repo,
+		issuer: issuer,
+	}
+}
+
+func (u *usersUC) Register(ctx context.Context, creds *models.Credentials) (*models.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(creds.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.Wrap(err, "Register.GenerateFromPassword")
+	}
+
+	return u.repo.Create(ctx, &models.User{
+		Id:        models.NewID(),
+		Username:  creds.Username,
+		Password:  string(hash),
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// Login - checks the credentials and issues an access token, an unknown username and a wrong password are not told apart.
+func (u *usersUC) Login(ctx context.Context, creds *models.Credentials) (*models.Session, error) {
+	invalid := customErrors.New(http.StatusUnauthorized, models.ErrInvalidCredentials)
+
+	user, err := u.repo.FindByUsername(ctx, creds.Username)
+	if err != nil {
+		var errResp *customErrors.ErrorResponse
+		if errors.As(err, &errResp) && errResp.ErrStatus == http.StatusNotFound {
+			return nil, invalid
+		}
+		return nil, err
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(creds.Password)); err != nil {
+		return nil, invalid
+	}
+
+	token, expiresAt, err := u.issuer.Issue(&auth.Principal{UserID: user.Id, Username: user.Username})
+	if err != nil {
+		return nil, errors.Wrap(err, "Login.Issue")
+	}
+
+	return &models.Session{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      user,
+	}, nil
+}
+
+func (u *usersUC) Profile(ctx context.Context, id string) (*models.User, error) {
+	return u.repo.FindByID(ctx, id)
+}
@@ -0,0 +1,22 @@
+// Package migrations embeds the migration files, so the app applies them without the migrate cli.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS - up and down migrations named <version>_<name>.<up|down>.json,
+// every file holds the array of database commands of the migration.
+//
+//go:embed *.json
+var FS embed.FS
+
+//go:embed postgres/*.sql
+var postgresFS embed.FS
+
+// Postgres - up and down migrations of the postgres storage named <version>_<name>.<up|down>.sql,
+// the statements of a file are applied together.
+func Postgres() (fs.FS, error) {
+	return fs.Sub(postgresFS, "postgres")
+}
@@ -0,0 +1,22 @@
+-- ids are the hex of mongo object ids, so the cursors and the apis are the same for both storages.
+-- The C collation orders them like mongo orders the object ids
+CREATE TABLE IF NOT EXISTS posts (
+    id            CHAR(24) COLLATE "C" PRIMARY KEY,
+    title         TEXT        NOT NULL,
+    author        TEXT        NOT NULL DEFAULT '',
+    link          TEXT,
+    subreddit     TEXT        NOT NULL,
+    content       TEXT,
+    score         INTEGER,
+    promoted      BOOLEAN,
+    nsfw          BOOLEAN,
+    ups           INTEGER     NOT NULL DEFAULT 0,
+    downs         INTEGER     NOT NULL DEFAULT 0,
+    comment_count INTEGER     NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ,
+    status        TEXT,
+    -- full-text search of the title and the content, like the text index of mongo
+    search        TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('english', title || ' ' || coalesce(content, ''))
+    ) STORED
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS posts_search_index;
+DROP INDEX IF EXISTS posts_author_created_at_id_index;
+DROP INDEX IF EXISTS posts_subreddit_score_id_index;
+DROP INDEX IF EXISTS posts_promoted_created_at_id_index;
+DROP INDEX IF EXISTS posts_promoted_score_id_index;
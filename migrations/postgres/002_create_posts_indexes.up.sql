@@ -0,0 +1,6 @@
+-- keyset pagination of the feeds, nulls sort last like in mongo, see the postgres repository
+CREATE INDEX IF NOT EXISTS posts_promoted_score_id_index ON posts (promoted, score DESC NULLS LAST, id DESC);
+CREATE INDEX IF NOT EXISTS posts_promoted_created_at_id_index ON posts (promoted, created_at DESC NULLS LAST, id DESC);
+CREATE INDEX IF NOT EXISTS posts_subreddit_score_id_index ON posts (subreddit, score DESC NULLS LAST, id DESC);
+CREATE INDEX IF NOT EXISTS posts_author_created_at_id_index ON posts (author, created_at DESC NULLS LAST, id DESC);
+CREATE INDEX IF NOT EXISTS posts_search_index ON posts USING GIN (search);
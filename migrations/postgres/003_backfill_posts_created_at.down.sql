@@ -0,0 +1 @@
+-- the backfilled creation times can not be told apart from the stored ones, they are kept
@@ -0,0 +1,2 @@
+-- posts imported without a creation time were created at the time of their id, its first 4 bytes are the seconds
+UPDATE posts SET created_at = to_timestamp(('x' || left(id, 8))::bit(32)::bigint) WHERE created_at IS NULL;
@@ -0,0 +1,8 @@
+-- a user has at most one vote per post, a cleared vote is kept with a zero direction
+CREATE TABLE IF NOT EXISTS votes (
+    user_id    TEXT                 NOT NULL,
+    post       CHAR(24) COLLATE "C" NOT NULL,
+    direction  SMALLINT             NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ          NOT NULL,
+    PRIMARY KEY (user_id, post)
+);
@@ -0,0 +1,17 @@
+-- the threads are kept like in mongo, path is the materialized path of the ancestors' ids
+CREATE TABLE IF NOT EXISTS comments (
+    id         CHAR(24) COLLATE "C" PRIMARY KEY,
+    post_id    CHAR(24) COLLATE "C" NOT NULL,
+    parent_id  CHAR(24) COLLATE "C",
+    path       TEXT COLLATE "C"     NOT NULL DEFAULT '',
+    depth      INTEGER              NOT NULL DEFAULT 0,
+    author     TEXT                 NOT NULL DEFAULT '',
+    body       TEXT                 NOT NULL,
+    score      INTEGER              NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ          NOT NULL
+);
+-- the listings of the top level comments of a post and of the replies to a comment, see the comments repository
+CREATE INDEX IF NOT EXISTS comments_post_depth_score_index ON comments (post_id, depth, score DESC, id DESC);
+CREATE INDEX IF NOT EXISTS comments_post_depth_created_at_index ON comments (post_id, depth, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS comments_parent_created_at_index ON comments (parent_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS comments_path_index ON comments (path);
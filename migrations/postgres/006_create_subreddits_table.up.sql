@@ -0,0 +1,8 @@
+-- the C collation orders the names like mongo does
+CREATE TABLE IF NOT EXISTS subreddits (
+    id          CHAR(24) COLLATE "C" PRIMARY KEY,
+    name        TEXT COLLATE "C"     NOT NULL UNIQUE,
+    description TEXT                 NOT NULL DEFAULT '',
+    nsfw        BOOLEAN,
+    created_at  TIMESTAMPTZ          NOT NULL
+);
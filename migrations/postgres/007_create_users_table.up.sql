@@ -0,0 +1,7 @@
+-- ids are the public t2_ prefixed ids of the users
+CREATE TABLE IF NOT EXISTS users (
+    id         TEXT        PRIMARY KEY,
+    username   TEXT        NOT NULL UNIQUE,
+    password   TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,11 @@
+-- the status changes of the posts, kept after the posts are hidden or deleted
+CREATE TABLE IF NOT EXISTS post_audit (
+    id          CHAR(24) COLLATE "C" PRIMARY KEY,
+    post        CHAR(24) COLLATE "C" NOT NULL,
+    actor       TEXT                 NOT NULL,
+    from_status TEXT                 NOT NULL,
+    to_status   TEXT                 NOT NULL,
+    reason      TEXT,
+    created_at  TIMESTAMPTZ          NOT NULL
+);
+CREATE INDEX IF NOT EXISTS post_audit_post_created_at_index ON post_audit (post, created_at, id);
@@ -0,0 +1,8 @@
+-- postgres has no ttl index, the idempotency repository removes the expired keys
+CREATE TABLE IF NOT EXISTS post_idempotency_keys (
+    id         TEXT        PRIMARY KEY,
+    body_hash  TEXT        NOT NULL,
+    post_id    TEXT        NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS post_idempotency_keys_expires_at_index ON post_idempotency_keys (expires_at);
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+
+	ctx := context.Background()
+
+	now := time.Now()
+	lru := NewLRU(2)
+	lru.now = func() time.Time { return now }
+
+	get := func(key string) string {
+		v, ok, err := lru.Get(ctx, key)
+		require.NoError(t, err)
+		if !ok {
+			return ""
+		}
+		return string(v)
+	}
+
+	t.Run("evicts least recently used", func(t *testing.T) {
+		require.NoError(t, lru.Set(ctx, "a", []byte("1"), time.Minute))
+		require.NoError(t, lru.Set(ctx, "b", []byte("2"), time.Minute))
+
+		// a becomes the most recently used, so b is evicted
+		require.Equal(t, "1", get("a"))
+		require.NoError(t, lru.Set(ctx, "c", []byte("3"), time.Minute))
+
+		require.Equal(t, "1", get("a"))
+		require.Equal(t, "", get("b"))
+		require.Equal(t, "3", get("c"))
+		require.Equal(t, 2, lru.Len())
+	})
+
+	t.Run("overwrites", func(t *testing.T) {
+		require.NoError(t, lru.Set(ctx, "a", []byte("4"), time.Minute))
+
+		require.Equal(t, "4", get("a"))
+		require.Equal(t, 2, lru.Len())
+	})
+
+	t.Run("expires", func(t *testing.T) {
+		require.NoError(t, lru.Set(ctx, "d", []byte("5"), time.Second))
+
+		now = now.Add(time.Second)
+
+		require.Equal(t, "", get("d"))
+		require.Equal(t, 1, lru.Len())
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		require.NoError(t, lru.Purge(ctx))
+
+		require.Equal(t, "", get("a"))
+		require.Equal(t, 0, lru.Len())
+	})
+
+}
@@ -45,4 +45,8 @@ var (
 	NotFound              = errors.New("not found")
 	InternalServerError   = errors.New("internal server error")
 	InvalidUriParam       = errors.New("invalid uri param")
+	Unauthorized          = errors.New("unauthorized")
+	Conflict              = errors.New("already exists")
+	Forbidden             = errors.New("forbidden")
+	TooManyRequests       = errors.New("too many requests")
 )
@@ -76,3 +76,13 @@ func handleValidationErr(err validator.ValidationErrors) (resp *ErrorResponse) {
 
 	return
 }
+
+// Validations - the error as a list of validation errors, errors without any field become a single entry
+func Validations(err error) []ErrorValidation {
+	resp := ParseError(err)
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+
+	return []ErrorValidation{{Message: resp.ErrError}}
+}
@@ -0,0 +1,99 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrExpiredCursor = errors.New("cursor has expired")
+)
+
+// Cursor - position of the last item of a page, used for keyset pagination.
+type Cursor struct {
+	// Sort - the ordering the cursor was issued for
+	Sort string `json:"o"`
+	// Key - value of the sort key of the last item
+	Key       float64 `json:"k"`
+	ID        string  `json:"i"`
+	ExpiresAt int64   `json:"e"`
+}
+
+// ObjectID - returns the id of the last item as mongo ObjectID.
+func (c *Cursor) ObjectID() primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(c.ID)
+	return id
+}
+
+// CursorCodec - encodes cursors into opaque signed tokens and decodes them back.
+type CursorCodec struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func NewCursorCodec(secret string, ttl time.Duration) *CursorCodec {
+	return &CursorCodec{
+		secret: []byte(secret),
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// Encode - returns the token for the given position, the token is valid for the codec ttl.
+func (cc *CursorCodec) Encode(sort string, key float64, id string) string {
+	c := Cursor{
+		Sort:      sort,
+		Key:       key,
+		ID:        id,
+		ExpiresAt: cc.now().Add(cc.ttl).Unix(),
+	}
+
+	// marshalling a struct of plain fields cannot fail
+	payload, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(cc.sign(payload))
+}
+
+// Decode - verifies the token signature and expiration and returns the cursor.
+func (cc *CursorCodec) Decode(token string) (*Cursor, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, cc.sign(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	c := &Cursor{}
+	if err = json.Unmarshal(payload, c); err != nil || !primitive.IsValidObjectID(c.ID) {
+		return nil, ErrInvalidCursor
+	}
+
+	if cc.now().Unix() > c.ExpiresAt {
+		return nil, ErrExpiredCursor
+	}
+
+	return c, nil
+}
+
+func (cc *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, cc.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
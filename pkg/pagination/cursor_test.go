@@ -0,0 +1,46 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorCodec(t *testing.T) {
+
+	codec := NewCursorCodec("secret", time.Hour)
+	id := primitive.NewObjectID()
+
+	t.Run("round trip", func(t *testing.T) {
+		c, err := codec.Decode(codec.Encode("top", 42, id.Hex()))
+
+		require.NoError(t, err)
+		require.Equal(t, "top", c.Sort)
+		require.Equal(t, float64(42), c.Key)
+		require.Equal(t, id, c.ObjectID())
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		token := codec.Encode("top", 42, id.Hex())
+		other := NewCursorCodec("other secret", time.Hour).Encode("top", 42, id.Hex())
+
+		for _, v := range []string{"", "abc", token + "x", "x" + token, other} {
+			_, err := codec.Decode(v)
+			require.Equal(t, ErrInvalidCursor, err, v)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		token := codec.Encode("top", 42, id.Hex())
+
+		expired := NewCursorCodec("secret", time.Hour)
+		expired.now = func() time.Time {
+			return time.Now().Add(time.Hour * 2)
+		}
+
+		_, err := expired.Decode(token)
+		require.Equal(t, ErrExpiredCursor, err)
+	})
+}
@@ -5,8 +5,10 @@ import "math"
 const defaultSize = 25
 
 type Query struct {
-	Size    int    `json:"size,omitempty" form:"size"`
-	Page    int    `json:"page,omitempty" form:"page"`
+	Size int `json:"size,omitempty" form:"size"`
+	Page int `json:"page,omitempty" form:"page"`
+	// Cursor - opaque next_cursor of the previous page, when set page is ignored
+	Cursor string `json:"cursor,omitempty" form:"cursor"`
 }
 
 func (q *Query) GetOffset() int {
@@ -33,6 +35,11 @@ func (q *Query) GetSize() int {
 	return q.Size
 }
 
+// IsCursor - reports whether the query uses keyset pagination instead of page/size.
+func (q *Query) IsCursor() bool {
+	return q.Cursor != ""
+}
+
 func GetTotalPages(totalCount int64, pageSize int) int {
 	d := float64(totalCount) / float64(pageSize)
 	return int(math.Ceil(d))
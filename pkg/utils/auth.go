@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"github.com/aliykh/reddit-feed/internal/auth"
+	"time"
+)
+
+// TestKeys - signing keys of the test access tokens
+var TestKeys, _ = auth.NewKeys(auth.HS256, "test-secret", "", "")
+
+// Bearer - returns the Authorization header of a test access token of the user, signed with the keys.
+func Bearer(keys *auth.Keys, user string) string {
+	token, _, _ := auth.NewIssuer(keys, time.Hour).Issue(&auth.Principal{UserID: user})
+	return "Bearer " + token
+}